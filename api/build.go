@@ -4,8 +4,14 @@
 package api
 
 import (
+	"context"
 	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
 
+	"github.com/openfaas/faas-cli/api/template"
 	"github.com/openfaas/faas-cli/builder"
 	"github.com/openfaas/faas-cli/options"
 	"github.com/openfaas/faas-cli/stack"
@@ -18,8 +24,8 @@ func Build(arg options.BuildOptions) error {
 	if arg.Services != nil {
 		services = *arg.Services
 	} else {
-		if len(arg.YamlFile) > 0 {
-			parsedServices, err := stack.ParseYAMLFile(arg.YamlFile, arg.Regex, arg.Filter)
+		if len(arg.YamlFiles) > 0 {
+			parsedServices, err := stack.ParseYAMLFiles(arg.YamlFiles, arg.Regex, arg.Filter)
 			if err != nil {
 				return err
 			}
@@ -30,12 +36,39 @@ func Build(arg options.BuildOptions) error {
 		}
 	}
 
-	if pullErr := Pull(options.TemplatePullOptions{URL: ""}); pullErr != nil {
-		return fmt.Errorf("could not pull templates for OpenFaaS: %v", pullErr)
+	languages := functionLanguages(services, arg.Language)
+
+	if !arg.Frozen && missingTemplates(languages) {
+		if pullErr := Pull(options.TemplatePullOptions{URL: "", Offline: arg.Offline}); pullErr != nil {
+			return fmt.Errorf("could not pull templates for OpenFaaS: %v", pullErr)
+		}
+	}
+
+	if err := verifyTemplates(languages, arg.Frozen); err != nil {
+		return err
+	}
+
+	if err := validateStrict(arg.FaasOptions); err != nil {
+		return err
+	}
+
+	buildArgMap, err := parseMap(arg.BuildArgs, "build-arg")
+	if err != nil {
+		return fmt.Errorf("error parsing build args: %v", err)
+	}
+
+	imageBuilder, err := newBuilder(arg.Engine, services)
+	if err != nil {
+		return err
+	}
+
+	ctx := arg.Context
+	if ctx == nil {
+		ctx = context.Background()
 	}
 
 	if len(services.Functions) > 0 {
-		return builder.BuildStack(&services, arg.Parallel, arg.Nocache, arg.Squash, arg.Shrinkwrap)
+		return builder.BuildStack(ctx, imageBuilder, &services, arg.Parallel, arg.Nocache, arg.Squash, arg.Shrinkwrap, buildArgMap, arg.BuildOptions, arg.Force, arg.FailFast, arg.Quiet)
 	}
 
 	if len(arg.Image) == 0 {
@@ -48,6 +81,8 @@ func Build(arg options.BuildOptions) error {
 		return fmt.Errorf("please provide the deployed --name of your function")
 	}
 	if err := builder.BuildImage(
+		ctx,
+		imageBuilder,
 		arg.Image,
 		arg.Handler,
 		arg.FunctionName,
@@ -55,9 +90,56 @@ func Build(arg options.BuildOptions) error {
 		arg.Nocache,
 		arg.Squash,
 		arg.Shrinkwrap,
+		buildArgMap,
+		arg.BuildOptions,
 	); err != nil {
 		return err
 	}
 
 	return nil
 }
+
+// functionLanguages returns the languages used by the functions being built
+func functionLanguages(services stack.Services, language string) []string {
+	var languages []string
+	for _, function := range services.Functions {
+		languages = append(languages, function.Language)
+	}
+	if len(language) > 0 {
+		languages = append(languages, language)
+	}
+	sort.Strings(languages)
+	return languages
+}
+
+// missingTemplates tells whether a language has no template in the template directory
+func missingTemplates(languages []string) bool {
+	for _, language := range languages {
+		if len(language) == 0 || strings.ToLower(language) == "dockerfile" {
+			continue
+		}
+		if _, err := os.Stat(filepath.Join(template.GetTemplateDirectory(), language)); err != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// verifyTemplates checks the templates used by the build against template.lock,
+// drift is only an error in frozen mode
+func verifyTemplates(languages []string, frozen bool) error {
+	err := template.VerifyLock(languages)
+	if _, drifted := err.(*template.DriftError); drifted && !frozen {
+		fmt.Printf("Warning: %s\nRun \"faas-cli template pull --frozen\" to restore them.\n", err)
+		return nil
+	}
+	return err
+}
+
+// newBuilder selects the build engine from the --engine flag, falling back to provider.builder in the stack file
+func newBuilder(engine string, services stack.Services) (builder.Builder, error) {
+	if len(engine) == 0 {
+		engine = services.Provider.Builder
+	}
+	return builder.NewBuilder(engine)
+}
@@ -6,8 +6,10 @@ package api
 import (
 	"fmt"
 	"io/ioutil"
+	"net/http"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 
 	"gopkg.in/yaml.v2"
@@ -28,12 +30,16 @@ func Deploy(arg options.DeployOptions) error {
 		return fmt.Errorf("cannot specify --update and --replace at the same time")
 	}
 
+	if err := validateStrict(arg.FaasOptions); err != nil {
+		return err
+	}
+
 	var services stack.Services
 	if arg.Services != nil {
 		services = *arg.Services
 	} else {
-		if len(arg.YamlFile) > 0 {
-			parsedServices, err := stack.ParseYAMLFile(arg.YamlFile, arg.Regex, arg.Filter)
+		if len(arg.YamlFiles) > 0 {
+			parsedServices, err := stack.ParseYAMLFiles(arg.YamlFiles, arg.Regex, arg.Filter)
 			if err != nil {
 				return err
 			}
@@ -56,6 +62,10 @@ func Deploy(arg options.DeployOptions) error {
 			services.Provider.Network = DefaultNetwork
 		}
 
+		var availableSecrets map[string]bool
+		secretsListed := false
+		var failed []string
+
 		for k, function := range services.Functions {
 
 			function.Name = k
@@ -103,12 +113,28 @@ func Deploy(arg options.DeployOptions) error {
 				}
 			}
 
+			if err := validateResources(function.Limits); err != nil {
+				return fmt.Errorf("invalid limits for function %s: %v", function.Name, err)
+			}
+			if err := validateResources(function.Requests); err != nil {
+				return fmt.Errorf("invalid requests for function %s: %v", function.Name, err)
+			}
+
 			functionResourceRequest1 := proxy.FunctionResourceRequest{
 				Limits:   function.Limits,
 				Requests: function.Requests,
 			}
 
-			proxy.DeployFunction(
+			functionSecrets := stack.MergeList(function.Secrets, arg.Secrets)
+			if len(functionSecrets) > 0 {
+				if !secretsListed {
+					availableSecrets = lookupSecrets(services.Provider.GatewayURL)
+					secretsListed = true
+				}
+				warnMissingSecrets(function.Name, functionSecrets, availableSecrets)
+			}
+
+			result, err := proxy.DeployFunction(
 				function.FProcess,
 				services.Provider.GatewayURL,
 				function.Name,
@@ -119,10 +145,19 @@ func Deploy(arg options.DeployOptions) error {
 				services.Provider.Network,
 				functionConstraints,
 				arg.Update,
-				arg.Secrets,
+				functionSecrets,
 				allLabels,
 				functionResourceRequest1,
 			)
+			printDeployResult(result, err)
+			if err != nil {
+				failed = append(failed, function.Name)
+			}
+		}
+
+		if len(failed) > 0 {
+			sort.Strings(failed)
+			return fmt.Errorf("failed to deploy %d of %d functions: %s", len(failed), len(services.Functions), strings.Join(failed, ", "))
 		}
 	} else {
 		if len(arg.Image) == 0 {
@@ -141,8 +176,23 @@ func Deploy(arg options.DeployOptions) error {
 		if labelErr != nil {
 			return fmt.Errorf("error parsing labels: %v", labelErr)
 		}
-		functionResourceRequest1 := proxy.FunctionResourceRequest{}
-		proxy.DeployFunction(
+
+		functionResourceRequest1 := proxy.FunctionResourceRequest{
+			Limits:   &stack.FunctionResources{Memory: arg.LimitMemory, CPU: arg.LimitCPU},
+			Requests: &stack.FunctionResources{Memory: arg.RequestMemory, CPU: arg.RequestCPU},
+		}
+		if err := validateResources(functionResourceRequest1.Limits); err != nil {
+			return fmt.Errorf("invalid limits: %v", err)
+		}
+		if err := validateResources(functionResourceRequest1.Requests); err != nil {
+			return fmt.Errorf("invalid requests: %v", err)
+		}
+
+		if len(arg.Secrets) > 0 {
+			warnMissingSecrets(arg.FunctionName, arg.Secrets, lookupSecrets(arg.Gateway))
+		}
+
+		result, err := proxy.DeployFunction(
 			arg.Fprocess,
 			arg.Gateway,
 			arg.FunctionName,
@@ -157,6 +207,49 @@ func Deploy(arg options.DeployOptions) error {
 			labelMap,
 			functionResourceRequest1,
 		)
+		printDeployResult(result, err)
+		if err != nil {
+			return fmt.Errorf("failed to deploy %s: %s", arg.FunctionName, err)
+		}
+	}
+
+	return nil
+}
+
+// printDeployResult prints the outcome of deploying a function
+func printDeployResult(result proxy.FunctionResult, err error) {
+	if err == nil {
+		fmt.Printf("%s. %d %s.\n", result.Message, result.StatusCode, http.StatusText(result.StatusCode))
+		fmt.Printf("URL: %s\n\n", result.URL)
+		return
+	}
+
+	switch err.(type) {
+	case *proxy.ErrUnauthorized:
+		// the message tells the user how to log in
+	case *proxy.ErrGatewayUnreachable:
+		fmt.Println("Is FaaS deployed? Do you need to specify the --gateway flag?")
+	default:
+		if statusErr, ok := proxy.AsStatusError(err); ok {
+			fmt.Printf("Unexpected status: %d, message: %s\n\n", statusErr.StatusCode, statusErr.Body)
+			return
+		}
+	}
+	fmt.Printf("%s\n\n", result.Message)
+}
+
+// validateResources checks the format of memory and CPU quantities before they are sent to the gateway
+func validateResources(resources *stack.FunctionResources) error {
+	if resources == nil {
+		return nil
+	}
+
+	if len(resources.Memory) > 0 && !stack.IsValidMemoryQuantity(resources.Memory) {
+		return fmt.Errorf("memory quantity %q is not valid, use a value such as 128m or 1Gi", resources.Memory)
+	}
+
+	if len(resources.CPU) > 0 && !stack.IsValidCPUQuantity(resources.CPU) {
+		return fmt.Errorf("cpu quantity %q is not valid, use a value such as 100m or 0.5", resources.CPU)
 	}
 
 	return nil
@@ -219,6 +312,35 @@ func parseMap(envvars []string, keyName string) (map[string]string, error) {
 	return result, nil
 }
 
+// lookupSecrets returns the names of the secrets known to the gateway, or nil
+// when they cannot be listed, i.e. on older gateways.
+func lookupSecrets(gateway string) map[string]bool {
+	secrets, err := proxy.ListSecrets(gateway)
+	if err != nil {
+		fmt.Printf("Unable to verify secrets on the gateway: %s\n", err)
+		return nil
+	}
+
+	available := make(map[string]bool)
+	for _, secret := range secrets {
+		available[secret.Name] = true
+	}
+	return available
+}
+
+// warnMissingSecrets prints a warning for each secret which is not present on the gateway
+func warnMissingSecrets(functionName string, secrets []string, available map[string]bool) {
+	if available == nil {
+		return
+	}
+
+	for _, secret := range secrets {
+		if !available[secret] {
+			fmt.Printf("WARNING! Secret %s used by function %s was not found on the gateway.\n", secret, functionName)
+		}
+	}
+}
+
 func mergeMap(i map[string]string, j map[string]string) map[string]string {
 	merged := make(map[string]string)
 
@@ -17,8 +17,8 @@ func List(arg options.ListOptions) ([]requests.Function, error) {
 	if arg.Services != nil {
 		services = *arg.Services
 	} else {
-		if len(arg.YamlFile) > 0 {
-			parsedServices, err := stack.ParseYAMLFile(arg.YamlFile, arg.Regex, arg.Filter)
+		if len(arg.YamlFiles) > 0 {
+			parsedServices, err := stack.ParseYAMLFiles(arg.YamlFiles, arg.Regex, arg.Filter)
 			if err != nil {
 				return nil, err
 			}
@@ -1,7 +1,7 @@
 package api
 
 import (
-	"crypto/tls"
+	"context"
 	"fmt"
 	"io/ioutil"
 	"net/http"
@@ -10,34 +10,127 @@ import (
 
 	"github.com/openfaas/faas-cli/config"
 	"github.com/openfaas/faas-cli/options"
+	"github.com/openfaas/faas-cli/proxy"
 )
 
 //Login to a OpenFaaS gateway
 func Login(arg options.LoginOptions) error {
 
 	gateway := strings.TrimRight(strings.TrimSpace(arg.Gateway), "/")
-	if err := validateLogin(gateway, arg.Username, arg.Password); err != nil {
+
+	var saved string
+	var err error
+	switch {
+	case len(arg.Token) > 0:
+		saved, err = loginWithToken(gateway, arg.Token)
+	case len(arg.ClientID) > 0:
+		saved, err = loginWithClientCredentials(gateway, arg)
+	default:
+		saved, err = loginWithPassword(gateway, arg.Username, arg.Password)
+	}
+	if err != nil {
 		return err
 	}
 
-	if err := config.UpdateAuthConfig(gateway, arg.Username, arg.Password); err != nil {
-		return err
+	// the TLS flags used to log in are saved for the gateway
+	if !config.DefaultTLSConfig.IsZero() {
+		if err := config.UpdateTLSConfig(gateway, config.DefaultTLSConfig); err != nil {
+			return err
+		}
+		fmt.Println("TLS settings saved for", gateway)
+	}
+
+	fmt.Println(saved, "saved for", gateway)
+
+	return nil
+}
+
+// loginWithPassword saves a username and password for basic auth
+func loginWithPassword(gateway string, username string, password string) (string, error) {
+	if err := validateLogin(gateway, &proxy.BasicAuth{Username: username, Password: password}); err != nil {
+		return "", err
+	}
+
+	if err := config.UpdateAuthConfig(gateway, username, password); err != nil {
+		return "", err
 	}
 
 	user, _, err := config.LookupAuthConfig(gateway)
 	if err != nil {
-		return err
+		return "", err
 	}
-	fmt.Println("credentials saved for", user, gateway)
+	return "credentials for " + user, nil
+}
 
-	return nil
+// loginWithToken saves a static bearer token
+func loginWithToken(gateway string, token string) (string, error) {
+	if err := validateLogin(gateway, &proxy.BearerAuth{Token: token}); err != nil {
+		return "", err
+	}
+
+	if err := config.UpdateBearerAuthConfig(gateway, token); err != nil {
+		return "", err
+	}
+	return "token", nil
 }
 
-func validateLogin(url string, user string, pass string) error {
-	// TODO: provide --insecure flag for this
+// loginWithClientCredentials fetches a token to check the OAuth2 client
+// credentials, then saves them along with the token
+func loginWithClientCredentials(gateway string, arg options.LoginOptions) (string, error) {
+	gatewayTLS, err := config.GatewayTLSConfig(gateway)
+	if err != nil {
+		return "", err
+	}
+
+	credentials := proxy.ClientCredentials{
+		TokenURL:     arg.TokenURL,
+		ClientID:     arg.ClientID,
+		ClientSecret: arg.ClientSecret,
+		Scopes:       arg.Scopes,
+		TLS:          gatewayTLS,
+	}
+
+	token, err := credentials.Token(context.Background())
+	if err != nil {
+		return "", err
+	}
+
+	if err := validateLogin(gateway, &proxy.BearerAuth{Token: token.AccessToken}); err != nil {
+		return "", err
+	}
+
+	err = config.UpdateOAuth2AuthConfig(gateway, config.OAuth2Config{
+		TokenURL:     arg.TokenURL,
+		ClientID:     arg.ClientID,
+		ClientSecret: arg.ClientSecret,
+		Scopes:       arg.Scopes,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	if err := config.UpdateOAuth2Token(gateway, token.AccessToken, token.Expiry); err != nil {
+		return "", err
+	}
+	return "client credentials for " + arg.ClientID, nil
+}
+
+func validateLogin(url string, auth proxy.ClientAuth) error {
+	gatewayTLS, err := config.GatewayTLSConfig(url)
+	if err != nil {
+		return err
+	}
+	tlsConfig, err := gatewayTLS.ClientConfig()
+	if err != nil {
+		return err
+	}
+	if gatewayTLS.InsecureSkipVerify {
+		fmt.Println("WARNING! TLS certificates are not verified, anyone on the network could read the credentials.")
+	}
+
 	tr := &http.Transport{
 		DisableKeepAlives: true,
-		TLSClientConfig:   &tls.Config{InsecureSkipVerify: true},
+		TLSClientConfig:   tlsConfig,
 	}
 	client := &http.Client{
 		Transport: tr,
@@ -47,11 +140,13 @@ func validateLogin(url string, user string, pass string) error {
 	// TODO: implement ping in the gateway API and call that
 	gatewayURL := strings.TrimRight(url, "/")
 	req, _ := http.NewRequest("GET", gatewayURL+"/system/functions", nil)
-	req.SetBasicAuth(user, pass)
+	if err := auth.Set(req); err != nil {
+		return err
+	}
 
 	res, err := client.Do(req)
 	if err != nil {
-		return fmt.Errorf("cannot connect to OpenFaaS on URL: %s", gatewayURL)
+		return fmt.Errorf("cannot connect to OpenFaaS on URL: %s, %s", gatewayURL, err)
 	}
 
 	if res.Body != nil {
@@ -66,7 +161,10 @@ func validateLogin(url string, user string, pass string) error {
 	case http.StatusOK:
 		return nil
 	case http.StatusUnauthorized:
-		return fmt.Errorf("unable to login, either username or password is incorrect")
+		if _, basic := auth.(*proxy.BasicAuth); basic {
+			return fmt.Errorf("unable to login, either username or password is incorrect")
+		}
+		return fmt.Errorf("unable to login, the token was rejected")
 	default:
 		bytesOut, err := ioutil.ReadAll(res.Body)
 		if err == nil {
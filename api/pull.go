@@ -9,9 +9,23 @@ import (
 
 //Pull templates from an URL
 func Pull(arg options.TemplatePullOptions) error {
-	repository := arg.URL
-	log.Printf("Fetch templates from repository: %s\n", repository)
-	err := template.FetchTemplates(arg.URL, arg.Overwrite)
+	mode := template.PreferCache
+	if arg.Refresh {
+		mode = template.RefreshCache
+	}
+	if arg.Offline {
+		mode = template.OfflineCache
+	}
+
+	var err error
+	if arg.Frozen {
+		log.Printf("Restore templates from %s\n", template.LockFile)
+		err = template.FetchLockedTemplates(mode)
+	} else {
+		repository := arg.URL
+		log.Printf("Fetch templates from repository: %s\n", repository)
+		err = template.FetchTemplates(arg.URL, arg.Path, arg.Overwrite, mode)
+	}
 	if err != nil {
 		log.Printf("Error: %s", err.Error())
 	}
@@ -1,8 +1,9 @@
 package api
 
 import (
+	"context"
 	"fmt"
-	"sync"
+	"os"
 
 	"github.com/openfaas/faas-cli/builder"
 	"github.com/openfaas/faas-cli/options"
@@ -12,12 +13,16 @@ import (
 //Push a function to repository
 func Push(arg options.PushOptions) error {
 
+	if err := validateStrict(arg.FaasOptions); err != nil {
+		return err
+	}
+
 	var services stack.Services
 	if arg.Services != nil {
 		services = *arg.Services
 	} else {
-		if len(arg.YamlFile) > 0 {
-			parsedServices, err := stack.ParseYAMLFile(arg.YamlFile, arg.Regex, arg.Filter)
+		if len(arg.YamlFiles) > 0 {
+			parsedServices, err := stack.ParseYAMLFiles(arg.YamlFiles, arg.Regex, arg.Filter)
 			if err != nil {
 				return err
 			}
@@ -28,48 +33,37 @@ func Push(arg options.PushOptions) error {
 		}
 	}
 
-	if len(services.Functions) > 0 {
-		pushStack(&services, arg.Parallel)
-	} else {
+	if len(services.Functions) == 0 {
 		return fmt.Errorf("you must supply a valid YAML file")
 	}
-	return nil
-}
-
-func pushImage(image string) {
-	builder.ExecCommand("./", []string{"docker", "push", image})
-}
-
-func pushStack(services *stack.Services, queueDepth int) {
-	wg := sync.WaitGroup{}
-
-	workChannel := make(chan stack.Function)
 
-	for i := 0; i < queueDepth; i++ {
-
-		go func(index int) {
-			wg.Add(1)
-			for function := range workChannel {
-				fmt.Printf("[%d] > Pushing: %s.\n", index, function.Name)
-				if len(function.Image) == 0 {
-					fmt.Println("Please provide a valid Image value in the YAML file.")
-				} else {
-					pushImage(function.Image)
-				}
-			}
-
-			fmt.Printf("[%d] < Pushing done.\n", index)
-			wg.Done()
-		}(i)
+	imageBuilder, err := newBuilder(arg.Engine, services)
+	if err != nil {
+		return err
 	}
 
-	for k, function := range services.Functions {
-		function.Name = k
-		workChannel <- function
+	ctx := arg.Context
+	if ctx == nil {
+		ctx = context.Background()
 	}
 
-	close(workChannel)
+	return pushStack(ctx, imageBuilder, &services, arg.Parallel, arg.FailFast)
+}
+
+func pushStack(ctx context.Context, imageBuilder builder.Builder, services *stack.Services, queueDepth int, failFast bool) error {
+	results, err := builder.RunStack(ctx, services, queueDepth, failFast, func(ctx context.Context, index int, function stack.Function) (string, error) {
+		fmt.Printf("[%d] > Pushing: %s.\n", index, function.Name)
+		if len(function.Image) == 0 {
+			return "", fmt.Errorf("please provide a valid image value in the YAML file")
+		}
+
+		if err := imageBuilder.Push(ctx, function.Image); err != nil {
+			return "", err
+		}
+		return "pushed", nil
+	})
 
-	wg.Wait()
+	builder.PrintStackSummary(os.Stdout, results)
 
+	return err
 }
@@ -0,0 +1,37 @@
+package api
+
+import (
+	"github.com/openfaas/faas-cli/api/template"
+	"github.com/openfaas/faas-cli/options"
+)
+
+//StoreList returns the templates in the store for a platform
+func StoreList(arg options.TemplateStoreOptions) ([]template.StoreTemplate, error) {
+	templates, err := template.ReadStore(template.GetStoreURL(arg.URL))
+	if err != nil {
+		return nil, err
+	}
+	return template.FilterStore(templates, arg.Platform), nil
+}
+
+//StoreDescribe returns a template from the store
+func StoreDescribe(arg options.TemplateStoreOptions, name string) (template.StoreTemplate, error) {
+	templates, err := template.ReadStore(template.GetStoreURL(arg.URL))
+	if err != nil {
+		return template.StoreTemplate{}, err
+	}
+	return template.FindStoreTemplate(templates, name, arg.Platform)
+}
+
+//StorePull pulls the repository of a template in the store
+func StorePull(arg options.TemplateStoreOptions, name string) error {
+	storeTemplate, err := StoreDescribe(arg, name)
+	if err != nil {
+		return err
+	}
+
+	return Pull(options.TemplatePullOptions{
+		URL:       storeTemplate.Repository,
+		Overwrite: arg.Overwrite,
+	})
+}
@@ -0,0 +1,231 @@
+// Copyright (c) OpenFaaS Project 2018. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package template
+
+import (
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mitchellh/go-homedir"
+	"github.com/openfaas/faas-cli/config"
+	yaml "gopkg.in/yaml.v2"
+)
+
+// CacheFolder is the folder of the OpenFaaS config directory which caches templates
+const CacheFolder = "templates"
+
+// cacheMetadataFile records where a cache entry was fetched from
+const cacheMetadataFile = "source.yml"
+
+// CacheMode is how the template cache is used when fetching templates
+type CacheMode int
+
+const (
+	// PreferCache uses cached templates when there are some, otherwise they are fetched and cached
+	PreferCache CacheMode = iota
+	// RefreshCache always fetches templates and replaces the cached copy
+	RefreshCache
+	// OfflineCache only uses cached templates and never touches the network
+	OfflineCache
+)
+
+// cacheKeyPattern matches the characters which are replaced in the folder of a cache entry
+var cacheKeyPattern = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// CachedTemplates is an entry of the template cache, holding the templates
+// of one source and ref
+type CachedTemplates struct {
+	Source    string    `yaml:"source"`
+	Ref       string    `yaml:"ref,omitempty"`
+	Commit    string    `yaml:"commit,omitempty"`
+	Path      string    `yaml:"path"`
+	Fetched   time.Time `yaml:"fetched"`
+	Languages []string  `yaml:"languages"`
+
+	// Dir is the folder of the entry
+	Dir string `yaml:"-"`
+}
+
+// GetCacheDirectory returns the folder of the template cache
+func GetCacheDirectory() (string, error) {
+	return homedir.Expand(filepath.Join(config.DefaultDir, CacheFolder))
+}
+
+// cacheKey returns the folder of a cache entry, such as github.com_openfaas_faas-cli@master
+func cacheKey(source string, ref string, templatePath string) string {
+	source = source[strings.Index(source, "://")+1:]
+	key := strings.Trim(cacheKeyPattern.ReplaceAllString(source, "_"), "_")
+	if len(ref) > 0 {
+		key += "@" + cacheKeyPattern.ReplaceAllString(ref, "_")
+	}
+	if templatePath != DefaultTemplatePath {
+		key += "+" + cacheKeyPattern.ReplaceAllString(templatePath, "_")
+	}
+	return key
+}
+
+// cacheTemplates returns the cache entry for a source and ref, fetching it
+// as the CacheMode tells
+func cacheTemplates(source string, ref string, templatePath string, mode CacheMode) (*CachedTemplates, error) {
+	cacheDir, err := GetCacheDirectory()
+	if err != nil {
+		return nil, err
+	}
+
+	cached, err := readCacheEntry(filepath.Join(cacheDir, cacheKey(source, ref, templatePath)))
+	switch {
+	case mode == OfflineCache && err != nil:
+		return nil, fmt.Errorf("%s is not in the template cache, pull it once without --offline: %v", describeSource(source, ref), err)
+	case mode == OfflineCache, mode == PreferCache && err == nil:
+		log.Printf("Using templates from %s cached %s\n", describeSource(source, ref), cached.Fetched.Format(time.RFC3339))
+		return cached, nil
+	}
+
+	return fetchIntoCache(cacheDir, source, ref, templatePath)
+}
+
+// fetchIntoCache fetches the templates of a source into the cache, replacing an older copy
+func fetchIntoCache(cacheDir string, source string, ref string, templatePath string) (*CachedTemplates, error) {
+	templates, err := openTemplateSource(source, ref)
+	if err != nil {
+		return nil, err
+	}
+	defer templates.Close()
+
+	if err := os.MkdirAll(cacheDir, 0755); err != nil {
+		return nil, fmt.Errorf("unable to create the template cache: %v", err)
+	}
+
+	stagingDir, err := ioutil.TempDir(cacheDir, ".fetch-")
+	if err != nil {
+		return nil, fmt.Errorf("unable to create a temporary directory for templates: %v", err)
+	}
+	defer os.RemoveAll(stagingDir)
+
+	stagedEntry := filepath.Join(stagingDir, "entry")
+	writeAll := func(relativePath string, isDirectory bool) (bool, error) { return true, nil }
+	if err := stageTemplates(templates, templatePath, stagedEntry, writeAll); err != nil {
+		return nil, err
+	}
+
+	cached := &CachedTemplates{
+		Source:  source,
+		Ref:     ref,
+		Commit:  templates.commit(),
+		Path:    templatePath,
+		Fetched: time.Now().UTC(),
+		Dir:     filepath.Join(cacheDir, cacheKey(source, ref, templatePath)),
+	}
+
+	folders, _ := ioutil.ReadDir(filepath.Join(stagedEntry, DefaultTemplatePath))
+	for _, folder := range folders {
+		if folder.IsDir() {
+			cached.Languages = append(cached.Languages, folder.Name())
+		}
+	}
+	if len(cached.Languages) == 0 {
+		return nil, fmt.Errorf("no templates were found in the %s folder of %s", templatePath, describeSource(source, ref))
+	}
+
+	data, err := yaml.Marshal(cached)
+	if err != nil {
+		return nil, err
+	}
+	if err := ioutil.WriteFile(filepath.Join(stagedEntry, cacheMetadataFile), data, 0644); err != nil {
+		return nil, err
+	}
+
+	if err := replaceDir(stagedEntry, cached.Dir, filepath.Join(stagingDir, "previous")); err != nil {
+		return nil, fmt.Errorf("unable to update the template cache: %v", err)
+	}
+	return cached, nil
+}
+
+// readCacheEntry reads the metadata of a cache entry
+func readCacheEntry(dir string) (*CachedTemplates, error) {
+	data, err := ioutil.ReadFile(filepath.Join(dir, cacheMetadataFile))
+	if err != nil {
+		return nil, err
+	}
+
+	cached := &CachedTemplates{}
+	if err := yaml.Unmarshal(data, cached); err != nil {
+		return nil, fmt.Errorf("unable to parse %s: %v", filepath.Join(dir, cacheMetadataFile), err)
+	}
+	cached.Dir = dir
+	return cached, nil
+}
+
+// ListCache returns the entries of the template cache sorted by source and ref
+func ListCache() ([]CachedTemplates, error) {
+	cacheDir, err := GetCacheDirectory()
+	if err != nil {
+		return nil, err
+	}
+
+	folders, err := ioutil.ReadDir(cacheDir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	var entries []CachedTemplates
+	for _, folder := range folders {
+		if !folder.IsDir() || strings.HasPrefix(folder.Name(), ".") {
+			continue
+		}
+		cached, err := readCacheEntry(filepath.Join(cacheDir, folder.Name()))
+		if err != nil {
+			// an entry without metadata was not written by faas-cli
+			continue
+		}
+		entries = append(entries, *cached)
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].Source == entries[j].Source {
+			return entries[i].Ref < entries[j].Ref
+		}
+		return entries[i].Source < entries[j].Source
+	})
+	return entries, nil
+}
+
+// PruneCache removes the cache entries fetched longer ago than olderThan, or
+// every entry when olderThan is zero. When source is given only its entries
+// are removed.
+func PruneCache(source string, olderThan time.Duration) ([]CachedTemplates, error) {
+	entries, err := ListCache()
+	if err != nil {
+		return nil, err
+	}
+
+	var pruned []CachedTemplates
+	for _, cached := range entries {
+		if len(source) > 0 && cached.Source != source {
+			continue
+		}
+		if olderThan > 0 && time.Since(cached.Fetched) < olderThan {
+			continue
+		}
+
+		if err := os.RemoveAll(cached.Dir); err != nil {
+			return pruned, err
+		}
+		pruned = append(pruned, cached)
+	}
+	return pruned, nil
+}
+
+func describeSource(source string, ref string) string {
+	return LockedTemplate{Source: source, Ref: ref}.String()
+}
@@ -0,0 +1,110 @@
+// Copyright (c) OpenFaaS Project 2018. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package template
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/openfaas/faas-cli/config"
+)
+
+// TestMain keeps the template cache of the tests out of the home directory
+func TestMain(m *testing.M) {
+	config.DefaultDir, _ = ioutil.TempDir("", "faas-cli-template-test")
+	code := m.Run()
+	os.RemoveAll(config.DefaultDir)
+	os.Exit(code)
+}
+
+func Test_cacheKey(t *testing.T) {
+	keys := []struct {
+		source       string
+		ref          string
+		templatePath string
+		want         string
+	}{
+		{"https://github.com/openfaas/faas-cli", "master", "template", "github.com_openfaas_faas-cli@master"},
+		{"https://github.com/owner/repo/", "feature/templates", "openfaas/templates", "github.com_owner_repo@feature_templates+openfaas_templates"},
+		{"git@git.internal:team/templates.git", "v1.2.0", "template", "git_git.internal_team_templates.git@v1.2.0"},
+		{"https://example.com/templates.tar.gz", "", "template", "example.com_templates.tar.gz"},
+	}
+
+	for _, key := range keys {
+		if got := cacheKey(key.source, key.ref, key.templatePath); got != key.want {
+			t.Errorf("%s: want %s, got %s", key.source, key.want, got)
+		}
+	}
+}
+
+func Test_FetchTemplates_Cache(t *testing.T) {
+	workDir, archivePath := writeTemplateZip(t, nodeTemplate)
+	defer os.RemoveAll(workDir)
+	defer SetWorkDirectory("./")
+	defer PruneCache("", 0)
+
+	requests := 0
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		http.ServeFile(w, r, archivePath)
+	}))
+	repository := ts.URL + "/owner/repo"
+
+	if err := FetchTemplates(repository, "", false, OfflineCache); err == nil || !strings.Contains(err.Error(), "is not in the template cache") {
+		t.Fatalf("want an error for templates which were never cached, got %v", err)
+	}
+
+	pullInto := func(mode CacheMode) string {
+		projectDir, _ := ioutil.TempDir(workDir, "project")
+		SetWorkDirectory(projectDir)
+		if err := FetchTemplates(repository, "", false, mode); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		return projectDir
+	}
+
+	pullInto(PreferCache)
+	projectDir := pullInto(PreferCache)
+	if requests != 1 {
+		t.Errorf("want the second project to use the cache, %d requests were made", requests)
+	}
+	if _, err := os.Stat(filepath.Join(projectDir, "template", "node", "template.yml")); err != nil {
+		t.Errorf("want node expanded from the cache: %s", err)
+	}
+	if target, err := os.Readlink(filepath.Join(projectDir, "template", "node", "current")); err != nil || target != "template.yml" {
+		t.Errorf("want symlinks kept by the cache, got %q %v", target, err)
+	}
+
+	pullInto(RefreshCache)
+	if requests != 2 {
+		t.Errorf("want the cache refreshed, %d requests were made", requests)
+	}
+
+	ts.Close()
+	pullInto(OfflineCache)
+
+	entries, err := ListCache()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].Source != repository || entries[0].Ref != "master" || strings.Join(entries[0].Languages, ",") != "node" {
+		t.Fatalf("want one cache entry for node, got %#v", entries)
+	}
+
+	if pruned, _ := PruneCache("", time.Hour); len(pruned) != 0 {
+		t.Errorf("want recently fetched templates kept, pruned %v", pruned)
+	}
+	if pruned, _ := PruneCache(repository, 0); len(pruned) != 1 {
+		t.Errorf("want the source pruned, pruned %v", pruned)
+	}
+	if entries, _ := ListCache(); len(entries) != 0 {
+		t.Errorf("want an empty cache, got %v", entries)
+	}
+}
@@ -6,13 +6,14 @@ package template
 import (
 	"archive/zip"
 	"fmt"
-	"io"
 	"io/ioutil"
 	"log"
 	"net/http"
 	"net/url"
 	"os"
+	"path"
 	"path/filepath"
+	"regexp"
 	"strings"
 	"time"
 
@@ -22,6 +23,21 @@ import (
 const (
 	defaultTemplateRepository = "https://github.com/openfaas/faas-cli"
 	rootLanguageDirSplitCount = 3
+
+	// DefaultTemplateRef is the branch pulled when a repository has no #ref
+	DefaultTemplateRef = "master"
+	// DefaultTemplatePath is the folder of a repository which holds the templates
+	DefaultTemplatePath = "template"
+)
+
+// commitPattern matches the commit id GitHub stores in the comment of an archive
+var commitPattern = regexp.MustCompile(`^[0-9a-f]{40}$`)
+
+var (
+	// maxTemplateFileSize is the largest single file accepted from a template archive
+	maxTemplateFileSize int64 = 50 * 1024 * 1024
+	// maxTemplateArchiveSize is the most data accepted from a template archive in total
+	maxTemplateArchiveSize int64 = 500 * 1024 * 1024
 )
 
 const defaultWorkDir = "./"
@@ -60,22 +76,27 @@ const (
 	skipWritingData
 )
 
-// FetchTemplates fetch code templates from a remote URL.
-func FetchTemplates(templateURL string, overwrite bool) error {
-
-	if len(templateURL) == 0 {
-		templateURL = defaultTemplateRepository
+// FetchTemplates fetch code templates from a repository, see openTemplateSource
+// for the sources supported. The URL may end with #ref to pull a branch or tag
+// other than DefaultTemplateRef, and templatePath selects the folder of the
+// repository holding the templates, DefaultTemplatePath when empty. The source
+// of each template fetched is recorded in the LockFile. Every source but a
+// file:// directory or archive is kept in the template cache, including
+// archives downloaded from a URL, and the cache is used as the CacheMode tells.
+func FetchTemplates(templateURL string, templatePath string, overwrite bool, mode CacheMode) error {
+
+	repositoryURL, ref := ParseRepository(templateURL)
+	if len(ref) > 0 && !hasRefs(repositoryURL) {
+		return fmt.Errorf("a #ref can not be given for %s, only for repositories", repositoryURL)
 	}
 
-	archive, err := fetchMasterZip(templateURL, GetWorkDirectory())
+	templatePath, err := cleanTemplatePath(templatePath)
 	if err != nil {
-		removeArchive(archive)
 		return err
 	}
 
-	log.Printf("Attempting to expand templates from %s\n", archive)
-
-	preExistingLanguages, fetchedLanguages, err := expandTemplatesFromZip(archive, overwrite)
+	source := LockedTemplate{Source: repositoryURL, Ref: ref, Path: templatePath}
+	preExistingLanguages, fetchedLanguages, err := pullTemplates(&source, overwrite, nil, mode)
 	if err != nil {
 		return err
 	}
@@ -84,86 +105,390 @@ func FetchTemplates(templateURL string, overwrite bool) error {
 		log.Printf("Cannot overwrite the following %d directories: %v\n", len(preExistingLanguages), preExistingLanguages)
 	}
 
-	log.Printf("Fetched %d template(s) : %v from %s\n", len(fetchedLanguages), fetchedLanguages, templateURL)
+	log.Printf("Fetched %d template(s) : %v from %s\n", len(fetchedLanguages), fetchedLanguages, source)
 
-	err = removeArchive(archive)
-	if err != nil {
-		return err
+	if err := lockTemplates(fetchedLanguages, source); err != nil {
+		return fmt.Errorf("unable to record templates in %s: %v", LockFile, err)
 	}
 
 	return nil
 }
 
-// expandTemplatesFromZip builds a list of languages that: already exist and
-// could not be overwritten and // a list of languages that are newly downloaded.
-func expandTemplatesFromZip(archivePath string, overwrite bool) ([]string, []string, error) {
-	var existingLanguages []string
-	var fetchedLanguages []string
+// pullTemplates expands the templates of a source into the template directory
+// and records the commit they were fetched at in the source
+func pullTemplates(source *LockedTemplate, overwrite bool, languages []string, mode CacheMode) ([]string, []string, error) {
+	ref := source.Ref
+	if len(source.Commit) > 0 {
+		ref = source.Commit
+	}
 
-	availableLanguages := make(map[string]bool)
+	var templates templateSource
+	templatePath := source.Path
 
-	zipFile, err := zip.OpenReader(archivePath)
+	if strings.HasPrefix(source.Source, "file://") {
+		// local templates are always read as they are, so they are not cached
+		local, err := openTemplateSource(source.Source, ref)
+		if err != nil {
+			return nil, nil, err
+		}
+		templates = local
+	} else {
+		cached, err := cacheTemplates(source.Source, ref, source.Path, mode)
+		if err != nil {
+			return nil, nil, err
+		}
+		templates = &dirSource{dir: cached.Dir, commitID: cached.Commit}
+		templatePath = DefaultTemplatePath
+	}
+	defer templates.Close()
+
+	log.Printf("Attempting to expand templates from %s\n", source)
+
+	preExistingLanguages, fetchedLanguages, err := expandTemplates(templates, templatePath, overwrite, languages)
 	if err != nil {
-		log.Fatal(err)
 		return nil, nil, err
 	}
 
+	source.Commit = templates.commit()
+	return preExistingLanguages, fetchedLanguages, nil
+}
+
+// ParseRepository splits a repository such as https://github.com/openfaas/faas-cli#0.6.0
+// into its URL and ref, applying the defaults when either is missing. Archives
+// and local directories have no refs, so their ref is only set when given.
+func ParseRepository(repository string) (string, string) {
+	repositoryURL, ref := repository, ""
+	if index := strings.LastIndex(repository, "#"); index >= 0 {
+		repositoryURL, ref = repository[:index], repository[index+1:]
+	}
+
+	if len(repositoryURL) == 0 {
+		repositoryURL = defaultTemplateRepository
+	}
+	if len(ref) == 0 && hasRefs(repositoryURL) {
+		ref = DefaultTemplateRef
+	}
+	return repositoryURL, ref
+}
+
+// cleanTemplatePath normalises the --path of templates within a repository
+func cleanTemplatePath(templatePath string) (string, error) {
+	if len(templatePath) == 0 {
+		return DefaultTemplatePath, nil
+	}
+
+	cleaned := strings.Trim(path.Clean("/"+filepath.ToSlash(templatePath)), "/")
+	if len(cleaned) == 0 {
+		return "", fmt.Errorf("the template path must name a folder within the repository")
+	}
+	return cleaned, nil
+}
+
+// readArchiveCommit returns the commit recorded in the comment of a GitHub zip archive, if any
+func readArchiveCommit(archivePath string) string {
+	zipFile, err := zip.OpenReader(archivePath)
+	if err != nil {
+		return ""
+	}
 	defer zipFile.Close()
 
-	for _, z := range zipFile.File {
+	if commitPattern.MatchString(zipFile.Comment) {
+		return zipFile.Comment
+	}
+	return ""
+}
+
+// lockTemplates records the source of the fetched languages in the LockFile
+func lockTemplates(languages []string, source LockedTemplate) error {
+	if len(languages) == 0 {
+		return nil
+	}
 
-		relativePath := z.Name[strings.Index(z.Name, "/")+1:]
-		if strings.Index(relativePath, "template/") != 0 {
-			// Process only directories inside "template" at root
-			continue
+	lock, err := ReadLock()
+	if err != nil {
+		return err
+	}
+
+	for _, language := range languages {
+		locked := source
+		locked.Name = language
+		locked.Digest, err = TreeDigest(filepath.Join(GetTemplateDirectory(), language))
+		if err != nil {
+			return err
 		}
+		lock.Set(locked)
+	}
+	return lock.Write()
+}
 
-		absolutePath := filepath.Join(GetWorkDirectory(), relativePath)
+// FetchLockedTemplates restores every template in the LockFile from the
+// commit, or failing that the ref, it was pulled from, replacing local copies.
+// A *DriftError is returned if the restored templates do not match their
+// recorded digests, i.e. a ref was moved since the LockFile was written.
+func FetchLockedTemplates(mode CacheMode) error {
+	lock, err := ReadLock()
+	if err != nil {
+		return err
+	}
+	if len(lock.Templates) == 0 {
+		return fmt.Errorf("no templates are recorded in %s, pull them with faas-cli template pull first", LockFile)
+	}
 
-		// We know that this path is a directory if the last character is a "/"
-		isDirectory := strings.HasSuffix(relativePath, "/")
-		action, language := canExpandTemplateData(availableLanguages, absolutePath, overwrite, isDirectory)
+	var sources []LockedTemplate
+	languages := make(map[LockedTemplate][]string)
+	for _, locked := range lock.Templates {
+		source := LockedTemplate{Source: locked.Source, Ref: locked.Ref, Commit: locked.Commit, Path: locked.Path}
+		if _, found := languages[source]; !found {
+			sources = append(sources, source)
+		}
+		languages[source] = append(languages[source], locked.Name)
+	}
 
-		var expandFromZip bool
+	var restored []string
+	for _, source := range sources {
+		locked := languages[source]
+		_, fetchedLanguages, err := pullTemplates(&source, true, locked, mode)
+		if err != nil {
+			return err
+		}
+
+		if len(fetchedLanguages) != len(locked) {
+			return fmt.Errorf("%s no longer has all of the templates %v, found %v", source, locked, fetchedLanguages)
+		}
+
+		log.Printf("Restored %d template(s) : %v from %s\n", len(fetchedLanguages), fetchedLanguages, source)
+		restored = append(restored, fetchedLanguages...)
+	}
+
+	return VerifyLock(restored)
+}
+
+// expandTemplates builds a list of languages that: already exist and
+// could not be overwritten and // a list of languages that are newly downloaded.
+// Templates are extracted to a temporary directory first and only moved into
+// place once the whole source has been checked and written, so a failed pull
+// never leaves a half-written template behind. When languages is not empty
+// only those languages are extracted.
+func expandTemplates(templates templateSource, templatePath string, overwrite bool, languages []string) ([]string, []string, error) {
+	var existingLanguages []string
+	var fetchedLanguages []string
+
+	availableLanguages := make(map[string]bool)
+
+	wantedLanguages := make(map[string]bool)
+	for _, language := range languages {
+		wantedLanguages[language] = true
+	}
+
+	stagingDir, err := ioutil.TempDir(GetWorkDirectory(), ".template-pull-")
+	if err != nil {
+		return nil, nil, fmt.Errorf("unable to create a temporary directory for templates: %v", err)
+	}
+	defer os.RemoveAll(stagingDir)
+
+	err = stageTemplates(templates, templatePath, stagingDir, func(relativePath string, isDirectory bool) (bool, error) {
+		absolutePath := filepath.Join(GetWorkDirectory(), filepath.FromSlash(relativePath))
+
+		action, language := canExpandTemplateData(availableLanguages, absolutePath, overwrite, isDirectory)
+		if len(wantedLanguages) > 0 && len(language) > 0 && !wantedLanguages[language] {
+			return false, nil
+		}
 
 		switch action {
 
 		case shouldExtractData:
-			expandFromZip = true
+			return true, nil
 		case newTemplateFound:
-			expandFromZip = true
 			fetchedLanguages = append(fetchedLanguages, language)
+			return true, nil
 		case directoryAlreadyExists:
-			expandFromZip = false
 			existingLanguages = append(existingLanguages, language)
+			return false, nil
 		case skipWritingData:
-			expandFromZip = false
+			return false, nil
 		default:
-			return nil, nil, fmt.Errorf(fmt.Sprintf("don't know what to do when extracting zip: %s", archivePath))
+			return false, fmt.Errorf("don't know what to do when extracting %s", relativePath)
 		}
+	})
+	if err != nil {
+		return nil, nil, err
+	}
 
-		if expandFromZip {
-			var rc io.ReadCloser
+	if err := moveTemplates(stagingDir, fetchedLanguages); err != nil {
+		return nil, nil, err
+	}
 
-			if rc, err = z.Open(); err != nil {
-				break
-			}
-			defer rc.Close()
+	return existingLanguages, fetchedLanguages, nil
+}
 
-			if err = createPath(absolutePath, z.Mode()); err != nil {
-				break
-			}
+// stageTemplates writes the templates found at templatePath in a source to
+// stagingDir/template, checking that each entry is safe to extract. include is
+// called with the path of each entry relative to stagingDir, such as
+// template/node/index.js, and tells whether it should be written.
+func stageTemplates(templates templateSource, templatePath string, stagingDir string, include func(relativePath string, isDirectory bool) (bool, error)) error {
+	root, err := sourceRoot(templates, templatePath)
+	if err != nil {
+		return err
+	}
 
-			// If relativePath is just a directory, then skip expanding it.
-			if len(absolutePath) > 1 && !isDirectory {
-				if err = writeFile(rc, z.UncompressedSize64, absolutePath, z.Mode()); err != nil {
-					return nil, nil, err
-				}
-			}
+	var extractedSize int64
+
+	return templates.walk(func(entry templateEntry) error {
+
+		relativePath, err := entryPath(entry.name)
+		if err != nil {
+			return err
+		}
+		relativePath = strings.TrimPrefix(relativePath, root)
+		if strings.Index(relativePath+"/", templatePath+"/") != 0 {
+			// Process only directories inside the template path
+			return nil
+		}
+		relativePath = DefaultTemplatePath + relativePath[len(templatePath):]
+
+		isDirectory := entry.mode.IsDir()
+		if write, err := include(relativePath, isDirectory); err != nil || !write {
+			return err
+		}
+
+		stagingPath := filepath.Join(stagingDir, filepath.FromSlash(relativePath))
+
+		if isDirectory {
+			return os.MkdirAll(stagingPath, 0755)
+		}
+
+		if entry.size > maxTemplateFileSize {
+			return fmt.Errorf("template file %s is %d bytes, larger than the limit of %d bytes", relativePath, entry.size, maxTemplateFileSize)
+		}
+		extractedSize += entry.size
+		if extractedSize > maxTemplateArchiveSize {
+			return fmt.Errorf("templates are larger than the limit of %d bytes", maxTemplateArchiveSize)
+		}
+
+		if err = createPath(stagingPath, entry.mode); err != nil {
+			return err
+		}
+
+		if len(entry.unsupported) > 0 {
+			return fmt.Errorf("template entry %s is %s, which is not supported", relativePath, entry.unsupported)
+		}
+
+		if entry.mode&os.ModeSymlink != 0 {
+			return writeSymlink(relativePath, entry.linkTarget, stagingPath)
 		}
+
+		if !entry.mode.IsRegular() {
+			return fmt.Errorf("template entry %s has unsupported file mode %s", relativePath, entry.mode)
+		}
+
+		rc, err := entry.open()
+		if err != nil {
+			return err
+		}
+
+		if err = writeFile(rc, maxTemplateFileSize, stagingPath, templateFileMode(entry.mode)); err != nil {
+			return fmt.Errorf("unable to extract %s: %v", relativePath, err)
+		}
+		return nil
+	})
+}
+
+// sourceRoot returns the folder to remove from the start of each entry's path.
+// GitHub archives keep the repository in a single top-level folder such as
+// faas-cli-master/, other archives and directories start with the repository.
+func sourceRoot(templates templateSource, templatePath string) (string, error) {
+	root := ""
+	hasTemplatePath := false
+	sharedRoot := true
+
+	err := templates.walk(func(entry templateEntry) error {
+		name, err := entryPath(entry.name)
+		if err != nil {
+			return err
+		}
+
+		if strings.Index(name+"/", templatePath+"/") == 0 {
+			hasTemplatePath = true
+		}
+
+		folder := strings.SplitN(name, "/", 2)[0] + "/"
+		if len(root) == 0 {
+			root = folder
+		} else if root != folder {
+			sharedRoot = false
+		}
+		return nil
+	})
+	if err != nil {
+		return "", err
 	}
 
-	return existingLanguages, fetchedLanguages, nil
+	if hasTemplatePath || !sharedRoot {
+		return "", nil
+	}
+	return root, nil
+}
+
+// entryPath returns the cleaned path of an entry. An error is returned for
+// absolute paths and paths with ".." segments, which could be used to write
+// outside of the work directory.
+func entryPath(name string) (string, error) {
+	if strings.HasPrefix(name, "/") || filepath.IsAbs(name) {
+		return "", fmt.Errorf("template entry %s has an absolute path, refusing to extract it", name)
+	}
+
+	segments := strings.FieldsFunc(name, func(r rune) bool { return r == '/' || r == '\\' })
+	for _, segment := range segments {
+		if segment == ".." {
+			return "", fmt.Errorf("template entry %s escapes the template directory, refusing to extract it", name)
+		}
+	}
+
+	return path.Clean(name), nil
+}
+
+// writeSymlink creates a symlink from the source, its target must stay inside the template directory
+func writeSymlink(relativePath string, linkTarget string, stagingPath string) error {
+	resolved := path.Join(path.Dir(relativePath), linkTarget)
+	if path.IsAbs(linkTarget) || !strings.HasPrefix(resolved, "template/") {
+		return fmt.Errorf("symlink %s points to %s outside of the template directory, refusing to extract it", relativePath, linkTarget)
+	}
+
+	return os.Symlink(filepath.FromSlash(linkTarget), stagingPath)
+}
+
+// moveTemplates renames each language from the staging directory into the
+// template directory, replacing an existing copy only once the new one is in place
+func moveTemplates(stagingDir string, languages []string) error {
+	if err := os.MkdirAll(GetTemplateDirectory(), 0755); err != nil {
+		return err
+	}
+
+	for _, language := range languages {
+		stagedPath := filepath.Join(stagingDir, "template", language)
+		templatePath := filepath.Join(GetTemplateDirectory(), language)
+
+		if err := replaceDir(stagedPath, templatePath, filepath.Join(stagingDir, "previous-"+language)); err != nil {
+			return fmt.Errorf("unable to move template %s into place: %v", language, err)
+		}
+	}
+	return nil
+}
+
+// replaceDir renames stagedPath to targetPath, moving an existing targetPath
+// to backupPath first and putting it back if the rename fails
+func replaceDir(stagedPath string, targetPath string, backupPath string) error {
+	if _, err := os.Stat(targetPath); err == nil {
+		if err := os.Rename(targetPath, backupPath); err != nil {
+			return err
+		}
+	}
+
+	if err := os.Rename(stagedPath, targetPath); err != nil {
+		os.Rename(backupPath, targetPath)
+		return err
+	}
+	return nil
 }
 
 // canExpandTemplateData returns what we should do with the file in form of ExtractAction enum
@@ -198,58 +523,65 @@ func canExpandTemplateData(availableLanguages map[string]bool, absolutePath stri
 	return skipWritingData, ""
 }
 
-// fetchMasterZip downloads a zip file from a repository URL
-func fetchMasterZip(templateBaseURL, destinationPath string) (string, error) {
-	var err error
-
+// fetchArchive downloads the zip file of a ref from a GitHub style repository URL
+func fetchArchive(templateBaseURL, ref, destinationPath string) (string, error) {
 	templateURL, err := url.Parse(templateBaseURL)
 	if err != nil {
 		return "", err
 	}
-	templateURL.Path = filepath.Join(templateURL.Path, "/archive/master.zip")
+	templateURL.Path = path.Join(templateURL.Path, "archive", ref+".zip")
 
-	archive := filepath.Join(destinationPath, "master.zip")
+	archive := filepath.Join(destinationPath, "templates.zip")
+	return archive, downloadFile(templateURL.String(), archive)
+}
+
+// downloadFile saves the content of a URL to a file
+func downloadFile(fileURL, destination string) error {
+	var err error
 
-	if _, serr := os.Stat(archive); serr == nil {
-		removeArchive(archive)
+	if _, serr := os.Stat(destination); serr == nil {
+		removeArchive(destination)
 	}
 
 	timeout := 120 * time.Second
-	client := proxy.MakeHTTPClient(&timeout)
+	client, cerr := proxy.MakeHTTPClient(&timeout)
+	if cerr != nil {
+		return cerr
+	}
 
-	req, rerr := http.NewRequest(http.MethodGet, templateURL.String(), nil)
+	req, rerr := http.NewRequest(http.MethodGet, fileURL, nil)
 	if rerr != nil {
-		return "", rerr
+		return rerr
 	}
 
-	log.Printf("HTTP GET %s\n", templateURL)
+	log.Printf("HTTP GET %s\n", fileURL)
 	res, derr := client.Do(req)
 	if derr != nil {
-		return "", derr
-	}
-
-	if res.StatusCode != http.StatusOK {
-		ferr := fmt.Errorf(fmt.Sprintf("%s is not valid, status code %d", templateURL, res.StatusCode))
-		log.Println(ferr.Error())
-		return "", ferr
+		return derr
 	}
 
 	if res.Body != nil {
 		defer res.Body.Close()
 	}
 
+	if res.StatusCode != http.StatusOK {
+		ferr := fmt.Errorf("%s is not valid, status code %d", fileURL, res.StatusCode)
+		log.Println(ferr.Error())
+		return ferr
+	}
+
 	bytesOut, rerr := ioutil.ReadAll(res.Body)
 	if rerr != nil {
-		return "", rerr
+		return rerr
 	}
 
-	log.Printf("Writing %dKb to %s\n", len(bytesOut)/1024, archive)
-	err = ioutil.WriteFile(archive, bytesOut, 0700)
+	log.Printf("Writing %dKb to %s\n", len(bytesOut)/1024, destination)
+	err = ioutil.WriteFile(destination, bytesOut, 0700)
 	if err != nil {
-		return "", err
+		return err
 	}
 
-	return archive, err
+	return nil
 }
 
 // canWriteLanguage tells whether the language can be expanded from the zip or not.
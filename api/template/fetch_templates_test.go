@@ -0,0 +1,227 @@
+// Copyright (c) OpenFaaS Project 2018. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package template
+
+import (
+	"archive/zip"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+type zipEntry struct {
+	name string
+	body string
+	mode os.FileMode
+}
+
+// writeTemplateZip writes a zip laid out like a GitHub archive into a new work directory
+func writeTemplateZip(t *testing.T, entries []zipEntry) (string, string) {
+	workDir, err := ioutil.TempDir("", "faas-cli-template-zip")
+	if err != nil {
+		t.Fatal(err)
+	}
+	SetWorkDirectory(workDir)
+
+	archivePath := filepath.Join(workDir, "master.zip")
+	file, err := os.Create(archivePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	writer := zip.NewWriter(file)
+	for _, entry := range entries {
+		header := &zip.FileHeader{Name: entry.name, Method: zip.Deflate}
+		mode := entry.mode
+		if mode == 0 {
+			mode = 0644
+			if strings.HasSuffix(entry.name, "/") {
+				mode = os.ModeDir | 0755
+			}
+		}
+		header.SetMode(mode)
+
+		w, err := writer.CreateHeader(header)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write([]byte(entry.body))
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	return workDir, archivePath
+}
+
+var nodeTemplate = []zipEntry{
+	{name: "faas-cli-master/"},
+	{name: "faas-cli-master/template/"},
+	{name: "faas-cli-master/template/node/"},
+	{name: "faas-cli-master/template/node/template.yml", body: "language: node\nfprocess: node index.js\n"},
+	{name: "faas-cli-master/template/node/build.sh", body: "#!/bin/sh\n", mode: 04777},
+	{name: "faas-cli-master/template/node/current", body: "template.yml", mode: os.ModeSymlink | 0777},
+}
+
+func Test_expandTemplatesFromZip(t *testing.T) {
+	workDir, archivePath := writeTemplateZip(t, nodeTemplate)
+	defer os.RemoveAll(workDir)
+	defer SetWorkDirectory("./")
+
+	_, fetched, err := expandTemplates(&zipSource{archive: archivePath}, DefaultTemplatePath, false, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(fetched) != 1 || fetched[0] != "node" {
+		t.Errorf("want node fetched, got %v", fetched)
+	}
+
+	info, err := os.Stat(filepath.Join(workDir, "template", "node", "build.sh"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode() != 0755 {
+		t.Errorf("want the setuid bit dropped and mode 0755, got %s", info.Mode())
+	}
+
+	if target, err := os.Readlink(filepath.Join(workDir, "template", "node", "current")); err != nil || target != "template.yml" {
+		t.Errorf("want symlink to template.yml, got %q %v", target, err)
+	}
+
+	leftovers, _ := filepath.Glob(filepath.Join(workDir, ".template-pull-*"))
+	if len(leftovers) > 0 {
+		t.Errorf("want the staging directory removed, found %v", leftovers)
+	}
+}
+
+func Test_expandTemplatesFromZip_Rejected(t *testing.T) {
+	rejectTests := []struct {
+		title string
+		entry zipEntry
+		want  string
+	}{
+		{
+			title: "parent directory segments",
+			entry: zipEntry{name: "faas-cli-master/template/node/../../../../tmp/evil.sh", body: "rm -rf /"},
+			want:  "escapes the template directory",
+		},
+		{
+			title: "absolute path",
+			entry: zipEntry{name: "/etc/cron.d/evil", body: "* * * * * root true"},
+			want:  "has an absolute path",
+		},
+		{
+			title: "symlink outside of template",
+			entry: zipEntry{name: "faas-cli-master/template/node/passwd", body: "../../../../etc/passwd", mode: os.ModeSymlink | 0777},
+			want:  "outside of the template directory",
+		},
+		{
+			title: "absolute symlink",
+			entry: zipEntry{name: "faas-cli-master/template/node/passwd", body: "/etc/passwd", mode: os.ModeSymlink | 0777},
+			want:  "outside of the template directory",
+		},
+		{
+			title: "oversized file",
+			entry: zipEntry{name: "faas-cli-master/template/node/huge.bin", body: strings.Repeat("0", 2048)},
+			want:  "larger than the limit of 1024 bytes",
+		},
+	}
+
+	defaultMaxFileSize := maxTemplateFileSize
+	maxTemplateFileSize = 1024
+	defer func() { maxTemplateFileSize = defaultMaxFileSize }()
+
+	for _, test := range rejectTests {
+		t.Run(test.title, func(t *testing.T) {
+			workDir, archivePath := writeTemplateZip(t, append(append([]zipEntry{}, nodeTemplate...), test.entry))
+			defer os.RemoveAll(workDir)
+			defer SetWorkDirectory("./")
+
+			_, _, err := expandTemplates(&zipSource{archive: archivePath}, DefaultTemplatePath, false, nil)
+			if err == nil || !strings.Contains(err.Error(), test.want) {
+				t.Fatalf("want error containing %q, got %v", test.want, err)
+			}
+
+			if _, err := os.Stat(filepath.Join(workDir, "template", "node")); !os.IsNotExist(err) {
+				t.Errorf("want no template written when the archive is rejected")
+			}
+		})
+	}
+}
+
+func Test_expandTemplatesFromZip_Path(t *testing.T) {
+	workDir, archivePath := writeTemplateZip(t, []zipEntry{
+		{name: "templates-0.6.0/"},
+		{name: "templates-0.6.0/template/"},
+		{name: "templates-0.6.0/template/python/"},
+		{name: "templates-0.6.0/template/python/template.yml", body: "language: python\n"},
+		{name: "templates-0.6.0/openfaas/templates/"},
+		{name: "templates-0.6.0/openfaas/templates/node/"},
+		{name: "templates-0.6.0/openfaas/templates/node/template.yml", body: "language: node\n"},
+		{name: "templates-0.6.0/openfaas/templates-extra/"},
+		{name: "templates-0.6.0/openfaas/templates-extra/go/"},
+		{name: "templates-0.6.0/openfaas/templates-extra/go/template.yml", body: "language: go\n"},
+	})
+	defer os.RemoveAll(workDir)
+	defer SetWorkDirectory("./")
+
+	_, fetched, err := expandTemplates(&zipSource{archive: archivePath}, "openfaas/templates", false, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(fetched) != 1 || fetched[0] != "node" {
+		t.Errorf("want only node fetched, got %v", fetched)
+	}
+
+	if _, err := os.Stat(filepath.Join(workDir, "template", "node", "template.yml")); err != nil {
+		t.Errorf("want node written to the template directory: %s", err)
+	}
+}
+
+func Test_ParseRepository(t *testing.T) {
+	parseTests := []struct {
+		repository string
+		url        string
+		ref        string
+	}{
+		{"", defaultTemplateRepository, DefaultTemplateRef},
+		{"https://github.com/owner/repo", "https://github.com/owner/repo", DefaultTemplateRef},
+		{"https://github.com/owner/repo#0.6.0", "https://github.com/owner/repo", "0.6.0"},
+		{"https://github.com/owner/repo#", "https://github.com/owner/repo", DefaultTemplateRef},
+		{"#feature/templates", defaultTemplateRepository, "feature/templates"},
+		{"git@git.internal:templates.git", "git@git.internal:templates.git", DefaultTemplateRef},
+		{"https://example.com/templates.tar.gz", "https://example.com/templates.tar.gz", ""},
+		{"file:///home/alex/templates", "file:///home/alex/templates", ""},
+	}
+
+	for _, test := range parseTests {
+		url, ref := ParseRepository(test.repository)
+		if url != test.url || ref != test.ref {
+			t.Errorf("%q: want %s and %s, got %s and %s", test.repository, test.url, test.ref, url, ref)
+		}
+	}
+}
+
+func Test_cleanTemplatePath(t *testing.T) {
+	pathTests := map[string]string{
+		"":                    "template",
+		"templates/":          "templates",
+		"./openfaas/template": "openfaas/template",
+		"../../etc":           "etc",
+	}
+
+	for templatePath, want := range pathTests {
+		got, err := cleanTemplatePath(templatePath)
+		if err != nil || got != want {
+			t.Errorf("%q: want %s, got %s %v", templatePath, want, got, err)
+		}
+	}
+
+	if _, err := cleanTemplatePath("/"); err == nil {
+		t.Errorf("want an error for a path naming the root of the repository")
+	}
+}
@@ -1,13 +1,15 @@
 package template
 
 import (
+	"fmt"
 	"io"
 	"log"
 	"os"
 	"path/filepath"
 )
 
-func writeFile(rc io.ReadCloser, size uint64, relativePath string, perms os.FileMode) error {
+// writeFile writes the content of rc to a file, failing if there is more than maxSize bytes of it
+func writeFile(rc io.ReadCloser, maxSize int64, relativePath string, perms os.FileMode) error {
 	var err error
 
 	defer rc.Close()
@@ -16,9 +18,25 @@ func writeFile(rc io.ReadCloser, size uint64, relativePath string, perms os.File
 		return err
 	}
 	defer f.Close()
-	_, err = io.CopyN(f, rc, int64(size))
 
-	return err
+	// the size recorded in an archive can not be trusted, so limit what is read
+	written, err := io.Copy(f, io.LimitReader(rc, maxSize+1))
+	if err != nil {
+		return err
+	}
+	if written > maxSize {
+		return fmt.Errorf("file is larger than the limit of %d bytes", maxSize)
+	}
+
+	return nil
+}
+
+// templateFileMode returns the permissions for an extracted file, only the executable bit is kept from the archive
+func templateFileMode(mode os.FileMode) os.FileMode {
+	if mode&0111 != 0 {
+		return 0755
+	}
+	return 0644
 }
 
 func createPath(relativePath string, perms os.FileMode) error {
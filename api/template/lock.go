@@ -0,0 +1,209 @@
+// Copyright (c) OpenFaaS Project 2018. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package template
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	yaml "gopkg.in/yaml.v2"
+)
+
+// LockFile records where each template was pulled from, relative to the work directory
+const LockFile = "template.lock"
+
+// TemplateLock is the content of the LockFile
+type TemplateLock struct {
+	Templates []LockedTemplate `yaml:"templates"`
+}
+
+// LockedTemplate records the source of a single language template
+type LockedTemplate struct {
+	Name string `yaml:"name"`
+	// Source is the repository the template was pulled from
+	Source string `yaml:"source"`
+	// Ref is the branch or tag which was requested, archives and directories have none
+	Ref string `yaml:"ref,omitempty"`
+	// Commit is the commit the ref resolved to, when the archive records it
+	Commit string `yaml:"commit,omitempty"`
+	// Path is the folder of the repository holding the templates
+	Path string `yaml:"path"`
+	// Digest is the SHA-256 of the template's tree when it was pulled, see TreeDigest
+	Digest string `yaml:"sha256,omitempty"`
+}
+
+// DriftError lists the templates which no longer match the LockFile
+type DriftError struct {
+	Templates []string
+}
+
+func (e *DriftError) Error() string {
+	return fmt.Sprintf("templates differ from %s:\n%s", LockFile, strings.Join(e.Templates, "\n"))
+}
+
+// GetLockPath returns the location of the LockFile
+func GetLockPath() string {
+	return filepath.Join(GetWorkDirectory(), LockFile)
+}
+
+// ReadLock reads the LockFile, a missing file gives an empty lock
+func ReadLock() (*TemplateLock, error) {
+	lock := &TemplateLock{}
+
+	data, err := ioutil.ReadFile(GetLockPath())
+	if os.IsNotExist(err) {
+		return lock, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	if err := yaml.Unmarshal(data, lock); err != nil {
+		return nil, fmt.Errorf("unable to parse %s: %v", LockFile, err)
+	}
+	return lock, nil
+}
+
+// Write saves the lock to the LockFile with templates sorted by name
+func (l *TemplateLock) Write() error {
+	sort.Slice(l.Templates, func(i, j int) bool {
+		return l.Templates[i].Name < l.Templates[j].Name
+	})
+
+	data, err := yaml.Marshal(l)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(GetLockPath(), data, 0644)
+}
+
+// Get returns the entry for a template, if there is one
+func (l *TemplateLock) Get(name string) (LockedTemplate, bool) {
+	for _, locked := range l.Templates {
+		if locked.Name == name {
+			return locked, true
+		}
+	}
+	return LockedTemplate{}, false
+}
+
+// Set adds or replaces the entry for a template
+func (l *TemplateLock) Set(locked LockedTemplate) {
+	for i := range l.Templates {
+		if l.Templates[i].Name == locked.Name {
+			l.Templates[i] = locked
+			return
+		}
+	}
+	l.Templates = append(l.Templates, locked)
+}
+
+// String returns where the template was pulled from, as accepted by template pull
+func (l LockedTemplate) String() string {
+	if len(l.Ref) == 0 {
+		return l.Source
+	}
+	return l.Source + "#" + l.Ref
+}
+
+// VerifyLock checks the given languages against the digests in the LockFile.
+// Languages which are not locked, or were locked without a digest, are not
+// checked. A *DriftError is returned when a template was modified or removed.
+func VerifyLock(languages []string) error {
+	lock, err := ReadLock()
+	if err != nil {
+		return err
+	}
+
+	var drifted []string
+	checked := make(map[string]bool)
+	for _, language := range languages {
+		locked, ok := lock.Get(language)
+		if !ok || len(locked.Digest) == 0 || checked[language] {
+			continue
+		}
+		checked[language] = true
+
+		digest, err := TreeDigest(filepath.Join(GetTemplateDirectory(), language))
+		if os.IsNotExist(err) {
+			drifted = append(drifted, fmt.Sprintf("%s: missing, it was pulled from %s", language, locked))
+			continue
+		} else if err != nil {
+			return err
+		}
+
+		if digest != locked.Digest {
+			drifted = append(drifted, fmt.Sprintf("%s: modified since it was pulled from %s", language, locked))
+		}
+	}
+
+	if len(drifted) > 0 {
+		return &DriftError{Templates: drifted}
+	}
+	return nil
+}
+
+// TreeDigest returns the SHA-256 of a template folder, covering the path,
+// type and content of every entry and whether files are executable
+func TreeDigest(dir string) (string, error) {
+	if _, err := os.Stat(dir); err != nil {
+		return "", err
+	}
+
+	digest := sha256.New()
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		relativePath, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		relativePath = filepath.ToSlash(relativePath)
+
+		switch {
+		case info.Mode()&os.ModeSymlink != 0:
+			target, err := os.Readlink(path)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(digest, "link %s %s\n", relativePath, filepath.ToSlash(target))
+		case info.IsDir():
+			fmt.Fprintf(digest, "dir %s\n", relativePath)
+		default:
+			contentDigest, err := fileDigest(path)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(digest, "file %s %t %s\n", relativePath, info.Mode()&0111 != 0, contentDigest)
+		}
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(digest.Sum(nil)), nil
+}
+
+func fileDigest(path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	digest := sha256.New()
+	if _, err := io.Copy(digest, file); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(digest.Sum(nil)), nil
+}
@@ -0,0 +1,179 @@
+// Copyright (c) OpenFaaS Project 2018. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package template
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeTemplates creates template/<language>/template.yml for each language in a new work directory
+func writeTemplates(t *testing.T, languages ...string) string {
+	workDir, err := ioutil.TempDir("", "faas-cli-template-lock")
+	if err != nil {
+		t.Fatal(err)
+	}
+	SetWorkDirectory(workDir)
+
+	for _, language := range languages {
+		dir := filepath.Join(GetTemplateDirectory(), language)
+		os.MkdirAll(dir, 0755)
+		ioutil.WriteFile(filepath.Join(dir, "template.yml"), []byte("language: "+language+"\n"), 0644)
+	}
+	return workDir
+}
+
+func Test_TemplateLock(t *testing.T) {
+	workDir := writeTemplates(t, "node", "python")
+	defer os.RemoveAll(workDir)
+	defer SetWorkDirectory("./")
+
+	lock, err := ReadLock()
+	if err != nil || len(lock.Templates) != 0 {
+		t.Fatalf("want an empty lock when there is no %s, got %v %v", LockFile, lock, err)
+	}
+
+	source := LockedTemplate{Source: "https://github.com/owner/repo", Ref: "0.6.0", Path: "template"}
+	if err := lockTemplates([]string{"python", "node"}, source); err != nil {
+		t.Fatal(err)
+	}
+
+	source.Ref = "0.7.0"
+	if err := lockTemplates([]string{"python"}, source); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := ioutil.ReadFile(GetLockPath())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	nodeDigest, _ := TreeDigest(filepath.Join(GetTemplateDirectory(), "node"))
+	pythonDigest, _ := TreeDigest(filepath.Join(GetTemplateDirectory(), "python"))
+	want := fmt.Sprintf(`templates:
+- name: node
+  source: https://github.com/owner/repo
+  ref: 0.6.0
+  path: template
+  sha256: %s
+- name: python
+  source: https://github.com/owner/repo
+  ref: 0.7.0
+  path: template
+  sha256: %s
+`, nodeDigest, pythonDigest)
+	if string(data) != want {
+		t.Errorf("want:\n%s\ngot:\n%s", want, data)
+	}
+}
+
+func Test_TreeDigest(t *testing.T) {
+	workDir := writeTemplates(t, "node")
+	defer os.RemoveAll(workDir)
+	defer SetWorkDirectory("./")
+
+	dir := filepath.Join(GetTemplateDirectory(), "node")
+	digest, err := TreeDigest(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	os.Chmod(filepath.Join(dir, "template.yml"), 0755)
+	if executable, _ := TreeDigest(dir); executable == digest {
+		t.Errorf("want the digest to change when a file becomes executable")
+	}
+
+	os.Chmod(filepath.Join(dir, "template.yml"), 0644)
+	if restored, _ := TreeDigest(dir); restored != digest {
+		t.Errorf("want the digest to depend only on the tree, got %s and %s", digest, restored)
+	}
+}
+
+func Test_VerifyLock(t *testing.T) {
+	workDir := writeTemplates(t, "node", "python", "go")
+	defer os.RemoveAll(workDir)
+	defer SetWorkDirectory("./")
+
+	if err := lockTemplates([]string{"node", "python"}, LockedTemplate{Source: "https://github.com/owner/repo", Ref: "master", Path: "template"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := VerifyLock([]string{"node", "python", "go", "Dockerfile"}); err != nil {
+		t.Fatalf("want no drift, got %v", err)
+	}
+
+	ioutil.WriteFile(filepath.Join(GetTemplateDirectory(), "node", "template.yml"), []byte("language: node\nfprocess: cat\n"), 0644)
+	os.RemoveAll(filepath.Join(GetTemplateDirectory(), "python"))
+
+	err := VerifyLock([]string{"node", "python", "go"})
+	driftErr, ok := err.(*DriftError)
+	if !ok {
+		t.Fatalf("want a DriftError, got %T: %v", err, err)
+	}
+
+	want := []string{
+		"node: modified since it was pulled from https://github.com/owner/repo#master",
+		"python: missing, it was pulled from https://github.com/owner/repo#master",
+	}
+	if strings.Join(driftErr.Templates, "\n") != strings.Join(want, "\n") {
+		t.Errorf("want drift:\n%s\ngot:\n%s", strings.Join(want, "\n"), strings.Join(driftErr.Templates, "\n"))
+	}
+}
+
+func Test_FetchLockedTemplates(t *testing.T) {
+	workDir, archivePath := writeTemplateZip(t, append([]zipEntry{
+		{name: "faas-cli-master/template/python/"},
+		{name: "faas-cli-master/template/python/template.yml", body: "language: python\n"},
+	}, nodeTemplate...))
+	defer os.RemoveAll(workDir)
+	defer SetWorkDirectory("./")
+
+	const commit = "75adf28c188ed298f221ea58c8433e447a236f87"
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/owner/repo/archive/"+commit+".zip" {
+			t.Errorf("want the locked commit requested, got %s", r.URL.Path)
+		}
+		http.ServeFile(w, r, archivePath)
+	}))
+	defer ts.Close()
+
+	if _, _, err := expandTemplates(&zipSource{archive: archivePath}, DefaultTemplatePath, false, nil); err != nil {
+		t.Fatal(err)
+	}
+	source := LockedTemplate{Source: ts.URL + "/owner/repo", Ref: "master", Commit: commit, Path: "template"}
+	if err := lockTemplates([]string{"node"}, source); err != nil {
+		t.Fatal(err)
+	}
+
+	ioutil.WriteFile(filepath.Join(GetTemplateDirectory(), "node", "template.yml"), []byte("edited"), 0644)
+	os.RemoveAll(filepath.Join(GetTemplateDirectory(), "python"))
+
+	if err := FetchLockedTemplates(PreferCache); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	data, _ := ioutil.ReadFile(filepath.Join(GetTemplateDirectory(), "node", "template.yml"))
+	if string(data) != "language: node\nfprocess: node index.js\n" {
+		t.Errorf("want node restored, got %q", data)
+	}
+	if _, err := os.Stat(filepath.Join(GetTemplateDirectory(), "python")); !os.IsNotExist(err) {
+		t.Errorf("want only locked templates restored")
+	}
+
+	lock, _ := ReadLock()
+	locked, _ := lock.Get("node")
+	locked.Digest = strings.Repeat("0", 64)
+	lock.Set(locked)
+	lock.Write()
+
+	if _, ok := FetchLockedTemplates(PreferCache).(*DriftError); !ok {
+		t.Errorf("want a DriftError when the ref no longer matches the lock")
+	}
+}
@@ -0,0 +1,383 @@
+// Copyright (c) OpenFaaS Project 2018. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package template
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/openfaas/faas-cli/config"
+)
+
+// gitRepositoryPattern matches repositories which are cloned with the system git,
+// i.e. git@host:owner/repo.git, ssh://host/repo.git and git://host/repo.git
+var gitRepositoryPattern = regexp.MustCompile(`^([A-Za-z0-9._-]+@[A-Za-z0-9.-]+:|ssh://|git://)`)
+
+// archiveExtensions are the archives which can be pulled from a URL or file:// path
+var archiveExtensions = []string{".zip", ".tar.gz", ".tgz"}
+
+// templateEntry is a file, folder or symlink read from a template source
+type templateEntry struct {
+	// name is the slash separated path of the entry within the source
+	name       string
+	mode       os.FileMode
+	size       int64
+	linkTarget string
+	open       func() (io.ReadCloser, error)
+	// unsupported describes an entry which can not be extracted although its
+	// mode is that of a file, such as a hard link in a tar archive
+	unsupported string
+}
+
+// templateSource is a repository of templates which has been fetched locally
+type templateSource interface {
+	// walk calls fn for every entry of the source, it may be called more than once
+	walk(fn func(entry templateEntry) error) error
+	// commit returns the commit the source was fetched at, if it is known
+	commit() string
+	// Close removes anything which was downloaded for the source
+	Close() error
+}
+
+// isGitRepository tells whether a repository is cloned with git rather than downloaded
+func isGitRepository(repositoryURL string) bool {
+	return gitRepositoryPattern.MatchString(repositoryURL)
+}
+
+// isArchive tells whether a URL or path names a .zip or .tar.gz archive
+func isArchive(repositoryURL string) bool {
+	return len(archiveExtension(repositoryURL)) > 0
+}
+
+func archiveExtension(repositoryURL string) string {
+	for _, extension := range archiveExtensions {
+		if strings.HasSuffix(strings.ToLower(repositoryURL), extension) {
+			return extension
+		}
+	}
+	return ""
+}
+
+// hasRefs tells whether a branch or tag can be picked from a repository with #ref
+func hasRefs(repositoryURL string) bool {
+	return isGitRepository(repositoryURL) || !(isArchive(repositoryURL) || strings.HasPrefix(repositoryURL, "file://"))
+}
+
+// openTemplateSource fetches the templates of a repository, which may be:
+//
+// - a GitHub style repository URL, whose archive of ref is downloaded
+// - a git repository reached over SSH, which is cloned at ref
+// - a .zip, .tar.gz or .tgz URL
+// - a file:// path to a local directory or archive
+func openTemplateSource(repositoryURL string, ref string) (templateSource, error) {
+	switch {
+	case isGitRepository(repositoryURL):
+		return openGitSource(repositoryURL, ref)
+
+	case strings.HasPrefix(repositoryURL, "file://"):
+		localPath := filepath.FromSlash(strings.TrimPrefix(repositoryURL, "file://"))
+		info, err := os.Stat(localPath)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read templates from %s: %v", repositoryURL, err)
+		}
+		if info.IsDir() {
+			return &dirSource{dir: localPath}, nil
+		}
+		return openArchive(localPath, false)
+
+	case isArchive(repositoryURL):
+		archive := filepath.Join(GetWorkDirectory(), "templates"+archiveExtension(repositoryURL))
+		if err := downloadFile(repositoryURL, archive); err != nil {
+			removeArchive(archive)
+			return nil, err
+		}
+		return openArchive(archive, true)
+
+	default:
+		archive, err := fetchArchive(repositoryURL, ref, GetWorkDirectory())
+		if err != nil {
+			removeArchive(archive)
+			return nil, err
+		}
+		return openArchive(archive, true)
+	}
+}
+
+// openArchive reads the templates of a .zip or .tar.gz archive, which is removed on Close when remove is set
+func openArchive(archive string, remove bool) (templateSource, error) {
+	if strings.HasSuffix(strings.ToLower(archive), ".zip") {
+		return &zipSource{archive: archive, remove: remove}, nil
+	}
+	if isArchive(archive) {
+		return &tarSource{archive: archive, remove: remove}, nil
+	}
+	return nil, fmt.Errorf("%s is not a directory or a %s archive", archive, strings.Join(archiveExtensions, ", "))
+}
+
+// zipSource reads templates from a zip archive, such as those downloaded from GitHub
+type zipSource struct {
+	archive string
+	remove  bool
+}
+
+func (s *zipSource) walk(fn func(entry templateEntry) error) error {
+	zipFile, err := zip.OpenReader(s.archive)
+	if err != nil {
+		return err
+	}
+	defer zipFile.Close()
+
+	for _, z := range zipFile.File {
+		entry := templateEntry{name: z.Name, mode: z.Mode(), size: int64(z.UncompressedSize64), open: z.Open}
+		if strings.HasSuffix(z.Name, "/") {
+			entry.mode |= os.ModeDir
+		}
+
+		if entry.mode&os.ModeSymlink != 0 {
+			// zip archives keep the target of a symlink as its content
+			if entry.linkTarget, err = readLinkTarget(z.Open); err != nil {
+				return err
+			}
+		}
+
+		if err := fn(entry); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *zipSource) commit() string {
+	return readArchiveCommit(s.archive)
+}
+
+func (s *zipSource) Close() error {
+	if s.remove {
+		return removeArchive(s.archive)
+	}
+	return nil
+}
+
+// tarSource reads templates from a gzipped tar archive
+type tarSource struct {
+	archive string
+	remove  bool
+	comment string
+}
+
+func (s *tarSource) walk(fn func(entry templateEntry) error) error {
+	file, err := os.Open(s.archive)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	gzipReader, err := gzip.NewReader(file)
+	if err != nil {
+		return fmt.Errorf("unable to read %s: %v", s.archive, err)
+	}
+	defer gzipReader.Close()
+
+	tarReader := tar.NewReader(gzipReader)
+	for {
+		header, err := tarReader.Next()
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return fmt.Errorf("unable to read %s: %v", s.archive, err)
+		}
+
+		if header.Typeflag == tar.TypeXGlobalHeader {
+			// GitHub records the commit of a tarball in its global header
+			s.comment = header.PAXRecords["comment"]
+			continue
+		}
+
+		entry := templateEntry{
+			name:       header.Name,
+			mode:       header.FileInfo().Mode(),
+			size:       header.Size,
+			linkTarget: header.Linkname,
+			open: func() (io.ReadCloser, error) {
+				return ioutil.NopCloser(tarReader), nil
+			},
+		}
+		switch header.Typeflag {
+		case tar.TypeReg, tar.TypeRegA, tar.TypeDir, tar.TypeSymlink:
+		case tar.TypeLink:
+			entry.unsupported = "a hard link to " + header.Linkname
+		default:
+			entry.unsupported = fmt.Sprintf("a tar entry of type %q", header.Typeflag)
+		}
+		if err := fn(entry); err != nil {
+			return err
+		}
+	}
+}
+
+func (s *tarSource) commit() string {
+	if commitPattern.MatchString(s.comment) {
+		return s.comment
+	}
+	return ""
+}
+
+func (s *tarSource) Close() error {
+	if s.remove {
+		return removeArchive(s.archive)
+	}
+	return nil
+}
+
+// dirSource reads templates from a local directory, such as a git clone
+type dirSource struct {
+	dir      string
+	commitID string
+	// clone is removed on Close
+	clone bool
+}
+
+func (s *dirSource) walk(fn func(entry templateEntry) error) error {
+	return filepath.Walk(s.dir, func(filePath string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() && info.Name() == ".git" {
+			return filepath.SkipDir
+		}
+
+		relativePath, err := filepath.Rel(s.dir, filePath)
+		if err != nil || relativePath == "." {
+			return err
+		}
+
+		entry := templateEntry{
+			name: filepath.ToSlash(relativePath),
+			mode: info.Mode(),
+			size: info.Size(),
+			open: func() (io.ReadCloser, error) {
+				return os.Open(filePath)
+			},
+		}
+		if info.Mode()&os.ModeSymlink != 0 {
+			target, err := os.Readlink(filePath)
+			if err != nil {
+				return err
+			}
+			entry.linkTarget = filepath.ToSlash(target)
+		}
+		return fn(entry)
+	})
+}
+
+func (s *dirSource) commit() string {
+	return s.commitID
+}
+
+func (s *dirSource) Close() error {
+	if s.clone {
+		return os.RemoveAll(s.dir)
+	}
+	return nil
+}
+
+// openGitSource clones a repository at ref with the system git, a commit is
+// checked out from a full clone as it can not be fetched with --depth
+func openGitSource(repositoryURL string, ref string) (templateSource, error) {
+	dir, err := ioutil.TempDir(GetWorkDirectory(), ".template-git-")
+	if err != nil {
+		return nil, fmt.Errorf("unable to create a temporary directory for %s: %v", repositoryURL, err)
+	}
+	source := &dirSource{dir: dir, clone: true}
+
+	// git runs inside the clone, so a relative path would be cloned into itself
+	if source.dir, err = filepath.Abs(dir); err != nil {
+		os.RemoveAll(dir)
+		return nil, err
+	}
+	dir = source.dir
+
+	commands := [][]string{{"clone", "--quiet", "--depth", "1", "--branch", ref, "--", repositoryURL, dir}}
+	if commitPattern.MatchString(ref) {
+		commands = [][]string{
+			{"clone", "--quiet", "--no-checkout", "--", repositoryURL, dir},
+			{"checkout", "--quiet", ref},
+		}
+	}
+
+	for _, args := range commands {
+		if _, err := runGit(dir, args...); err != nil {
+			source.Close()
+			return nil, err
+		}
+	}
+
+	if source.commitID, err = runGit(dir, "rev-parse", "HEAD"); err != nil {
+		source.Close()
+		return nil, err
+	}
+	return source, nil
+}
+
+// runGit runs git in dir with the TLS settings of the global flags and returns its output
+func runGit(dir string, args ...string) (string, error) {
+	tlsArgs, err := gitTLSArgs(config.DefaultTLSConfig)
+	if err != nil {
+		return "", err
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command("git", append(tlsArgs, args...)...)
+	cmd.Dir = dir
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("git %s failed: %v %s", args[0], err, strings.TrimSpace(stderr.String()))
+	}
+	return strings.TrimSpace(stdout.String()), nil
+}
+
+// gitTLSArgs returns the git options for the TLS settings, the certificate
+// files are made absolute as git runs in the clone
+func gitTLSArgs(tlsConfig config.TLSConfig) ([]string, error) {
+	tlsConfig, err := tlsConfig.AbsPaths()
+	if err != nil {
+		return nil, err
+	}
+
+	var args []string
+	if tlsConfig.InsecureSkipVerify {
+		args = append(args, "-c", "http.sslVerify=false")
+	}
+	if len(tlsConfig.CACert) > 0 {
+		args = append(args, "-c", "http.sslCAInfo="+tlsConfig.CACert)
+	}
+	if len(tlsConfig.ClientCert) > 0 {
+		args = append(args, "-c", "http.sslCert="+tlsConfig.ClientCert, "-c", "http.sslKey="+tlsConfig.ClientKey)
+	}
+	return args, nil
+}
+
+// readLinkTarget reads the target of a symlink stored as the content of an archive entry
+func readLinkTarget(open func() (io.ReadCloser, error)) (string, error) {
+	rc, err := open()
+	if err != nil {
+		return "", err
+	}
+	defer rc.Close()
+
+	target, err := ioutil.ReadAll(io.LimitReader(rc, 4096))
+	return string(target), err
+}
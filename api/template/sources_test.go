@@ -0,0 +1,290 @@
+// Copyright (c) OpenFaaS Project 2018. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package template
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/openfaas/faas-cli/config"
+)
+
+// writeTemplateTarball writes a .tar.gz with the templates at its root, as made by tar -czf
+func writeTemplateTarball(t *testing.T, archivePath string, comment string) {
+	file, err := os.Create(archivePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	gzipWriter := gzip.NewWriter(file)
+	tarWriter := tar.NewWriter(gzipWriter)
+
+	if len(comment) > 0 {
+		tarWriter.WriteHeader(&tar.Header{Typeflag: tar.TypeXGlobalHeader, Name: "pax_global_header", PAXRecords: map[string]string{"comment": comment}})
+	}
+
+	body := "language: ruby\n"
+	headers := []*tar.Header{
+		{Typeflag: tar.TypeDir, Name: "./template/", Mode: 0755},
+		{Typeflag: tar.TypeDir, Name: "./template/ruby/", Mode: 0755},
+		{Typeflag: tar.TypeReg, Name: "./template/ruby/template.yml", Mode: 0644, Size: int64(len(body))},
+		{Typeflag: tar.TypeSymlink, Name: "./template/ruby/current", Linkname: "template.yml", Mode: 0777},
+	}
+	for _, header := range headers {
+		if err := tarWriter.WriteHeader(header); err != nil {
+			t.Fatal(err)
+		}
+		if header.Typeflag == tar.TypeReg {
+			tarWriter.Write([]byte(body))
+		}
+	}
+
+	tarWriter.Close()
+	gzipWriter.Close()
+}
+
+func Test_FetchTemplates_Tarball(t *testing.T) {
+	workDir, err := ioutil.TempDir("", "faas-cli-template-tarball")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(workDir)
+	SetWorkDirectory(workDir)
+	defer SetWorkDirectory("./")
+
+	const commit = "75adf28c188ed298f221ea58c8433e447a236f87"
+	archivePath := filepath.Join(workDir, "ruby.tar.gz")
+	writeTemplateTarball(t, archivePath, commit)
+
+	if err := FetchTemplates("file://"+archivePath, "", false, PreferCache); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if target, err := os.Readlink(filepath.Join(GetTemplateDirectory(), "ruby", "current")); err != nil || target != "template.yml" {
+		t.Errorf("want symlink to template.yml, got %q %v", target, err)
+	}
+	if _, err := os.Stat(archivePath); err != nil {
+		t.Errorf("want a local archive kept after the pull: %s", err)
+	}
+
+	lock, _ := ReadLock()
+	locked, _ := lock.Get("ruby")
+	if locked.Source != "file://"+archivePath || locked.Ref != "" || locked.Commit != commit {
+		t.Errorf("want the archive and its commit recorded, got %#v", locked)
+	}
+}
+
+func Test_FetchTemplates_Tarball_Rejected(t *testing.T) {
+	workDir, err := ioutil.TempDir("", "faas-cli-template-tarball")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(workDir)
+	SetWorkDirectory(workDir)
+	defer SetWorkDirectory("./")
+
+	tests := []struct {
+		header *tar.Header
+		want   string
+	}{
+		{
+			header: &tar.Header{Typeflag: tar.TypeLink, Name: "template/ruby/index.rb", Linkname: "template/ruby/template.yml"},
+			want:   "template entry template/ruby/index.rb is a hard link to template/ruby/template.yml, which is not supported",
+		},
+		{
+			header: &tar.Header{Typeflag: tar.TypeFifo, Name: "template/ruby/pipe", Mode: 0644},
+			want:   "template entry template/ruby/pipe is a tar entry of type '6', which is not supported",
+		},
+	}
+
+	for _, test := range tests {
+		archivePath := filepath.Join(workDir, "ruby.tar.gz")
+		file, err := os.Create(archivePath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		gzipWriter := gzip.NewWriter(file)
+		tarWriter := tar.NewWriter(gzipWriter)
+		body := "language: ruby\n"
+		tarWriter.WriteHeader(&tar.Header{Typeflag: tar.TypeReg, Name: "template/ruby/template.yml", Mode: 0644, Size: int64(len(body))})
+		tarWriter.Write([]byte(body))
+		tarWriter.WriteHeader(test.header)
+		tarWriter.Close()
+		gzipWriter.Close()
+		file.Close()
+
+		err = FetchTemplates("file://"+archivePath, "", false, PreferCache)
+		if err == nil || !strings.Contains(err.Error(), test.want) {
+			t.Errorf("want an error containing %q, got %v", test.want, err)
+		}
+		if _, err := os.Stat(filepath.Join(GetTemplateDirectory(), "ruby")); err == nil {
+			t.Errorf("want no template extracted from an archive with a %q entry", test.header.Typeflag)
+		}
+	}
+}
+
+func Test_FetchTemplates_Directory(t *testing.T) {
+	sourceDir := writeTemplates(t, "node", "python")
+	defer os.RemoveAll(sourceDir)
+
+	workDir, err := ioutil.TempDir("", "faas-cli-template-directory")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(workDir)
+	SetWorkDirectory(workDir)
+	defer SetWorkDirectory("./")
+
+	os.MkdirAll(filepath.Join(workDir, "template", "node"), 0755)
+
+	if err := FetchTemplates("file://"+sourceDir+"#master", "", false, PreferCache); err == nil {
+		t.Errorf("want an error for a #ref on a directory")
+	}
+
+	if err := FetchTemplates("file://"+sourceDir, "", false, PreferCache); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if _, err := os.Stat(filepath.Join(workDir, "template", "node", "template.yml")); !os.IsNotExist(err) {
+		t.Errorf("want the existing node template left alone without overwrite")
+	}
+	if _, err := os.Stat(filepath.Join(workDir, "template", "python", "template.yml")); err != nil {
+		t.Errorf("want python copied from the directory: %s", err)
+	}
+}
+
+func Test_FetchTemplates_Git(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	repositoryDir := writeTemplates(t, "go")
+	defer os.RemoveAll(repositoryDir)
+
+	git := func(args ...string) string {
+		output, err := runGit(repositoryDir, append([]string{"-c", "user.name=faas-cli", "-c", "user.email=faas-cli@example.com"}, args...)...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return output
+	}
+	git("init", "--quiet")
+	git("add", ".")
+	git("commit", "--quiet", "-m", "Add go template")
+	git("tag", "0.1.0")
+	commit := git("rev-parse", "HEAD")
+
+	ioutil.WriteFile(filepath.Join(repositoryDir, "template", "go", "template.yml"), []byte("language: go\nfprocess: ./handler\n"), 0644)
+	git("commit", "--quiet", "-a", "-m", "Change go template")
+
+	tempDir, err := ioutil.TempDir("", "faas-cli-template-git")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	// the default work directory is relative to the current one
+	cwd, _ := os.Getwd()
+	if err := os.Chdir(tempDir); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(cwd)
+	previousWorkDir := workDir
+	workDir = ""
+	defer func() { workDir = previousWorkDir }()
+
+	// git accepts a local path as well as git@host:repo, openGitSource is used directly to avoid SSH
+	templates, err := openGitSource(repositoryDir, "0.1.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer templates.Close()
+
+	if templates.commit() != commit {
+		t.Errorf("want commit %s, got %s", commit, templates.commit())
+	}
+
+	if _, _, err := expandTemplates(templates, DefaultTemplatePath, false, nil); err != nil {
+		t.Fatal(err)
+	}
+	data, _ := ioutil.ReadFile(filepath.Join(tempDir, "template", "go", "template.yml"))
+	if string(data) != "language: go\n" {
+		t.Errorf("want the template at the tag, got %q", data)
+	}
+
+	templates.Close()
+	leftovers, _ := filepath.Glob(filepath.Join(tempDir, ".template-*"))
+	if len(leftovers) > 0 {
+		t.Errorf("want the clone removed, found %v", leftovers)
+	}
+
+	if _, err := openGitSource(repositoryDir, "missing"); err == nil || !strings.Contains(err.Error(), "git clone failed") {
+		t.Errorf("want git clone to fail for a missing ref, got %v", err)
+	}
+
+	if _, err := openGitSource("--upload-pack=touch uploaded", "0.1.0"); err == nil {
+		t.Errorf("want git clone to fail for a repository named like an option")
+	}
+	if _, err := os.Stat(filepath.Join(tempDir, "uploaded")); err == nil {
+		t.Errorf("want the repository URL not to be read as an option")
+	}
+}
+
+func Test_isGitRepository(t *testing.T) {
+	repositories := map[string]bool{
+		"git@github.com:openfaas/faas-cli.git":   true,
+		"git@git.internal:templates":             true,
+		"ssh://git@git.internal:2222/templates":  true,
+		"git://git.internal/templates.git":       true,
+		"https://github.com/openfaas/faas-cli":   false,
+		"https://example.com/templates.tar.gz":   false,
+		"file:///home/alex/templates":            false,
+		"file:///home/alex/git@internal:archive": false,
+	}
+
+	for repository, want := range repositories {
+		if got := isGitRepository(repository); got != want {
+			t.Errorf("%s: want %t, got %t", repository, want, got)
+		}
+	}
+}
+
+func Test_gitTLSArgs(t *testing.T) {
+	caCert, _ := filepath.Abs("ca.crt")
+
+	tests := []struct {
+		name      string
+		tlsConfig config.TLSConfig
+		want      []string
+	}{
+		{name: "defaults", tlsConfig: config.TLSConfig{}, want: nil},
+		{name: "no verification", tlsConfig: config.TLSConfig{InsecureSkipVerify: true}, want: []string{"-c", "http.sslVerify=false"}},
+		{name: "relative CA", tlsConfig: config.TLSConfig{CACert: "ca.crt"}, want: []string{"-c", "http.sslCAInfo=" + caCert}},
+		{
+			name:      "client certificate",
+			tlsConfig: config.TLSConfig{ClientCert: "/certs/client.crt", ClientKey: "/certs/client.key"},
+			want:      []string{"-c", "http.sslCert=/certs/client.crt", "-c", "http.sslKey=/certs/client.key"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := gitTLSArgs(test.tlsConfig)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("want %q, got %q", test.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,140 @@
+// Copyright (c) OpenFaaS Project 2018. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package template
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/openfaas/faas-cli/proxy"
+)
+
+const (
+	// DefaultStoreURL is the index of community templates
+	DefaultStoreURL = "https://raw.githubusercontent.com/openfaas/store/master/templates.json"
+	// StoreURLEnvironment overrides DefaultStoreURL, i.e. for an internal index
+	StoreURLEnvironment = "OPENFAAS_TEMPLATE_STORE_URL"
+	// DefaultPlatform is the name of the amd64 platform used by the store
+	DefaultPlatform = "x86_64"
+)
+
+// StoreTemplate is an entry of the template store's index
+type StoreTemplate struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	// Repository is where the template is pulled from, in any format accepted by FetchTemplates
+	Repository string `json:"repo"`
+	Platform   string `json:"platform"`
+	Maintainer string `json:"maintainer"`
+}
+
+// GetStoreURL returns the store index to read, the storeURL given, then the
+// StoreURLEnvironment variable and finally DefaultStoreURL
+func GetStoreURL(storeURL string) string {
+	if len(storeURL) > 0 {
+		return storeURL
+	}
+	if envURL := os.Getenv(StoreURLEnvironment); len(envURL) > 0 {
+		return envURL
+	}
+	return DefaultStoreURL
+}
+
+// ReadStore reads the index of a template store from a http(s) URL or a local file,
+// templates are sorted by name and platform
+func ReadStore(storeURL string) ([]StoreTemplate, error) {
+	var data []byte
+	var err error
+
+	if strings.HasPrefix(storeURL, "http://") || strings.HasPrefix(storeURL, "https://") {
+		data, err = fetchStoreIndex(storeURL)
+	} else {
+		data, err = ioutil.ReadFile(filepath.FromSlash(strings.TrimPrefix(storeURL, "file://")))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unable to read the template store %s: %v", storeURL, err)
+	}
+
+	var templates []StoreTemplate
+	if err := json.Unmarshal(data, &templates); err != nil {
+		return nil, fmt.Errorf("unable to parse the template store %s: %v", storeURL, err)
+	}
+
+	sort.SliceStable(templates, func(i, j int) bool {
+		if templates[i].Name == templates[j].Name {
+			return templates[i].Platform < templates[j].Platform
+		}
+		return templates[i].Name < templates[j].Name
+	})
+	return templates, nil
+}
+
+// FilterStore returns the templates for a platform, or every template when platform
+// is empty. Templates with no platform are returned for every platform.
+func FilterStore(templates []StoreTemplate, platform string) []StoreTemplate {
+	if len(platform) == 0 {
+		return templates
+	}
+
+	var filtered []StoreTemplate
+	for _, storeTemplate := range templates {
+		if len(storeTemplate.Platform) == 0 || strings.EqualFold(storeTemplate.Platform, platform) {
+			filtered = append(filtered, storeTemplate)
+		}
+	}
+	return filtered
+}
+
+// FindStoreTemplate looks up a template by name for a platform
+func FindStoreTemplate(templates []StoreTemplate, name string, platform string) (StoreTemplate, error) {
+	for _, storeTemplate := range FilterStore(templates, platform) {
+		if storeTemplate.Name == name {
+			return storeTemplate, nil
+		}
+	}
+
+	if len(platform) > 0 {
+		return StoreTemplate{}, fmt.Errorf("template %s was not found in the store for platform %s", name, platform)
+	}
+	return StoreTemplate{}, fmt.Errorf("template %s was not found in the store", name)
+}
+
+// GetPlatform returns the platform of this machine as it is named by templates
+// and the store, such as x86_64 or armhf
+func GetPlatform() string {
+	switch runtime.GOARCH {
+	case "amd64":
+		return DefaultPlatform
+	case "arm":
+		return "armhf"
+	}
+	return runtime.GOARCH
+}
+
+func fetchStoreIndex(storeURL string) ([]byte, error) {
+	timeout := 30 * time.Second
+	client, err := proxy.MakeHTTPClient(&timeout)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := client.Get(storeURL)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("status code %d", res.StatusCode)
+	}
+	return ioutil.ReadAll(res.Body)
+}
@@ -0,0 +1,99 @@
+// Copyright (c) OpenFaaS Project 2018. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package template
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const storeIndex = `[
+  {"name": "node", "platform": "x86_64", "repo": "https://github.com/openfaas/templates", "description": "NodeJS 8", "maintainer": "openfaas"},
+  {"name": "golang-http", "platform": "x86_64", "repo": "git@git.internal:team/templates.git", "description": "Go HTTP", "maintainer": "team"},
+  {"name": "node", "platform": "armhf", "repo": "https://github.com/openfaas/templates", "description": "NodeJS 8 for Raspberry Pi", "maintainer": "openfaas"},
+  {"name": "bash", "repo": "https://github.com/owner/bash-template", "description": "Bash", "maintainer": "owner"}
+]`
+
+func Test_ReadStore(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(storeIndex))
+	}))
+	defer ts.Close()
+
+	indexDir, err := ioutil.TempDir("", "faas-cli-template-store")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(indexDir)
+	indexPath := filepath.Join(indexDir, "templates.json")
+	ioutil.WriteFile(indexPath, []byte(storeIndex), 0644)
+
+	for _, storeURL := range []string{ts.URL, indexPath, "file://" + indexPath} {
+		templates, err := ReadStore(storeURL)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %s", storeURL, err)
+		}
+
+		var names []string
+		for _, storeTemplate := range templates {
+			names = append(names, storeTemplate.Name+"/"+storeTemplate.Platform)
+		}
+		if got := fmt.Sprint(names); got != "[bash/ golang-http/x86_64 node/armhf node/x86_64]" {
+			t.Errorf("%s: want templates sorted by name and platform, got %s", storeURL, got)
+		}
+	}
+
+	if _, err := ReadStore(ts.URL + "/missing"); err == nil {
+		t.Errorf("want an error for a store which can not be read")
+	}
+}
+
+func Test_FindStoreTemplate(t *testing.T) {
+	var templates []StoreTemplate
+	json.Unmarshal([]byte(storeIndex), &templates)
+
+	node, err := FindStoreTemplate(templates, "node", "armhf")
+	if err != nil || node.Description != "NodeJS 8 for Raspberry Pi" {
+		t.Errorf("want node for armhf, got %v %v", node, err)
+	}
+
+	if bash, err := FindStoreTemplate(templates, "bash", "armhf"); err != nil || bash.Name != "bash" {
+		t.Errorf("want a template without a platform found for every platform, got %v %v", bash, err)
+	}
+
+	if _, err := FindStoreTemplate(templates, "golang-http", "armhf"); err == nil || err.Error() != "template golang-http was not found in the store for platform armhf" {
+		t.Errorf("want golang-http missing for armhf, got %v", err)
+	}
+
+	if got := len(FilterStore(templates, "")); got != 4 {
+		t.Errorf("want every template for an empty platform, got %d", got)
+	}
+}
+
+func Test_GetStoreURL(t *testing.T) {
+	defer os.Unsetenv(StoreURLEnvironment)
+
+	os.Unsetenv(StoreURLEnvironment)
+	if got := GetStoreURL(""); got != DefaultStoreURL {
+		t.Errorf("want %s, got %s", DefaultStoreURL, got)
+	}
+
+	os.Setenv(StoreURLEnvironment, "https://templates.example.com/index.json")
+	if got := GetStoreURL(""); got != "https://templates.example.com/index.json" {
+		t.Errorf("want the store from the environment, got %s", got)
+	}
+	if got := GetStoreURL("./templates.json"); got != "./templates.json" {
+		t.Errorf("want the store given, got %s", got)
+	}
+}
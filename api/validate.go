@@ -0,0 +1,29 @@
+// Copyright (c) OpenFaaS Project 2018. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package api
+
+import (
+	"fmt"
+
+	"github.com/openfaas/faas-cli/options"
+	"github.com/openfaas/faas-cli/stack"
+)
+
+//Validate strictly checks one or more stack files
+func Validate(arg options.FaasOptions) error {
+	if len(arg.YamlFiles) == 0 {
+		return fmt.Errorf("please provide a stack file to validate with --yaml")
+	}
+
+	return stack.ValidateYAMLFiles(arg.YamlFiles)
+}
+
+// validateStrict validates the stack files only when strict mode is enabled
+func validateStrict(arg options.FaasOptions) error {
+	if !arg.Strict || len(arg.YamlFiles) == 0 || arg.Services != nil {
+		return nil
+	}
+
+	return stack.ValidateYAMLFiles(arg.YamlFiles)
+}
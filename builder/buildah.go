@@ -0,0 +1,39 @@
+// Copyright (c) OpenFaaS Project 2018. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package builder
+
+import (
+	"context"
+	"os"
+)
+
+// BuildahBuilder builds and pushes images with buildah, which needs no daemon
+type BuildahBuilder struct {
+}
+
+// Build runs buildah bud in the context directory
+func (b *BuildahBuilder) Build(ctx context.Context, config BuildConfig) error {
+	return execCommand(ctx, config.ContextPath, b.buildCommand(config), config.output())
+}
+
+// Push runs buildah push to the registry named by the image
+func (b *BuildahBuilder) Push(ctx context.Context, image string) error {
+	return execCommand(ctx, "./", b.pushCommand(image), os.Stdout)
+}
+
+// ImageExists runs buildah inspect against the local image store
+func (b *BuildahBuilder) ImageExists(image string) (bool, error) {
+	return execSucceeds([]string{"buildah", "inspect", "--type", "image", image})
+}
+
+func (b *BuildahBuilder) buildCommand(config BuildConfig) []string {
+	// buildah passes the proxy variables of the environment to the build by itself
+	flagSlice := buildFlagSlice(config.NoCache, config.Squash, "", "", config.BuildArgs)
+	cmd := append([]string{"buildah", "bud"}, flagSlice...)
+	return append(cmd, "-t", config.Image, ".")
+}
+
+func (b *BuildahBuilder) pushCommand(image string) []string {
+	return []string{"buildah", "push", image, "docker://" + image}
+}
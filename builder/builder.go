@@ -1,20 +1,33 @@
 package builder
 
 import (
+	"bytes"
+	"context"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"log"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 	"sync"
 
 	"github.com/openfaas/faas-cli/api/template"
 	"github.com/openfaas/faas-cli/stack"
+	"github.com/openfaas/faas-cli/version"
 )
 
+// AdditionalPackageBuildArg is the build-arg used by templates to install the packages of a build option
+const AdditionalPackageBuildArg = "ADDITIONAL_PACKAGE"
+
 // BuildImage construct Docker image from function parameters
-func BuildImage(image string, handler string, functionName string, language string, nocache bool, squash bool, shrinkwrap bool) error {
+func BuildImage(ctx context.Context, imageBuilder Builder, image string, handler string, functionName string, language string, nocache bool, squash bool, shrinkwrap bool, buildArgMap map[string]string, buildOptions []string) error {
+	return buildImage(ctx, imageBuilder, os.Stdout, image, handler, functionName, language, nocache, squash, shrinkwrap, buildArgMap, buildOptions)
+}
+
+// buildImage is BuildImage with the build output sent to output
+func buildImage(ctx context.Context, imageBuilder Builder, output io.Writer, image string, handler string, functionName string, language string, nocache bool, squash bool, shrinkwrap bool, buildArgMap map[string]string, buildOptions []string) error {
 
 	if stack.IsValidTemplate(language) {
 
@@ -22,36 +35,66 @@ func BuildImage(image string, handler string, functionName string, language stri
 		if strings.ToLower(language) == "dockerfile" {
 
 			if shrinkwrap {
-				fmt.Printf("Nothing to do for: %s.\n", functionName)
+				fmt.Fprintf(output, "Nothing to do for: %s.\n", functionName)
 
 				return nil
 			}
 
 			tempPath = handler
 			if _, err := os.Stat(handler); err != nil {
-				fmt.Printf("Unable to build %s, %s is an invalid path\n", image, handler)
-				fmt.Printf("Image: %s not built.\n", image)
-
-				return nil
+				return fmt.Errorf("unable to build %s, %s is an invalid path", image, handler)
+			}
+			if len(buildOptions) > 0 {
+				return fmt.Errorf("build_options are not supported for the Dockerfile language, use build_args instead")
 			}
-			fmt.Printf("Building: %s with Dockerfile. Please wait..\n", image)
+			fmt.Fprintf(output, "Building: %s with Dockerfile. Please wait..\n", image)
 
 		} else {
+			pathToTemplateYAML := filepath.Join(template.GetTemplateDirectory(), language, "template.yml")
+			langTemplate, err := stack.ParseYAMLForLanguageTemplate(pathToTemplateYAML)
+			if err != nil {
+				return fmt.Errorf("unable to read template %s: %v", language, err)
+			}
+			if err := langTemplate.CheckCLIVersion(version.BuildVersion()); err != nil {
+				return err
+			}
+
+			packages, err := getBuildOptionPackages(buildOptions, language)
+			if err != nil {
+				return err
+			}
+			buildArgMap = appendAdditionalPackages(buildArgMap, packages)
 
-			tempPath = createBuildTemplate(functionName, handler, language)
-			fmt.Printf("Building: %s with %s template. Please wait..\n", image, language)
+			handlerFolder, err := langTemplate.GetHandlerFolder()
+			if err != nil {
+				return fmt.Errorf("unable to build with template %s: %v", language, err)
+			}
+
+			tempPath, err = createBuildTemplate(output, functionName, handler, language, handlerFolder)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(output, "Building: %s with %s template. Please wait..\n", image, language)
 
 			if shrinkwrap {
-				fmt.Printf("%s shrink-wrapped to %s\n", functionName, tempPath)
+				fmt.Fprintf(output, "%s shrink-wrapped to %s\n", functionName, tempPath)
 
 				return nil
 			}
 		}
 
-		flagStr := buildFlagString(nocache, squash, os.Getenv("http_proxy"), os.Getenv("https_proxy"))
-		cmd := strings.Split(fmt.Sprintf("docker build %s-t %s .", flagStr, image), " ")
-		ExecCommand(tempPath, cmd)
-		fmt.Printf("Image: %s built.\n", image)
+		err := imageBuilder.Build(ctx, BuildConfig{
+			ContextPath: tempPath,
+			Image:       image,
+			NoCache:     nocache,
+			Squash:      squash,
+			BuildArgs:   buildArgMap,
+			Output:      output,
+		})
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(output, "Image: %s built.\n", image)
 
 	} else {
 		return fmt.Errorf("Language template: %s not supported. Build a custom Dockerfile instead", language)
@@ -60,51 +103,63 @@ func BuildImage(image string, handler string, functionName string, language stri
 	return nil
 }
 
-// createBuildTemplate creates temporary build folder to perform a Docker build with language template
-func createBuildTemplate(functionName string, handler string, language string) string {
+// createBuildTemplate creates temporary build folder to perform a Docker build with
+// language template, the handler is copied into the template's handler folder
+func createBuildTemplate(output io.Writer, functionName string, handler string, language string, handlerFolder string) (string, error) {
+	// hidden entries of the build folder hold the logs and state of every function
+	if strings.HasPrefix(functionName, ".") {
+		return "", fmt.Errorf("function name %s must not start with a dot", functionName)
+	}
+
 	tempPath := filepath.Join(
 		template.GetWorkDirectory(),
 		"build",
 		functionName,
 	)
 
-	fmt.Printf("Clearing temporary build folder: %s\n", tempPath)
+	fmt.Fprintf(output, "Clearing temporary build folder: %s\n", tempPath)
 
 	clearErr := os.RemoveAll(tempPath)
 	if clearErr != nil {
-		fmt.Printf("Error clearing temporary build folder %s\n", tempPath)
+		fmt.Fprintf(output, "Error clearing temporary build folder %s\n", tempPath)
 	}
 
-	functionPath := filepath.Join(tempPath, "/function")
+	functionPath := filepath.Join(tempPath, handlerFolder)
 
-	fmt.Printf("Preparing %s %s\n", handler+"/", functionPath)
+	fmt.Fprintf(output, "Preparing %s %s\n", handler+"/", functionPath)
 
 	mkdirErr := os.MkdirAll(functionPath, 0700)
 	if mkdirErr != nil {
-		fmt.Printf("Error creating path %s - %s.\n", functionPath, mkdirErr.Error())
+		fmt.Fprintf(output, "Error creating path %s - %s.\n", functionPath, mkdirErr.Error())
 	}
 
 	// Drop in directory tree from template
-	CopyFiles(filepath.Join(template.GetTemplateDirectory(), language), tempPath, true)
+	if err := CopyFiles(filepath.Join(template.GetTemplateDirectory(), language), tempPath, true); err != nil {
+		return "", fmt.Errorf("unable to copy template %s: %v", language, err)
+	}
 
 	// Overlay in user-function
-	CopyFiles(handler, functionPath, true)
+	if err := CopyFiles(handler, functionPath, true); err != nil {
+		return "", fmt.Errorf("unable to copy handler %s: %v", handler, err)
+	}
 
-	return tempPath
+	return tempPath, nil
 }
 
 // CopyFiles copies files from src to destination, optionally recursively.
-func CopyFiles(src string, destination string, recursive bool) {
+func CopyFiles(src string, destination string, recursive bool) error {
 
 	files, err := ioutil.ReadDir(src)
 	if err != nil {
-		log.Fatal(err)
+		return err
 	}
 
 	for _, file := range files {
 
 		if file.IsDir() == false {
-			cp(filepath.Join(src, file.Name()), filepath.Join(destination, file.Name()))
+			if err := cp(filepath.Join(src, file.Name()), filepath.Join(destination, file.Name())); err != nil {
+				return err
+			}
 		} else {
 			//make new destination dir
 			newDir := filepath.Join(destination, file.Name())
@@ -122,10 +177,13 @@ func CopyFiles(src string, destination string, recursive bool) {
 			//did the call ask to recurse into sub directories?
 			if recursive == true {
 				//call CopyFiles to copy the contents
-				CopyFiles(filepath.Join(src, file.Name()), newDir, true)
+				if err := CopyFiles(filepath.Join(src, file.Name()), newDir, true); err != nil {
+					return err
+				}
 			}
 		}
 	}
+	return nil
 }
 
 func pathExists(path string) bool {
@@ -144,111 +202,255 @@ func cp(src string, destination string) error {
 
 	memoryBuffer, readErr := ioutil.ReadFile(src)
 	if readErr != nil {
-		return fmt.Errorf("Error reading source file: %s\n" + readErr.Error())
+		return fmt.Errorf("error reading source file: %s", readErr.Error())
 	}
 	writeErr := ioutil.WriteFile(destination, memoryBuffer, 0660)
 	if writeErr != nil {
-		return fmt.Errorf("Error writing file: %s\n" + writeErr.Error())
+		return fmt.Errorf("error writing file: %s", writeErr.Error())
 	}
 
 	return nil
 }
 
-func buildFlagString(nocache bool, squash bool, httpProxy string, httpsProxy string) string {
+func buildFlagSlice(nocache bool, squash bool, httpProxy string, httpsProxy string, buildArgMap map[string]string) []string {
 
-	buildFlags := ""
+	var buildFlags []string
 
 	if nocache {
-		buildFlags += "--no-cache "
+		buildFlags = append(buildFlags, "--no-cache")
 	}
 	if squash {
-		buildFlags += "--squash "
+		buildFlags = append(buildFlags, "--squash")
 	}
 
 	if len(httpProxy) > 0 {
-		buildFlags += fmt.Sprintf("--build-arg http_proxy=%s ", httpProxy)
+		buildFlags = append(buildFlags, "--build-arg", fmt.Sprintf("http_proxy=%s", httpProxy))
 	}
 
 	if len(httpsProxy) > 0 {
-		buildFlags += fmt.Sprintf("--build-arg https_proxy=%s ", httpsProxy)
+		buildFlags = append(buildFlags, "--build-arg", fmt.Sprintf("https_proxy=%s", httpsProxy))
+	}
+
+	var keys []string
+	for k := range buildArgMap {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		buildFlags = append(buildFlags, "--build-arg", fmt.Sprintf("%s=%s", k, buildArgMap[k]))
 	}
 
 	return buildFlags
 }
 
-func debugPrint(message string) {
+// getBuildOptionPackages resolves the named build options against the
+// build_options declared in the language template's template.yml
+func getBuildOptionPackages(requestedOptions []string, language string) ([]string, error) {
+	var packages []string
+	if len(requestedOptions) == 0 {
+		return packages, nil
+	}
 
-	if val, exists := os.LookupEnv("debug"); exists && (val == "1" || val == "true") {
-		fmt.Println(message)
+	pathToTemplateYAML := filepath.Join(template.GetTemplateDirectory(), language, "template.yml")
+	langTemplate, err := stack.ParseYAMLForLanguageTemplate(pathToTemplateYAML)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read build options for template %s: %v", language, err)
+	}
+
+	availableOptions := make(map[string][]string)
+	var optionNames []string
+	for _, option := range langTemplate.BuildOptions {
+		availableOptions[option.Name] = option.Packages
+		optionNames = append(optionNames, option.Name)
 	}
+
+	for _, requested := range requestedOptions {
+		optionPackages, found := availableOptions[requested]
+		if !found {
+			return nil, fmt.Errorf("build option %s is not available for template %s, available options: %v", requested, language, optionNames)
+		}
+		packages = append(packages, optionPackages...)
+	}
+
+	return deduplicate(packages), nil
 }
 
-//BuildStack build a stack of functions
-func BuildStack(services *stack.Services, queueDepth int, nocache bool, squash bool, shrinkwrap bool) error {
-	wg := sync.WaitGroup{}
+// appendAdditionalPackages adds the packages to the ADDITIONAL_PACKAGE build-arg, keeping any value already set
+func appendAdditionalPackages(buildArgMap map[string]string, packages []string) map[string]string {
+	if len(packages) == 0 {
+		return buildArgMap
+	}
 
-	workChannel := make(chan stack.Function)
-	var buildErr error
-	for i := 0; i < queueDepth; i++ {
+	merged := make(map[string]string)
+	for k, v := range buildArgMap {
+		merged[k] = v
+	}
 
-		go func(index int) {
-			// interrupt build
-			if buildErr != nil {
-				return
-			}
-			wg.Add(1)
-			for function := range workChannel {
-				fmt.Printf("[%d] > Building: %s.\n", index, function.Name)
-				if len(function.Language) == 0 {
-					fmt.Println("Please provide a valid --lang or 'Dockerfile' for your function.")
-
-				} else {
-					if err := BuildImage(
-						function.Image,
-						function.Handler,
-						function.Name,
-						function.Language,
-						nocache,
-						squash,
-						shrinkwrap,
-					); err != nil {
-						buildErr = err
-					}
-				}
-			}
+	if existing := strings.TrimSpace(merged[AdditionalPackageBuildArg]); len(existing) > 0 {
+		packages = append(packages, strings.Fields(existing)...)
+	}
+	merged[AdditionalPackageBuildArg] = strings.Join(deduplicate(packages), " ")
 
-			fmt.Printf("[%d] < Builder done.\n", index)
-			wg.Done()
-		}(i)
+	return merged
+}
+
+func deduplicate(values []string) []string {
+	var unique []string
+	seen := make(map[string]bool)
+	for _, value := range values {
+		if !seen[value] {
+			seen[value] = true
+			unique = append(unique, value)
+		}
 	}
+	return unique
+}
 
-	for k, function := range services.Functions {
-		if buildErr != nil {
-			break
+func debugPrint(message string) {
+
+	if val, exists := os.LookupEnv("debug"); exists && (val == "1" || val == "true") {
+		fmt.Println(message)
+	}
+}
+
+// BuildStack builds a stack of functions with queueDepth builders in
+// parallel, then prints a summary of the results. Every function is built
+// unless failFast is set, the returned error is of type StackErrors and names
+// every function which failed to build. The output of each build is saved
+// under BuildLogDir and shown on the console prefixed by the function name,
+// or left out in quiet mode.
+func BuildStack(ctx context.Context, imageBuilder Builder, services *stack.Services, queueDepth int, nocache bool, squash bool, shrinkwrap bool, buildArgMap map[string]string, buildOptions []string, force bool, failFast bool, quiet bool) error {
+	// a no-cache build is an explicit request to rebuild, as is shrinkwrap which never builds
+	incremental := !force && !nocache && !shrinkwrap
+	state := LoadBuildState()
+
+	var prefixWidth int
+	for name := range services.Functions {
+		if len(name) > prefixWidth {
+			prefixWidth = len(name)
 		}
+	}
+	consoleLock := &sync.Mutex{}
+
+	results, err := RunStack(ctx, services, queueDepth, failFast, func(ctx context.Context, index int, function stack.Function) (string, error) {
 		if function.SkipBuild {
 			fmt.Printf("Skipping build of: %s.\n", function.Name)
+			return "skipped", nil
+		}
+
+		fmt.Printf("[%d] > Building: %s.\n", index, function.Name)
+		if len(function.Language) == 0 {
+			return "", fmt.Errorf("please provide a valid --lang or 'Dockerfile' for your function")
+		}
+
+		functionBuildArgs := mergeBuildArgs(function.BuildArgs, buildArgMap)
+		functionBuildOptions := deduplicate(append(append([]string{}, function.BuildOptions...), buildOptions...))
+
+		hash, hashErr := functionHash(function, functionBuildArgs, functionBuildOptions, squash)
+		if incremental && hashErr == nil && isUpToDate(imageBuilder, state, function, hash) {
+			fmt.Printf("Skipping build of: %s, no changes since the last build. Use --force to rebuild.\n", function.Name)
+			return "up to date", nil
+		}
+
+		var console io.Writer = ioutil.Discard
+		var prefixed *prefixWriter
+		if !quiet {
+			prefixed = &prefixWriter{prefix: builderPrefix(function.Name, prefixWidth, index), out: os.Stdout, lock: consoleLock}
+			console = prefixed
+		}
+
+		output := console
+		logFile, logErr := createBuildLog(function.Name)
+		if logErr != nil {
+			fmt.Printf("Unable to create build log for %s: %s\n", function.Name, logErr)
 		} else {
-			function.Name = k
-			workChannel <- function
+			defer logFile.Close()
+			output = io.MultiWriter(logFile, console)
 		}
-	}
 
-	close(workChannel)
+		err := buildImage(
+			ctx,
+			imageBuilder,
+			output,
+			function.Image,
+			function.Handler,
+			function.Name,
+			function.Language,
+			nocache,
+			squash,
+			shrinkwrap,
+			functionBuildArgs,
+			functionBuildOptions,
+		)
+		if prefixed != nil {
+			prefixed.Flush()
+		}
+
+		if err != nil {
+			// a build stopped by the failure of another function has no useful tail
+			if logErr == nil && ctx.Err() == nil {
+				var tail bytes.Buffer
+				printLogTail(&tail, function.Name)
+				consoleLock.Lock()
+				os.Stdout.Write(tail.Bytes())
+				consoleLock.Unlock()
+			}
+			return "", err
+		}
+
+		if shrinkwrap {
+			return "shrink-wrapped", nil
+		}
+
+		if hashErr == nil {
+			if err := state.Record(function.Name, hash); err != nil {
+				fmt.Printf("Unable to save build state for %s: %s\n", function.Name, err)
+			}
+		}
+		return "built", nil
+	})
+
+	PrintStackSummary(os.Stdout, results)
 
-	wg.Wait()
+	return err
+}
+
+// isUpToDate tells whether a function was last built from the same inputs and its image is still present
+func isUpToDate(imageBuilder Builder, state *BuildState, function stack.Function, hash string) bool {
+	if !state.UpToDate(function.Name, hash) {
+		return false
+	}
 
-	return buildErr
+	exists, err := imageBuilder.ImageExists(function.Image)
+	if err != nil {
+		fmt.Printf("Unable to check for image %s: %s\n", function.Image, err)
+		return false
+	}
+	return exists
+}
+
+// mergeBuildArgs combines the build args from the stack file with those passed as flags, flags take priority
+func mergeBuildArgs(functionBuildArgs map[string]string, buildArgMap map[string]string) map[string]string {
+	merged := make(map[string]string)
+	for k, v := range functionBuildArgs {
+		merged[k] = v
+	}
+	for k, v := range buildArgMap {
+		merged[k] = v
+	}
+	return merged
 }
 
-// PullTemplates pulls templates from Github from the master zip download file.
+// PullTemplates pulls templates from Github from the master zip download file,
+// or from the template cache when they were pulled before.
 func PullTemplates(templateURL string) error {
 	var err error
 	exists, err := os.Stat(filepath.Join(template.GetTemplateDirectory()))
 	if err != nil || exists == nil {
 		log.Println("No templates found in current directory.")
 
-		err = template.FetchTemplates(templateURL, false)
+		err = template.FetchTemplates(templateURL, "", false, template.PreferCache)
 		if err != nil {
 			log.Println("Unable to download templates from Github.")
 			return err
@@ -0,0 +1,194 @@
+// Copyright (c) OpenFaaS Project 2018. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package builder
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/openfaas/faas-cli/api/template"
+	"github.com/openfaas/faas-cli/stack"
+	"github.com/openfaas/faas-cli/test"
+)
+
+func Test_buildFlagSlice(t *testing.T) {
+	flags := buildFlagSlice(true, false, "http://proxy:3128", "", map[string]string{
+		"GO_VERSION":         "1.9",
+		"ADDITIONAL_PACKAGE": "make gcc",
+	})
+
+	want := []string{
+		"--no-cache",
+		"--build-arg", "http_proxy=http://proxy:3128",
+		"--build-arg", "ADDITIONAL_PACKAGE=make gcc",
+		"--build-arg", "GO_VERSION=1.9",
+	}
+	if !reflect.DeepEqual(flags, want) {
+		t.Errorf("want %v, got %v", want, flags)
+	}
+}
+
+func Test_getBuildOptionPackages(t *testing.T) {
+	workDir, err := ioutil.TempDir("", "faas-cli-build-options")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(workDir)
+	template.SetWorkDirectory(workDir)
+	defer template.SetWorkDirectory("./")
+
+	templatePath := filepath.Join(workDir, "template", "python3")
+	os.MkdirAll(templatePath, 0700)
+	ioutil.WriteFile(filepath.Join(templatePath, "template.yml"), []byte(`language: python3
+fprocess: python3 index.py
+build_options:
+  - name: dev
+    packages:
+      - make
+      - gcc
+  - name: imaging
+    packages:
+      - imagemagick
+      - gcc
+`), 0600)
+
+	packages, err := getBuildOptionPackages([]string{"dev", "imaging"}, "python3")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if want := []string{"make", "gcc", "imagemagick"}; !reflect.DeepEqual(packages, want) {
+		t.Errorf("want %v, got %v", want, packages)
+	}
+
+	_, err = getBuildOptionPackages([]string{"gpu"}, "python3")
+	if err == nil || !strings.Contains(err.Error(), "build option gpu is not available for template python3") {
+		t.Errorf("want unknown build option error, got %v", err)
+	}
+}
+
+func Test_appendAdditionalPackages(t *testing.T) {
+	buildArgs := map[string]string{AdditionalPackageBuildArg: "curl"}
+
+	merged := appendAdditionalPackages(buildArgs, []string{"make", "curl"})
+	if merged[AdditionalPackageBuildArg] != "make curl" {
+		t.Errorf("want packages merged with existing value, got %q", merged[AdditionalPackageBuildArg])
+	}
+	if buildArgs[AdditionalPackageBuildArg] != "curl" {
+		t.Errorf("original build args must not be modified")
+	}
+}
+
+func Test_BuildStack_MissingHandler(t *testing.T) {
+	workDir, err := ioutil.TempDir("", "faas-cli-build-handler")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(workDir)
+	template.SetWorkDirectory(workDir)
+	defer template.SetWorkDirectory("./")
+	// stack.IsValidTemplate looks for templates under $workdir
+	os.Setenv("workdir", workDir)
+	defer os.Unsetenv("workdir")
+
+	templatePath := filepath.Join(workDir, "template", "python3")
+	os.MkdirAll(filepath.Join(templatePath, "function"), 0700)
+	ioutil.WriteFile(filepath.Join(templatePath, "template.yml"), []byte("language: python3\nfprocess: python3 index.py\n"), 0600)
+
+	handler := filepath.Join(workDir, "fn1")
+	services := &stack.Services{Functions: map[string]stack.Function{
+		"fn1": {Language: "python3", Handler: handler, Image: "functions/fn1"},
+	}}
+
+	var buildErr error
+	test.CaptureStdout(func() {
+		buildErr = BuildStack(context.Background(), &fakeBuilder{images: map[string]bool{}}, services, 1, false, false, false, nil, nil, true, false, true)
+	})
+
+	stackErrors, ok := buildErr.(StackErrors)
+	if !ok || len(stackErrors) != 1 || stackErrors[0].Function != "fn1" {
+		t.Fatalf("want fn1 to fail, got %v", buildErr)
+	}
+	if !strings.Contains(stackErrors[0].Err.Error(), "unable to copy handler "+handler) {
+		t.Errorf("want an error for the missing handler, got %s", stackErrors[0].Err)
+	}
+}
+
+func Test_BuildStack_HandlerFolderOutsideTemplate(t *testing.T) {
+	workDir, err := ioutil.TempDir("", "faas-cli-build-handler-folder")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(workDir)
+	template.SetWorkDirectory(workDir)
+	defer template.SetWorkDirectory("./")
+	os.Setenv("workdir", workDir)
+	defer os.Unsetenv("workdir")
+
+	templatePath := filepath.Join(workDir, "template", "python3")
+	os.MkdirAll(templatePath, 0700)
+	ioutil.WriteFile(filepath.Join(templatePath, "template.yml"), []byte("language: python3\nfprocess: python3 index.py\nhandler_folder: ../..\n"), 0600)
+
+	handler := filepath.Join(workDir, "fn1")
+	os.MkdirAll(handler, 0700)
+	ioutil.WriteFile(filepath.Join(handler, "handler.py"), []byte("def handle(req):\n    return req\n"), 0600)
+	services := &stack.Services{Functions: map[string]stack.Function{
+		"fn1": {Language: "python3", Handler: handler, Image: "functions/fn1"},
+	}}
+
+	var buildErr error
+	test.CaptureStdout(func() {
+		buildErr = BuildStack(context.Background(), &fakeBuilder{images: map[string]bool{}}, services, 1, false, false, false, nil, nil, true, false, true)
+	})
+
+	stackErrors, ok := buildErr.(StackErrors)
+	if !ok || len(stackErrors) != 1 || !strings.Contains(stackErrors[0].Err.Error(), "handler_folder ../.. must be a folder within the template") {
+		t.Fatalf("want the handler folder refused, got %v", buildErr)
+	}
+	if _, err := os.Stat(filepath.Join(workDir, "handler.py")); err == nil {
+		t.Errorf("want no handler written outside the build folder")
+	}
+}
+
+func Test_BuildStack_FunctionNamedLogs(t *testing.T) {
+	workDir, err := ioutil.TempDir("", "faas-cli-build-named-logs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(workDir)
+	template.SetWorkDirectory(workDir)
+	defer template.SetWorkDirectory("./")
+	os.Setenv("workdir", workDir)
+	defer os.Unsetenv("workdir")
+
+	templatePath := filepath.Join(workDir, "template", "python3")
+	os.MkdirAll(filepath.Join(templatePath, "function"), 0700)
+	ioutil.WriteFile(filepath.Join(templatePath, "template.yml"), []byte("language: python3\nfprocess: python3 index.py\n"), 0600)
+
+	services := &stack.Services{Functions: map[string]stack.Function{}}
+	for _, name := range []string{"fn1", "logs"} {
+		handler := filepath.Join(workDir, name)
+		os.MkdirAll(handler, 0700)
+		services.Functions[name] = stack.Function{Language: "python3", Handler: handler, Image: "functions/" + name}
+	}
+
+	var buildErr error
+	test.CaptureStdout(func() {
+		buildErr = BuildStack(context.Background(), &fakeBuilder{images: map[string]bool{}}, services, 1, false, false, false, nil, nil, true, false, true)
+	})
+	if buildErr != nil {
+		t.Fatal(buildErr)
+	}
+	if _, err := os.Stat(filepath.Join(workDir, BuildLogDir, "fn1.log")); err != nil {
+		t.Errorf("want the log of fn1 kept when a function is named logs: %s", err)
+	}
+
+	if _, err := createBuildTemplate(ioutil.Discard, ".logs", filepath.Join(workDir, "fn1"), "python3", "function"); err == nil {
+		t.Errorf("want a function name starting with a dot refused")
+	}
+}
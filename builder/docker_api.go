@@ -0,0 +1,434 @@
+// Copyright (c) OpenFaaS Project 2018. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package builder
+
+import (
+	"archive/tar"
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/mitchellh/go-homedir"
+	"github.com/openfaas/faas-cli/config"
+)
+
+// DefaultDockerHost is the Docker daemon socket used when DOCKER_HOST is not set
+const DefaultDockerHost = "unix:///var/run/docker.sock"
+
+// DaemonError is returned when the Docker daemon can not be reached or rejects a request
+type DaemonError struct {
+	Host string
+	// StatusCode is the HTTP status returned by the daemon, or 0 if it could not be reached
+	StatusCode int
+	Message    string
+}
+
+func (e *DaemonError) Error() string {
+	if e.StatusCode == 0 {
+		return fmt.Sprintf("cannot connect to the Docker daemon at %s: %s", e.Host, e.Message)
+	}
+	return fmt.Sprintf("Docker daemon at %s returned %d: %s", e.Host, e.StatusCode, e.Message)
+}
+
+// BuildError is returned when the Docker daemon reports a failed build, i.e. a RUN step exiting non-zero
+type BuildError struct {
+	Image   string
+	Message string
+}
+
+func (e *BuildError) Error() string {
+	return fmt.Sprintf("build of %s failed: %s", e.Image, e.Message)
+}
+
+// PushError is returned when the Docker daemon reports a failed push, i.e. the registry denied access
+type PushError struct {
+	Image   string
+	Message string
+}
+
+func (e *PushError) Error() string {
+	return fmt.Sprintf("push of %s failed: %s", e.Image, e.Message)
+}
+
+// DockerAPIBuilder builds and pushes images through the Docker Engine API
+// without needing the docker CLI. The build context is streamed to the daemon
+// as a tar archive created in-process.
+type DockerAPIBuilder struct {
+	// Host is the daemon address such as unix:///var/run/docker.sock or tcp://127.0.0.1:2375
+	Host string
+	// Output receives the build and push log, defaults to os.Stdout
+	Output io.Writer
+	// TLS makes a tcp:// host be reached over HTTPS when set
+	TLS *config.TLSConfig
+}
+
+// NewDockerAPIBuilder returns a DockerAPIBuilder for the daemon set in DOCKER_HOST,
+// using TLS when DOCKER_TLS_VERIFY or DOCKER_TLS is set as the docker CLI does
+func NewDockerAPIBuilder() *DockerAPIBuilder {
+	host := os.Getenv("DOCKER_HOST")
+	if len(host) == 0 {
+		host = DefaultDockerHost
+	}
+	return &DockerAPIBuilder{Host: host, Output: os.Stdout, TLS: dockerTLSConfig()}
+}
+
+// dockerTLSConfig reads the TLS settings of the daemon from the environment,
+// the certificates are the ca.pem, cert.pem and key.pem files found in
+// DOCKER_CERT_PATH, which defaults to ~/.docker
+func dockerTLSConfig() *config.TLSConfig {
+	verify := len(os.Getenv("DOCKER_TLS_VERIFY")) > 0
+	if !verify && len(os.Getenv("DOCKER_TLS")) == 0 {
+		return nil
+	}
+
+	certPath := os.Getenv("DOCKER_CERT_PATH")
+	if len(certPath) == 0 {
+		if home, err := homedir.Dir(); err == nil {
+			certPath = filepath.Join(home, ".docker")
+		}
+	}
+
+	tlsConfig := &config.TLSConfig{InsecureSkipVerify: !verify}
+	if len(certPath) == 0 {
+		return tlsConfig
+	}
+
+	for _, file := range []struct {
+		name string
+		path *string
+	}{
+		{"ca.pem", &tlsConfig.CACert},
+		{"cert.pem", &tlsConfig.ClientCert},
+		{"key.pem", &tlsConfig.ClientKey},
+	} {
+		if _, err := os.Stat(filepath.Join(certPath, file.name)); err == nil {
+			*file.path = filepath.Join(certPath, file.name)
+		}
+	}
+	return tlsConfig
+}
+
+// dockerMessage is a single entry of the JSON stream returned by the build and push endpoints
+type dockerMessage struct {
+	Stream      string `json:"stream"`
+	Status      string `json:"status"`
+	Progress    string `json:"progress"`
+	ID          string `json:"id"`
+	Error       string `json:"error"`
+	ErrorDetail *struct {
+		Message string `json:"message"`
+	} `json:"errorDetail"`
+}
+
+// Build sends the context directory to the daemon and streams back the build log
+func (b *DockerAPIBuilder) Build(ctx context.Context, config BuildConfig) error {
+	client, baseURL, err := b.client()
+	if err != nil {
+		return err
+	}
+
+	buildArgs, err := json.Marshal(withProxyBuildArgs(config.BuildArgs))
+	if err != nil {
+		return err
+	}
+
+	query := url.Values{}
+	query.Set("t", config.Image)
+	query.Set("rm", "1")
+	query.Set("buildargs", string(buildArgs))
+	if config.NoCache {
+		query.Set("nocache", "1")
+	}
+	if config.Squash {
+		query.Set("squash", "1")
+	}
+
+	buildContext, writer := io.Pipe()
+	go func() {
+		writer.CloseWithError(writeBuildContext(config.ContextPath, writer))
+	}()
+	defer buildContext.Close()
+
+	req, err := http.NewRequest(http.MethodPost, baseURL+"/build?"+query.Encode(), buildContext)
+	if err != nil {
+		return err
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Content-Type", "application/x-tar")
+
+	res, err := client.Do(req)
+	if err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		return &DaemonError{Host: b.Host, Message: err.Error()}
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return &DaemonError{Host: b.Host, StatusCode: res.StatusCode, Message: readDaemonMessage(res.Body)}
+	}
+
+	output := config.Output
+	if output == nil {
+		output = b.Output
+	}
+	err = b.readStream(res.Body, output, func(message string) error {
+		return &BuildError{Image: config.Image, Message: message}
+	})
+	if err != nil && ctx.Err() != nil {
+		return ctx.Err()
+	}
+	return err
+}
+
+// Push pushes an image from the daemon to its registry, using the
+// credentials stored by docker login
+func (b *DockerAPIBuilder) Push(ctx context.Context, image string) error {
+	client, baseURL, err := b.client()
+	if err != nil {
+		return err
+	}
+
+	name, tag := splitImageTag(image)
+	registryAuth, err := encodeRegistryAuth(lookupRegistryAuth(registryHost(name)))
+	if err != nil {
+		return err
+	}
+
+	query := url.Values{}
+	if len(tag) > 0 {
+		query.Set("tag", tag)
+	}
+
+	req, err := http.NewRequest(http.MethodPost, baseURL+"/images/"+name+"/push?"+query.Encode(), nil)
+	if err != nil {
+		return err
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("X-Registry-Auth", registryAuth)
+
+	res, err := client.Do(req)
+	if err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		return &DaemonError{Host: b.Host, Message: err.Error()}
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return &DaemonError{Host: b.Host, StatusCode: res.StatusCode, Message: readDaemonMessage(res.Body)}
+	}
+
+	err = b.readStream(res.Body, b.Output, func(message string) error {
+		return &PushError{Image: image, Message: message}
+	})
+	if err != nil && ctx.Err() != nil {
+		return ctx.Err()
+	}
+	return err
+}
+
+// ImageExists inspects the image on the daemon
+func (b *DockerAPIBuilder) ImageExists(image string) (bool, error) {
+	client, baseURL, err := b.client()
+	if err != nil {
+		return false, err
+	}
+
+	res, err := client.Get(baseURL + "/images/" + image + "/json")
+	if err != nil {
+		return false, &DaemonError{Host: b.Host, Message: err.Error()}
+	}
+	defer res.Body.Close()
+
+	switch res.StatusCode {
+	case http.StatusOK:
+		return true, nil
+	case http.StatusNotFound:
+		return false, nil
+	default:
+		return false, &DaemonError{Host: b.Host, StatusCode: res.StatusCode, Message: readDaemonMessage(res.Body)}
+	}
+}
+
+// readStream prints the progress stream returned by the daemon to output, an
+// error reported in the stream is turned into a typed error by newError
+func (b *DockerAPIBuilder) readStream(body io.Reader, output io.Writer, newError func(message string) error) error {
+	if output == nil {
+		output = os.Stdout
+	}
+
+	decoder := json.NewDecoder(bufio.NewReader(body))
+	for {
+		var message dockerMessage
+		if err := decoder.Decode(&message); err == io.EOF {
+			return nil
+		} else if err != nil {
+			return &DaemonError{Host: b.Host, StatusCode: http.StatusOK, Message: fmt.Sprintf("unable to read daemon output: %v", err)}
+		}
+
+		if len(message.Error) > 0 || message.ErrorDetail != nil {
+			errorMessage := message.Error
+			if message.ErrorDetail != nil && len(message.ErrorDetail.Message) > 0 {
+				errorMessage = message.ErrorDetail.Message
+			}
+			return newError(errorMessage)
+		}
+
+		if len(message.Stream) > 0 {
+			fmt.Fprint(output, message.Stream)
+		} else if len(message.Status) > 0 && len(message.Progress) == 0 {
+			if len(message.ID) > 0 {
+				fmt.Fprintf(output, "%s: %s\n", message.ID, message.Status)
+			} else {
+				fmt.Fprintln(output, message.Status)
+			}
+		}
+	}
+}
+
+// client returns an HTTP client which dials the daemon along with the base URL to use for requests
+func (b *DockerAPIBuilder) client() (*http.Client, string, error) {
+	hostURL, err := url.Parse(b.Host)
+	if err != nil {
+		return nil, "", fmt.Errorf("invalid Docker host %s: %v", b.Host, err)
+	}
+
+	switch hostURL.Scheme {
+	case "unix":
+		socketPath := hostURL.Path
+		transport := &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				var dialer net.Dialer
+				return dialer.DialContext(ctx, "unix", socketPath)
+			},
+		}
+		return &http.Client{Transport: transport}, "http://docker", nil
+	case "tcp", "http":
+		if b.TLS == nil || hostURL.Scheme == "http" {
+			return &http.Client{}, "http://" + hostURL.Host, nil
+		}
+
+		tlsConfig, err := b.TLS.ClientConfig()
+		if err != nil {
+			return nil, "", fmt.Errorf("unable to use TLS for Docker host %s: %v", b.Host, err)
+		}
+		transport := &http.Transport{TLSClientConfig: tlsConfig}
+		return &http.Client{Transport: transport}, "https://" + hostURL.Host, nil
+	default:
+		return nil, "", fmt.Errorf("unsupported Docker host %s, use a unix:// or tcp:// address or select the docker-cli engine", b.Host)
+	}
+}
+
+func readDaemonMessage(body io.Reader) string {
+	data, _ := ioutil.ReadAll(body)
+
+	var message struct {
+		Message string `json:"message"`
+	}
+	if err := json.Unmarshal(data, &message); err == nil && len(message.Message) > 0 {
+		return message.Message
+	}
+	return strings.TrimSpace(string(data))
+}
+
+// withProxyBuildArgs passes the proxy settings of the environment to the build, as the docker CLI does
+func withProxyBuildArgs(buildArgMap map[string]string) map[string]string {
+	merged := make(map[string]string)
+	for _, name := range []string{"http_proxy", "https_proxy"} {
+		if value := os.Getenv(name); len(value) > 0 {
+			merged[name] = value
+		}
+	}
+	for k, v := range buildArgMap {
+		merged[k] = v
+	}
+	return merged
+}
+
+// writeBuildContext writes the files under contextPath to w as a tar archive,
+// leaving out any paths matched by a .dockerignore file.
+func writeBuildContext(contextPath string, w io.Writer) error {
+	ignored, err := readDockerignore(contextPath)
+	if err != nil {
+		return err
+	}
+
+	tarWriter := tar.NewWriter(w)
+
+	err = filepath.Walk(contextPath, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		relativePath, err := filepath.Rel(contextPath, path)
+		if err != nil {
+			return err
+		}
+		if relativePath == "." {
+			return nil
+		}
+		relativePath = filepath.ToSlash(relativePath)
+
+		// the Dockerfile and .dockerignore are always sent as the daemon needs them
+		if relativePath != "Dockerfile" && relativePath != ".dockerignore" && ignored.matches(relativePath) {
+			// a folder is still walked when an exclusion may send some of its files
+			if info.IsDir() && !ignored.exclusions {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		var link string
+		if info.Mode()&os.ModeSymlink != 0 {
+			if link, err = os.Readlink(path); err != nil {
+				return err
+			}
+		}
+
+		header, err := tar.FileInfoHeader(info, link)
+		if err != nil {
+			return err
+		}
+		header.Name = relativePath
+		if info.IsDir() {
+			header.Name += "/"
+		}
+		header.Uid, header.Gid = 0, 0
+		header.Uname, header.Gname = "", ""
+
+		if err := tarWriter.WriteHeader(header); err != nil {
+			return err
+		}
+
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+
+		file, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+
+		_, err = io.Copy(tarWriter, file)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("unable to create build context from %s: %v", contextPath, err)
+	}
+
+	return tarWriter.Close()
+}
@@ -0,0 +1,339 @@
+// Copyright (c) OpenFaaS Project 2018. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package builder
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/openfaas/faas-cli/test"
+)
+
+func writeBuildContextDir(t *testing.T) string {
+	contextPath, err := ioutil.TempDir("", "faas-cli-build-context")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	files := map[string]string{
+		"Dockerfile":            "FROM alpine:3.7\n",
+		"function/handler.py":   "def handle(req):\n    return req\n",
+		"function/debug.log":    "ignored\n",
+		"function/keep.log":     "kept\n",
+		".dockerignore":         "# logs are not needed\nfunction/*.log\n!function/keep.log\n**/*.pyc\n",
+		"function/README.md":    "docs\n",
+		"function/nested/x.py":  "x = 1\n",
+		"function/nested/x.pyc": "ignored\n",
+		"cache.pyc":             "ignored\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(contextPath, name)
+		os.MkdirAll(filepath.Dir(path), 0700)
+		if err := ioutil.WriteFile(path, []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return contextPath
+}
+
+func Test_writeBuildContext(t *testing.T) {
+	contextPath := writeBuildContextDir(t)
+	defer os.RemoveAll(contextPath)
+
+	var archive bytes.Buffer
+	if err := writeBuildContext(contextPath, &archive); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	var names []string
+	reader := tar.NewReader(&archive)
+	for {
+		header, err := reader.Next()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			t.Fatal(err)
+		}
+		names = append(names, header.Name)
+	}
+	sort.Strings(names)
+
+	want := []string{".dockerignore", "Dockerfile", "function/", "function/README.md", "function/handler.py", "function/keep.log", "function/nested/", "function/nested/x.py"}
+	if !reflect.DeepEqual(names, want) {
+		t.Errorf("want %v, got %v", want, names)
+	}
+}
+
+func Test_DockerAPIBuilder_Build(t *testing.T) {
+	contextPath := writeBuildContextDir(t)
+	defer os.RemoveAll(contextPath)
+
+	var query map[string][]string
+	var contextFiles []string
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/build" || r.Method != http.MethodPost {
+			t.Errorf("want POST /build, got %s %s", r.Method, r.URL.Path)
+		}
+		query = r.URL.Query()
+
+		reader := tar.NewReader(r.Body)
+		for {
+			header, err := reader.Next()
+			if err != nil {
+				break
+			}
+			contextFiles = append(contextFiles, header.Name)
+		}
+
+		w.Write([]byte(`{"stream":"Step 1/1 : FROM alpine:3.7\n"}` + "\n"))
+		w.Write([]byte(`{"status":"Pulling from library/alpine","id":"3.7"}` + "\n"))
+		w.Write([]byte(`{"stream":"Successfully tagged alexellis/url-ping:latest\n"}` + "\n"))
+	}))
+	defer s.Close()
+
+	var output bytes.Buffer
+	imageBuilder := &DockerAPIBuilder{Host: "tcp://" + strings.TrimPrefix(s.URL, "http://"), Output: &output}
+
+	err := imageBuilder.Build(context.Background(), BuildConfig{
+		ContextPath: contextPath,
+		Image:       "alexellis/url-ping:latest",
+		NoCache:     true,
+		BuildArgs:   map[string]string{"GO_VERSION": "1.9"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if got := query["t"]; !reflect.DeepEqual(got, []string{"alexellis/url-ping:latest"}) {
+		t.Errorf("want tag alexellis/url-ping:latest, got %v", got)
+	}
+	if got := query["nocache"]; !reflect.DeepEqual(got, []string{"1"}) {
+		t.Errorf("want nocache=1, got %v", got)
+	}
+	if _, found := query["squash"]; found {
+		t.Errorf("squash must not be set")
+	}
+
+	var buildArgs map[string]string
+	json.Unmarshal([]byte(query["buildargs"][0]), &buildArgs)
+	if buildArgs["GO_VERSION"] != "1.9" {
+		t.Errorf("want build arg GO_VERSION=1.9, got %v", buildArgs)
+	}
+
+	if len(contextFiles) != 8 {
+		t.Errorf("want 8 entries in the build context, got %v", contextFiles)
+	}
+
+	want := "Step 1/1 : FROM alpine:3.7\n3.7: Pulling from library/alpine\nSuccessfully tagged alexellis/url-ping:latest\n"
+	if output.String() != want {
+		t.Errorf("want output:\n%s\ngot:\n%s", want, output.String())
+	}
+}
+
+func Test_DockerAPIBuilder_Build_Errors(t *testing.T) {
+	contextPath := writeBuildContextDir(t)
+	defer os.RemoveAll(contextPath)
+
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ioutil.ReadAll(r.Body)
+		if r.URL.Query().Get("t") == "rejected" {
+			w.WriteHeader(http.StatusInternalServerError)
+			w.Write([]byte(`{"message":"squash is only supported with experimental mode"}`))
+			return
+		}
+		w.Write([]byte(`{"stream":"Step 2/2 : RUN false\n"}` + "\n"))
+		w.Write([]byte(`{"errorDetail":{"code":1,"message":"The command '/bin/sh -c false' returned a non-zero code: 1"},"error":"The command '/bin/sh -c false' returned a non-zero code: 1"}` + "\n"))
+	}))
+	defer s.Close()
+
+	imageBuilder := &DockerAPIBuilder{Host: "tcp://" + strings.TrimPrefix(s.URL, "http://"), Output: ioutil.Discard}
+
+	err := imageBuilder.Build(context.Background(), BuildConfig{ContextPath: contextPath, Image: "failing"})
+	buildErr, ok := err.(*BuildError)
+	if !ok {
+		t.Fatalf("want *BuildError, got %T: %v", err, err)
+	}
+	if buildErr.Message != "The command '/bin/sh -c false' returned a non-zero code: 1" {
+		t.Errorf("unexpected build error message: %s", buildErr.Message)
+	}
+
+	err = imageBuilder.Build(context.Background(), BuildConfig{ContextPath: contextPath, Image: "rejected", Squash: true})
+	daemonErr, ok := err.(*DaemonError)
+	if !ok {
+		t.Fatalf("want *DaemonError, got %T: %v", err, err)
+	}
+	if daemonErr.StatusCode != http.StatusInternalServerError || daemonErr.Message != "squash is only supported with experimental mode" {
+		t.Errorf("unexpected daemon error: %v", daemonErr)
+	}
+
+	unreachable := &DockerAPIBuilder{Host: "unix://" + filepath.Join(contextPath, "missing.sock"), Output: ioutil.Discard}
+	err = unreachable.Build(context.Background(), BuildConfig{ContextPath: contextPath, Image: "unreachable"})
+	if daemonErr, ok := err.(*DaemonError); !ok || daemonErr.StatusCode != 0 {
+		t.Errorf("want *DaemonError for an unreachable daemon, got %T: %v", err, err)
+	}
+}
+
+func Test_DockerAPIBuilder_Push(t *testing.T) {
+	configDir, err := ioutil.TempDir("", "faas-cli-docker-config")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(configDir)
+	ioutil.WriteFile(filepath.Join(configDir, "config.json"), []byte(`{"auths":{"registry.local:5000":{"auth":"YWxpY2U6c2VjcmV0"}}}`), 0600)
+	os.Setenv("DOCKER_CONFIG", configDir)
+	defer os.Unsetenv("DOCKER_CONFIG")
+
+	var path, tag, registryAuthHeader string
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.Path
+		tag = r.URL.Query().Get("tag")
+		registryAuthHeader = r.Header.Get("X-Registry-Auth")
+		w.Write([]byte(`{"status":"The push refers to repository [registry.local:5000/fn/url-ping]"}` + "\n"))
+		w.Write([]byte(`{"errorDetail":{"message":"unauthorized: authentication required"},"error":"unauthorized: authentication required"}` + "\n"))
+	}))
+	defer s.Close()
+
+	imageBuilder := &DockerAPIBuilder{Host: "tcp://" + strings.TrimPrefix(s.URL, "http://"), Output: ioutil.Discard}
+	err = imageBuilder.Push(context.Background(), "registry.local:5000/fn/url-ping:0.1")
+
+	if path != "/images/registry.local:5000/fn/url-ping/push" || tag != "0.1" {
+		t.Errorf("want push of registry.local:5000/fn/url-ping with tag 0.1, got %s tag %s", path, tag)
+	}
+
+	decoded, _ := base64.URLEncoding.DecodeString(registryAuthHeader)
+	var auth registryAuth
+	json.Unmarshal(decoded, &auth)
+	if auth.Username != "alice" || auth.Password != "secret" || auth.ServerAddress != "registry.local:5000" {
+		t.Errorf("want credentials from docker config, got %+v", auth)
+	}
+
+	if pushErr, ok := err.(*PushError); !ok || pushErr.Message != "unauthorized: authentication required" {
+		t.Errorf("want *PushError, got %T: %v", err, err)
+	}
+}
+
+func Test_DockerAPIBuilder_TLS(t *testing.T) {
+	certPath, err := ioutil.TempDir("", "faas-cli-docker-certs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(certPath)
+
+	serverCert, serverKey := test.WriteCertificate(t, certPath, "daemon")
+	clientCert, clientKey := test.WriteCertificate(t, certPath, "client")
+	for file, name := range map[string]string{serverCert: "ca.pem", clientCert: "cert.pem", clientKey: "key.pem"} {
+		data, _ := ioutil.ReadFile(file)
+		ioutil.WriteFile(filepath.Join(certPath, name), data, 0600)
+	}
+
+	certificate, err := tls.LoadX509KeyPair(serverCert, serverKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	clientCAs := x509.NewCertPool()
+	clientPEM, _ := ioutil.ReadFile(clientCert)
+	clientCAs.AppendCertsFromPEM(clientPEM)
+
+	s := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/images/alexellis/url-ping:latest/json" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write([]byte(`{}`))
+	}))
+	s.TLS = &tls.Config{
+		Certificates: []tls.Certificate{certificate},
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+		ClientCAs:    clientCAs,
+	}
+	s.StartTLS()
+	defer s.Close()
+
+	os.Setenv("DOCKER_HOST", "tcp://"+strings.TrimPrefix(s.URL, "https://"))
+	os.Setenv("DOCKER_TLS_VERIFY", "1")
+	os.Setenv("DOCKER_CERT_PATH", certPath)
+	defer os.Unsetenv("DOCKER_HOST")
+	defer os.Unsetenv("DOCKER_TLS_VERIFY")
+	defer os.Unsetenv("DOCKER_CERT_PATH")
+
+	exists, err := NewDockerAPIBuilder().ImageExists("alexellis/url-ping:latest")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if !exists {
+		t.Errorf("want image to exist")
+	}
+
+	os.Unsetenv("DOCKER_TLS_VERIFY")
+	plain := NewDockerAPIBuilder()
+	if plain.TLS != nil {
+		t.Errorf("want no TLS without DOCKER_TLS_VERIFY or DOCKER_TLS, got %+v", plain.TLS)
+	}
+	if _, err := plain.ImageExists("alexellis/url-ping:latest"); err == nil {
+		t.Errorf("want error when sending plain HTTP to a TLS daemon")
+	}
+}
+
+func Test_ExecCommand_ReturnsError(t *testing.T) {
+	err := ExecCommand("./", []string{"false"})
+	if _, ok := err.(*CommandError); !ok {
+		t.Errorf("want *CommandError, got %T: %v", err, err)
+	}
+}
+
+func Test_execCommand_Cancelled(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	err := execCommand(ctx, "./", []string{"sleep", "10"}, ioutil.Discard)
+	if err != context.DeadlineExceeded {
+		t.Errorf("want the deadline of the context, got %T: %v", err, err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("want the command killed when the context is done, it ran for %s", elapsed)
+	}
+}
+
+func Test_DockerAPIBuilder_Build_Cancelled(t *testing.T) {
+	contextPath := writeBuildContextDir(t)
+	defer os.RemoveAll(contextPath)
+
+	done := make(chan struct{})
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ioutil.ReadAll(r.Body)
+		w.Write([]byte(`{"stream":"Step 1/2 : FROM alpine:3.7\n"}` + "\n"))
+		w.(http.Flusher).Flush()
+		select {
+		case <-r.Context().Done():
+		case <-done:
+		}
+	}))
+	defer s.Close()
+	defer close(done)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	imageBuilder := &DockerAPIBuilder{Host: "tcp://" + strings.TrimPrefix(s.URL, "http://"), Output: ioutil.Discard}
+	if err := imageBuilder.Build(ctx, BuildConfig{ContextPath: contextPath, Image: "slow"}); err != context.DeadlineExceeded {
+		t.Errorf("want the deadline of the context, got %T: %v", err, err)
+	}
+}
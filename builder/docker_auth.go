@@ -0,0 +1,156 @@
+// Copyright (c) OpenFaaS Project 2018. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package builder
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/mitchellh/go-homedir"
+)
+
+// dockerHubRegistry is the key docker login uses for Docker Hub credentials
+const dockerHubRegistry = "https://index.docker.io/v1/"
+
+// registryAuth is the credential sent to the daemon in the X-Registry-Auth header
+type registryAuth struct {
+	Username      string `json:"username,omitempty"`
+	Password      string `json:"password,omitempty"`
+	IdentityToken string `json:"identitytoken,omitempty"`
+	ServerAddress string `json:"serveraddress,omitempty"`
+}
+
+// dockerConfig is the part of ~/.docker/config.json which holds registry credentials
+type dockerConfig struct {
+	Auths map[string]struct {
+		Auth          string `json:"auth"`
+		IdentityToken string `json:"identitytoken"`
+	} `json:"auths"`
+	CredsStore  string            `json:"credsStore"`
+	CredHelpers map[string]string `json:"credHelpers"`
+}
+
+// splitImageTag splits an image such as registry:5000/org/fn:0.1 into its name and tag
+func splitImageTag(image string) (string, string) {
+	if index := strings.LastIndex(image, ":"); index > strings.LastIndex(image, "/") {
+		return image[:index], image[index+1:]
+	}
+	return image, ""
+}
+
+// registryHost returns the registry an image name belongs to, Docker Hub is used when none is given
+func registryHost(name string) string {
+	parts := strings.SplitN(name, "/", 2)
+	if len(parts) == 2 && (strings.ContainsAny(parts[0], ".:") || parts[0] == "localhost") {
+		return parts[0]
+	}
+	return dockerHubRegistry
+}
+
+// lookupRegistryAuth finds the credentials stored by docker login for a
+// registry, either in config.json or through a credential helper. An empty
+// registryAuth is returned when there are none, which is enough for
+// registries without authentication.
+func lookupRegistryAuth(registry string) registryAuth {
+	config, err := readDockerConfig()
+	if err != nil {
+		return registryAuth{}
+	}
+
+	candidates := []string{registry, "https://" + registry, "http://" + registry}
+	if registry == dockerHubRegistry {
+		candidates = []string{dockerHubRegistry, "index.docker.io", "docker.io"}
+	}
+
+	if helper, found := config.CredHelpers[registry]; found {
+		return credentialHelperAuth(helper, registry)
+	}
+
+	for _, candidate := range candidates {
+		entry, found := config.Auths[candidate]
+		if !found {
+			continue
+		}
+
+		if len(entry.Auth) > 0 {
+			decoded, err := base64.StdEncoding.DecodeString(entry.Auth)
+			if err == nil {
+				userPassword := strings.SplitN(string(decoded), ":", 2)
+				if len(userPassword) == 2 {
+					return registryAuth{Username: userPassword[0], Password: userPassword[1], ServerAddress: candidate}
+				}
+			}
+		}
+		if len(entry.IdentityToken) > 0 {
+			return registryAuth{IdentityToken: entry.IdentityToken, ServerAddress: candidate}
+		}
+	}
+
+	if len(config.CredsStore) > 0 {
+		return credentialHelperAuth(config.CredsStore, registry)
+	}
+
+	return registryAuth{}
+}
+
+func readDockerConfig() (*dockerConfig, error) {
+	configDir := os.Getenv("DOCKER_CONFIG")
+	if len(configDir) == 0 {
+		home, err := homedir.Dir()
+		if err != nil {
+			return nil, err
+		}
+		configDir = filepath.Join(home, ".docker")
+	}
+
+	data, err := ioutil.ReadFile(filepath.Join(configDir, "config.json"))
+	if err != nil {
+		return nil, err
+	}
+
+	var config dockerConfig
+	if err := json.Unmarshal(data, &config); err != nil {
+		return nil, err
+	}
+	return &config, nil
+}
+
+// credentialHelperAuth asks a docker-credential-* helper for the credentials of a registry
+func credentialHelperAuth(helper string, registry string) registryAuth {
+	cmd := exec.Command("docker-credential-"+helper, "get")
+	cmd.Stdin = strings.NewReader(registry)
+	var stdout bytes.Buffer
+	cmd.Stdout = &stdout
+	if err := cmd.Run(); err != nil {
+		return registryAuth{}
+	}
+
+	var credentials struct {
+		Username string
+		Secret   string
+	}
+	if err := json.Unmarshal(stdout.Bytes(), &credentials); err != nil {
+		return registryAuth{}
+	}
+
+	// helpers return an identity token with this placeholder username
+	if credentials.Username == "<token>" {
+		return registryAuth{IdentityToken: credentials.Secret, ServerAddress: registry}
+	}
+	return registryAuth{Username: credentials.Username, Password: credentials.Secret, ServerAddress: registry}
+}
+
+func encodeRegistryAuth(auth registryAuth) (string, error) {
+	data, err := json.Marshal(auth)
+	if err != nil {
+		return "", err
+	}
+	return base64.URLEncoding.EncodeToString(data), nil
+}
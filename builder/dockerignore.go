@@ -0,0 +1,144 @@
+// Copyright (c) OpenFaaS Project 2018. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package builder
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// ignorePattern is a single line of a .dockerignore file
+type ignorePattern struct {
+	regexp *regexp.Regexp
+	// dirs is the number of path segments of the pattern
+	dirs int
+	// exclusion is set for a pattern starting with !, which sends paths again
+	exclusion bool
+}
+
+// ignoreMatcher matches build context paths against a .dockerignore file the
+// way the docker CLI does: ** matches any number of folders, a pattern which
+// matches a folder matches everything inside it, and a pattern starting with !
+// sends paths matched by an earlier pattern again. The last match wins.
+type ignoreMatcher struct {
+	patterns   []ignorePattern
+	exclusions bool
+}
+
+// readDockerignore reads the .dockerignore file of a build context, if there is one
+func readDockerignore(contextPath string) (*ignoreMatcher, error) {
+	data, err := ioutil.ReadFile(filepath.Join(contextPath, ".dockerignore"))
+	if os.IsNotExist(err) {
+		return &ignoreMatcher{}, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	matcher, err := newIgnoreMatcher(strings.Split(string(data), "\n"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid .dockerignore in %s: %v", contextPath, err)
+	}
+	return matcher, nil
+}
+
+// newIgnoreMatcher compiles the lines of a .dockerignore file, skipping blank lines and comments
+func newIgnoreMatcher(lines []string) (*ignoreMatcher, error) {
+	matcher := &ignoreMatcher{}
+
+	for _, line := range lines {
+		pattern := strings.TrimSpace(line)
+		if len(pattern) == 0 || strings.HasPrefix(pattern, "#") {
+			continue
+		}
+
+		exclusion := strings.HasPrefix(pattern, "!")
+		if exclusion {
+			pattern = strings.TrimSpace(pattern[1:])
+			if len(pattern) == 0 {
+				return nil, fmt.Errorf("illegal exclusion pattern: \"!\"")
+			}
+			matcher.exclusions = true
+		}
+
+		pattern = strings.TrimPrefix(filepath.ToSlash(filepath.Clean(pattern)), "/")
+		compiled, err := compileIgnorePattern(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pattern %s: %v", pattern, err)
+		}
+
+		matcher.patterns = append(matcher.patterns, ignorePattern{
+			regexp:    compiled,
+			dirs:      len(strings.Split(pattern, "/")),
+			exclusion: exclusion,
+		})
+	}
+	return matcher, nil
+}
+
+// compileIgnorePattern turns a slash separated .dockerignore pattern into a regular expression
+func compileIgnorePattern(pattern string) (*regexp.Regexp, error) {
+	expression := "^"
+	for i := 0; i < len(pattern); i++ {
+		switch ch := pattern[i]; ch {
+		case '*':
+			if i+1 < len(pattern) && pattern[i+1] == '*' {
+				i++
+				if i+1 < len(pattern) && pattern[i+1] == '/' {
+					i++
+				}
+				if i+1 == len(pattern) {
+					expression += ".*"
+				} else {
+					expression += "(.*/)?"
+				}
+			} else {
+				expression += "[^/]*"
+			}
+		case '?':
+			expression += "[^/]"
+		case '[':
+			end := strings.IndexByte(pattern[i:], ']')
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated character class")
+			}
+			class := pattern[i+1 : i+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			expression += "[" + class + "]"
+			i += end
+		case '\\':
+			if i+1 < len(pattern) {
+				i++
+				expression += regexp.QuoteMeta(string(pattern[i]))
+			}
+		default:
+			expression += regexp.QuoteMeta(string(ch))
+		}
+	}
+	return regexp.Compile(expression + "$")
+}
+
+// matches tells whether a slash separated context path is left out of the build context
+func (m *ignoreMatcher) matches(relativePath string) bool {
+	parentDirs := strings.Split(relativePath, "/")
+	parentDirs = parentDirs[:len(parentDirs)-1]
+
+	matched := false
+	for _, pattern := range m.patterns {
+		match := pattern.regexp.MatchString(relativePath)
+		// a pattern matching one of the folders of the path matches the path
+		if !match && len(parentDirs) > 0 && pattern.dirs <= len(parentDirs) {
+			match = pattern.regexp.MatchString(strings.Join(parentDirs[:pattern.dirs], "/"))
+		}
+		if match {
+			matched = !pattern.exclusion
+		}
+	}
+	return matched
+}
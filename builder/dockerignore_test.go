@@ -0,0 +1,61 @@
+// Copyright (c) OpenFaaS Project 2018. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package builder
+
+import (
+	"testing"
+)
+
+func Test_ignoreMatcher(t *testing.T) {
+	matcher, err := newIgnoreMatcher([]string{
+		"# comment",
+		"",
+		"*.md",
+		"!README.md",
+		"**/*.pyc",
+		"/build",
+		"docs/**",
+		"!docs/index.html",
+		"tmp?",
+		"[ab].txt",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	cases := []struct {
+		path    string
+		ignored bool
+	}{
+		{"CHANGELOG.md", true},
+		{"README.md", false},
+		{"function/CHANGELOG.md", false},
+		{"x.pyc", true},
+		{"function/nested/x.pyc", true},
+		{"function/x.py", false},
+		{"build", true},
+		{"build/function/handler.py", true},
+		{"function/build", false},
+		{"docs/guide/setup.html", true},
+		{"docs/index.html", false},
+		{"tmp1/file", true},
+		{"tmp", false},
+		{"a.txt", true},
+		{"c.txt", false},
+		{"# comment", false},
+	}
+	for _, c := range cases {
+		if got := matcher.matches(c.path); got != c.ignored {
+			t.Errorf("%s: want ignored %t, got %t", c.path, c.ignored, got)
+		}
+	}
+}
+
+func Test_newIgnoreMatcher_Errors(t *testing.T) {
+	for _, lines := range [][]string{{"!"}, {"function/[a.txt"}} {
+		if _, err := newIgnoreMatcher(lines); err == nil {
+			t.Errorf("%v: want error, got nil", lines)
+		}
+	}
+}
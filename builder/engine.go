@@ -0,0 +1,75 @@
+// Copyright (c) OpenFaaS Project 2018. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package builder
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+)
+
+// DefaultEngine is the build engine used when none is selected
+const DefaultEngine = "docker"
+
+// Builder builds container images from a build context on disk and pushes them to a registry,
+// a build or push is stopped when its context is cancelled
+type Builder interface {
+	Build(ctx context.Context, config BuildConfig) error
+	Push(ctx context.Context, image string) error
+	// ImageExists tells whether the image is present in the engine's local image store
+	ImageExists(image string) (bool, error)
+}
+
+// BuildConfig describes a single image build
+type BuildConfig struct {
+	// ContextPath is the directory sent as the build context, it must contain a Dockerfile
+	ContextPath string
+	Image       string
+	NoCache     bool
+	Squash      bool
+	BuildArgs   map[string]string
+	// Output receives the build log, defaults to os.Stdout
+	Output io.Writer
+}
+
+// output returns the writer for the build log
+func (c BuildConfig) output() io.Writer {
+	if c.Output == nil {
+		return os.Stdout
+	}
+	return c.Output
+}
+
+// engines maps the names accepted by --engine to their Builder
+var engines = map[string]func() Builder{
+	"docker":     func() Builder { return NewDockerAPIBuilder() },
+	"docker-cli": func() Builder { return &ExecBuilder{} },
+	"podman":     func() Builder { return &PodmanBuilder{} },
+	"buildah":    func() Builder { return &BuildahBuilder{} },
+}
+
+// NewBuilder returns the Builder for the named engine, an empty name selects the DefaultEngine
+func NewBuilder(engine string) (Builder, error) {
+	if len(engine) == 0 {
+		engine = DefaultEngine
+	}
+
+	newBuilder, found := engines[engine]
+	if !found {
+		return nil, fmt.Errorf("unknown build engine %s, valid engines are: %v", engine, Engines())
+	}
+	return newBuilder(), nil
+}
+
+// Engines lists the names of the available build engines
+func Engines() []string {
+	var names []string
+	for name := range engines {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
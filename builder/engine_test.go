@@ -0,0 +1,100 @@
+// Copyright (c) OpenFaaS Project 2018. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package builder
+
+import (
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func Test_NewBuilder(t *testing.T) {
+	engineTests := map[string]Builder{
+		"":           &DockerAPIBuilder{},
+		"docker":     &DockerAPIBuilder{},
+		"docker-cli": &ExecBuilder{},
+		"podman":     &PodmanBuilder{},
+		"buildah":    &BuildahBuilder{},
+	}
+	for engine, want := range engineTests {
+		imageBuilder, err := NewBuilder(engine)
+		if err != nil {
+			t.Errorf("engine %q: unexpected error: %s", engine, err)
+			continue
+		}
+		if reflect.TypeOf(imageBuilder) != reflect.TypeOf(want) {
+			t.Errorf("engine %q: want %T, got %T", engine, want, imageBuilder)
+		}
+	}
+
+	if _, err := NewBuilder("kaniko"); err == nil || !strings.Contains(err.Error(), "unknown build engine kaniko") {
+		t.Errorf("want unknown engine error, got %v", err)
+	}
+}
+
+func Test_CLIBuilders_Commands(t *testing.T) {
+	os.Unsetenv("http_proxy")
+	os.Unsetenv("https_proxy")
+
+	config := BuildConfig{
+		ContextPath: "./build/url-ping",
+		Image:       "alexellis/url-ping:0.1",
+		NoCache:     true,
+		Squash:      true,
+		BuildArgs:   map[string]string{"GO_VERSION": "1.9"},
+	}
+
+	commandTests := []struct {
+		engine string
+		got    []string
+		want   []string
+	}{
+		{
+			engine: "docker-cli",
+			got:    (&ExecBuilder{}).buildCommand(config),
+			want:   []string{"docker", "build", "--no-cache", "--squash", "--build-arg", "GO_VERSION=1.9", "-t", "alexellis/url-ping:0.1", "."},
+		},
+		{
+			engine: "podman",
+			got:    (&PodmanBuilder{}).buildCommand(config),
+			want:   []string{"podman", "build", "--no-cache", "--squash", "--build-arg", "GO_VERSION=1.9", "-t", "alexellis/url-ping:0.1", "."},
+		},
+		{
+			engine: "buildah",
+			got:    (&BuildahBuilder{}).buildCommand(config),
+			want:   []string{"buildah", "bud", "--no-cache", "--squash", "--build-arg", "GO_VERSION=1.9", "-t", "alexellis/url-ping:0.1", "."},
+		},
+		{
+			engine: "buildah push",
+			got:    (&BuildahBuilder{}).pushCommand("alexellis/url-ping:0.1"),
+			want:   []string{"buildah", "push", "alexellis/url-ping:0.1", "docker://alexellis/url-ping:0.1"},
+		},
+	}
+
+	for _, test := range commandTests {
+		if !reflect.DeepEqual(test.got, test.want) {
+			t.Errorf("%s: want %v, got %v", test.engine, test.want, test.got)
+		}
+	}
+}
+
+func Test_registryHost(t *testing.T) {
+	imageTests := map[string][2]string{
+		"alexellis/url-ping:0.1":              {"alexellis/url-ping", dockerHubRegistry},
+		"url-ping":                            {"url-ping", dockerHubRegistry},
+		"registry.local:5000/fn/url-ping:0.1": {"registry.local:5000/fn/url-ping", "registry.local:5000"},
+		"localhost/url-ping":                  {"localhost/url-ping", "localhost"},
+	}
+
+	for image, want := range imageTests {
+		name, _ := splitImageTag(image)
+		if name != want[0] {
+			t.Errorf("%s: want name %s, got %s", image, want[0], name)
+		}
+		if registry := registryHost(name); registry != want[1] {
+			t.Errorf("%s: want registry %s, got %s", image, want[1], registry)
+		}
+	}
+}
@@ -4,24 +4,84 @@
 package builder
 
 import (
+	"context"
 	"fmt"
-	"log"
+	"io"
 	"os"
 	"os/exec"
-
-	"github.com/morikuni/aec"
+	"strings"
 )
 
+// CommandError is returned when an external command fails to run or exits with an error
+type CommandError struct {
+	Command []string
+	Err     error
+}
+
+func (e *CommandError) Error() string {
+	return fmt.Sprintf("could not execute command: %s: %v", strings.Join(e.Command, " "), e.Err)
+}
+
+// ExecBuilder builds and pushes images by running the docker CLI
+type ExecBuilder struct {
+}
+
+// Build runs docker build in the context directory
+func (b *ExecBuilder) Build(ctx context.Context, config BuildConfig) error {
+	return execCommand(ctx, config.ContextPath, b.buildCommand(config), config.output())
+}
+
+// Push runs docker push
+func (b *ExecBuilder) Push(ctx context.Context, image string) error {
+	return execCommand(ctx, "./", []string{"docker", "push", image}, os.Stdout)
+}
+
+// ImageExists runs docker image inspect
+func (b *ExecBuilder) ImageExists(image string) (bool, error) {
+	return execSucceeds([]string{"docker", "image", "inspect", image})
+}
+
+func (b *ExecBuilder) buildCommand(config BuildConfig) []string {
+	flagSlice := buildFlagSlice(config.NoCache, config.Squash, os.Getenv("http_proxy"), os.Getenv("https_proxy"), config.BuildArgs)
+	cmd := append([]string{"docker", "build"}, flagSlice...)
+	return append(cmd, "-t", config.Image, ".")
+}
+
 // ExecCommand run a system command
-func ExecCommand(tempPath string, builder []string) {
+func ExecCommand(tempPath string, builder []string) error {
 	targetCmd := exec.Command(builder[0], builder[1:]...)
 	targetCmd.Dir = tempPath
 	targetCmd.Stdout = os.Stdout
 	targetCmd.Stderr = os.Stderr
-	targetCmd.Start()
-	err := targetCmd.Wait()
-	if err != nil {
-		errString := fmt.Sprintf("ERROR - Could not execute command: %s", builder)
-		log.Fatalf(aec.RedF.Apply(errString))
+	if err := targetCmd.Run(); err != nil {
+		return &CommandError{Command: builder, Err: err}
+	}
+	return nil
+}
+
+// execCommand runs a system command with its stdout and stderr sent to output,
+// the command is killed when ctx is cancelled
+func execCommand(ctx context.Context, tempPath string, builder []string, output io.Writer) error {
+	targetCmd := exec.CommandContext(ctx, builder[0], builder[1:]...)
+	targetCmd.Dir = tempPath
+	targetCmd.Stdout = output
+	targetCmd.Stderr = output
+	if err := targetCmd.Run(); err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		return &CommandError{Command: builder, Err: err}
+	}
+	return nil
+}
+
+// execSucceeds runs a command without output and reports whether it exited zero
+func execSucceeds(command []string) (bool, error) {
+	err := exec.Command(command[0], command[1:]...).Run()
+	if _, exited := err.(*exec.ExitError); exited {
+		return false, nil
+	} else if err != nil {
+		return false, &CommandError{Command: command, Err: err}
 	}
+	return true, nil
 }
@@ -0,0 +1,114 @@
+// Copyright (c) OpenFaaS Project 2018. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package builder
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/morikuni/aec"
+	"github.com/openfaas/faas-cli/api/template"
+)
+
+// BuildLogDir is where the output of each function's build is kept, relative to
+// the work directory. Like BuildStateFile it is hidden, so it can not be the
+// build folder of a function.
+const BuildLogDir = "build/.logs"
+
+// logTailLines is how much of a failing build's log is printed
+const logTailLines = 20
+
+// builderColours tell the output of parallel builders apart
+var builderColours = []aec.ANSI{aec.CyanF, aec.GreenF, aec.YellowF, aec.MagentaF, aec.BlueF}
+
+// prefixWriter writes each complete line it receives to out with a prefix,
+// so that the output of several builders can share the console. Writers
+// sharing out must share lock.
+type prefixWriter struct {
+	prefix string
+	out    io.Writer
+	lock   *sync.Mutex
+	buffer []byte
+}
+
+func (w *prefixWriter) Write(p []byte) (int, error) {
+	w.buffer = append(w.buffer, p...)
+	for {
+		end := bytes.IndexByte(w.buffer, '\n')
+		if end < 0 {
+			break
+		}
+		w.writeLine(w.buffer[:end+1])
+		w.buffer = w.buffer[end+1:]
+	}
+	return len(p), nil
+}
+
+// Flush writes out a final line which was not terminated by a newline
+func (w *prefixWriter) Flush() {
+	if len(w.buffer) > 0 {
+		w.writeLine(append(w.buffer, '\n'))
+		w.buffer = nil
+	}
+}
+
+func (w *prefixWriter) writeLine(line []byte) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	fmt.Fprintf(w.out, "%s %s", w.prefix, line)
+}
+
+// builderPrefix returns the console prefix for a function, coloured by the builder running it
+func builderPrefix(functionName string, width int, index int) string {
+	prefix := fmt.Sprintf("%-*s |", width, functionName)
+	if !isTerminal(os.Stdout) {
+		return prefix
+	}
+	return builderColours[index%len(builderColours)].Apply(prefix)
+}
+
+func isTerminal(file *os.File) bool {
+	info, err := file.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// buildLogPath returns the log file for a function's build
+func buildLogPath(functionName string) string {
+	return filepath.Join(template.GetWorkDirectory(), BuildLogDir, functionName+".log")
+}
+
+// createBuildLog truncates the log file of a function, ready for a new build
+func createBuildLog(functionName string) (*os.File, error) {
+	logPath := buildLogPath(functionName)
+	if err := os.MkdirAll(filepath.Dir(logPath), 0700); err != nil {
+		return nil, err
+	}
+	return os.Create(logPath)
+}
+
+// printLogTail prints the last lines of a function's build log
+func printLogTail(out io.Writer, functionName string) {
+	logPath := buildLogPath(functionName)
+	data, err := ioutil.ReadFile(logPath)
+	if err != nil || len(data) == 0 {
+		return
+	}
+
+	lines := strings.Split(strings.TrimRight(string(data), "\n"), "\n")
+	if len(lines) > logTailLines {
+		lines = lines[len(lines)-logTailLines:]
+	}
+
+	fmt.Fprintf(out, "Last %d lines of %s:\n", len(lines), logPath)
+	for _, line := range lines {
+		fmt.Fprintf(out, "    %s\n", line)
+	}
+}
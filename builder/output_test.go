@@ -0,0 +1,109 @@
+// Copyright (c) OpenFaaS Project 2018. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package builder
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/openfaas/faas-cli/api/template"
+	"github.com/openfaas/faas-cli/stack"
+	"github.com/openfaas/faas-cli/test"
+)
+
+func Test_prefixWriter(t *testing.T) {
+	var out bytes.Buffer
+	lock := &sync.Mutex{}
+	fn1 := &prefixWriter{prefix: "fn1 |", out: &out, lock: lock}
+	fn2 := &prefixWriter{prefix: "fn2 |", out: &out, lock: lock}
+
+	fmt.Fprint(fn1, "Step 1/2 : FROM ")
+	fmt.Fprint(fn2, "Step 1/3 : FROM node:8\n")
+	fmt.Fprint(fn1, "alpine:3.7\nStep 2/2")
+	fn1.Flush()
+
+	want := "fn2 | Step 1/3 : FROM node:8\nfn1 | Step 1/2 : FROM alpine:3.7\nfn1 | Step 2/2\n"
+	if out.String() != want {
+		t.Errorf("want:\n%s\ngot:\n%s", want, out.String())
+	}
+}
+
+// loggingBuilder writes a numbered build log and fails for one image
+type loggingBuilder struct {
+	fakeBuilder
+	failImage string
+}
+
+func (b *loggingBuilder) Build(ctx context.Context, config BuildConfig) error {
+	for i := 1; i <= 30; i++ {
+		fmt.Fprintf(config.Output, "%s line %d\n", config.Image, i)
+	}
+	if config.Image == b.failImage {
+		return fmt.Errorf("exit status 1")
+	}
+	return nil
+}
+
+func Test_BuildStack_Logs(t *testing.T) {
+	workDir, err := ioutil.TempDir("", "faas-cli-build-logs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(workDir)
+	template.SetWorkDirectory(workDir)
+	defer template.SetWorkDirectory("./")
+
+	services := &stack.Services{Functions: map[string]stack.Function{}}
+	for _, name := range []string{"fn1", "fn2"} {
+		handler := filepath.Join(workDir, name)
+		os.MkdirAll(handler, 0700)
+		ioutil.WriteFile(filepath.Join(handler, "Dockerfile"), []byte("FROM alpine:3.7\n"), 0600)
+		services.Functions[name] = stack.Function{Language: "Dockerfile", Handler: handler, Image: "functions/" + name}
+	}
+
+	imageBuilder := &loggingBuilder{failImage: "functions/fn2"}
+
+	var buildErr error
+	stdout := test.CaptureStdout(func() {
+		buildErr = BuildStack(context.Background(), imageBuilder, services, 2, false, false, false, nil, nil, true, false, false)
+	})
+
+	if buildErr == nil {
+		t.Fatalf("want an error for fn2")
+	}
+
+	if !strings.Contains(stdout, "fn1 | functions/fn1 line 1\n") {
+		t.Errorf("want build output prefixed with the function name, got:\n%s", stdout)
+	}
+
+	tail := "Last 20 lines of " + filepath.Join(workDir, BuildLogDir, "fn2.log") + ":\n    functions/fn2 line 11\n"
+	if !strings.Contains(stdout, tail) {
+		t.Errorf("want the tail of the failing log, got:\n%s", stdout)
+	}
+
+	logData, err := ioutil.ReadFile(filepath.Join(workDir, BuildLogDir, "fn1.log"))
+	if err != nil {
+		t.Fatalf("want a log for fn1: %s", err)
+	}
+	if !strings.HasSuffix(string(logData), "functions/fn1 line 30\nImage: functions/fn1 built.\n") {
+		t.Errorf("unexpected log for fn1:\n%s", logData)
+	}
+
+	quietOutput := test.CaptureStdout(func() {
+		BuildStack(context.Background(), imageBuilder, services, 2, false, false, false, nil, nil, true, false, true)
+	})
+	if strings.Contains(quietOutput, "functions/fn1 line 1\n") {
+		t.Errorf("want no build output in quiet mode, got:\n%s", quietOutput)
+	}
+	if !strings.Contains(quietOutput, "    functions/fn2 line 30\n") {
+		t.Errorf("want the tail of the failing log in quiet mode, got:\n%s", quietOutput)
+	}
+}
@@ -0,0 +1,35 @@
+// Copyright (c) OpenFaaS Project 2018. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package builder
+
+import (
+	"context"
+	"os"
+)
+
+// PodmanBuilder builds and pushes images with podman, which needs no daemon
+type PodmanBuilder struct {
+}
+
+// Build runs podman build in the context directory
+func (b *PodmanBuilder) Build(ctx context.Context, config BuildConfig) error {
+	return execCommand(ctx, config.ContextPath, b.buildCommand(config), config.output())
+}
+
+// Push runs podman push
+func (b *PodmanBuilder) Push(ctx context.Context, image string) error {
+	return execCommand(ctx, "./", []string{"podman", "push", image}, os.Stdout)
+}
+
+// ImageExists runs podman image exists
+func (b *PodmanBuilder) ImageExists(image string) (bool, error) {
+	return execSucceeds([]string{"podman", "image", "exists", image})
+}
+
+func (b *PodmanBuilder) buildCommand(config BuildConfig) []string {
+	// podman passes the proxy variables of the environment to the build by itself
+	flagSlice := buildFlagSlice(config.NoCache, config.Squash, "", "", config.BuildArgs)
+	cmd := append([]string{"podman", "build"}, flagSlice...)
+	return append(cmd, "-t", config.Image, ".")
+}
@@ -0,0 +1,166 @@
+// Copyright (c) OpenFaaS Project 2018. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package builder
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/openfaas/faas-cli/stack"
+)
+
+// StatusCancelled is the result of a function which was never started, or was
+// stopped while running, because the stack was cancelled
+const StatusCancelled = "cancelled"
+
+// StatusFailed is the result of a function whose task returned an error
+const StatusFailed = "failed"
+
+// FunctionError is the failure of a single function of a stack
+type FunctionError struct {
+	Function string
+	Err      error
+}
+
+func (e FunctionError) Error() string {
+	return fmt.Sprintf("%s: %v", e.Function, e.Err)
+}
+
+// StackErrors aggregates the failures of the functions of a stack
+type StackErrors []FunctionError
+
+func (e StackErrors) Error() string {
+	var messages []string
+	for _, functionErr := range e {
+		messages = append(messages, functionErr.Error())
+	}
+
+	functions := "functions"
+	if len(e) == 1 {
+		functions = "function"
+	}
+	return fmt.Sprintf("%d %s failed:\n%s", len(e), functions, strings.Join(messages, "\n"))
+}
+
+// StackResult is the outcome of the task run for one function of a stack
+type StackResult struct {
+	Function string
+	Image    string
+	Status   string
+	Duration time.Duration
+	Err      error
+}
+
+// StackTask works on one function, index identifies the worker. The returned
+// status, i.e. "built" or "skipped", is shown in the summary when there is no error.
+type StackTask func(ctx context.Context, index int, function stack.Function) (string, error)
+
+// RunStack runs task for every function of the stack with queueDepth
+// workers, in order of function name. Every function is attempted unless
+// failFast is set, in which case no more functions are started after the
+// first failure and the context of those still running is cancelled. The returned error is of type StackErrors and names every
+// failing function, or is the context's error if ctx was cancelled.
+func RunStack(ctx context.Context, services *stack.Services, queueDepth int, failFast bool, task StackTask) ([]StackResult, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var names []string
+	for name := range services.Functions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	results := make([]StackResult, len(names))
+	functions := make([]stack.Function, len(names))
+	for i, name := range names {
+		functions[i] = services.Functions[name]
+		functions[i].Name = name
+		results[i] = StackResult{Function: name, Image: functions[i].Image, Status: StatusCancelled}
+	}
+
+	if queueDepth < 1 {
+		queueDepth = 1
+	}
+
+	workChannel := make(chan int)
+	wg := sync.WaitGroup{}
+
+	for i := 0; i < queueDepth; i++ {
+		wg.Add(1)
+		go func(index int) {
+			defer wg.Done()
+
+			for n := range workChannel {
+				start := time.Now()
+				status, err := task(ctx, index, functions[n])
+				if err != nil && ctx.Err() != nil {
+					// stopped by the failure of another function, or by the caller
+					status, err = StatusCancelled, nil
+				} else if err != nil {
+					status = StatusFailed
+					if failFast {
+						cancel()
+					}
+				}
+
+				// each function has its own slot, so no lock is needed
+				results[n].Status = status
+				results[n].Duration = time.Since(start)
+				results[n].Err = err
+			}
+		}(i)
+	}
+
+dispatch:
+	for n := range functions {
+		if ctx.Err() != nil {
+			break
+		}
+		select {
+		case workChannel <- n:
+		case <-ctx.Done():
+			break dispatch
+		}
+	}
+	close(workChannel)
+
+	wg.Wait()
+
+	var stackErrors StackErrors
+	for _, result := range results {
+		if result.Err != nil {
+			stackErrors = append(stackErrors, FunctionError{Function: result.Function, Err: result.Err})
+		}
+	}
+
+	if len(stackErrors) > 0 {
+		return results, stackErrors
+	}
+	return results, ctx.Err()
+}
+
+// PrintStackSummary writes a table with the result of every function of a stack
+func PrintStackSummary(w io.Writer, results []StackResult) {
+	if len(results) == 0 {
+		return
+	}
+
+	table := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(table)
+	fmt.Fprintln(table, "FUNCTION\tIMAGE\tRESULT\tDURATION")
+	for _, result := range results {
+		duration := "-"
+		if result.Status != StatusCancelled {
+			duration = result.Duration.Round(time.Millisecond * 100).String()
+		}
+		fmt.Fprintf(table, "%s\t%s\t%s\t%s\n", result.Function, result.Image, result.Status, duration)
+	}
+	table.Flush()
+}
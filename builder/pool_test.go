@@ -0,0 +1,108 @@
+// Copyright (c) OpenFaaS Project 2018. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package builder
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/openfaas/faas-cli/stack"
+)
+
+func stackOf(names ...string) *stack.Services {
+	services := &stack.Services{Functions: map[string]stack.Function{}}
+	for _, name := range names {
+		services.Functions[name] = stack.Function{Image: "functions/" + name}
+	}
+	return services
+}
+
+func failing(names ...string) StackTask {
+	return func(ctx context.Context, index int, function stack.Function) (string, error) {
+		for _, name := range names {
+			if function.Name == name {
+				return "", fmt.Errorf("%s is broken", name)
+			}
+		}
+		return "built", nil
+	}
+}
+
+func Test_RunStack_ContinuesOnError(t *testing.T) {
+	results, err := RunStack(context.Background(), stackOf("fn1", "fn2", "fn3", "fn4"), 3, false, failing("fn2", "fn4"))
+
+	stackErrors, ok := err.(StackErrors)
+	if !ok {
+		t.Fatalf("want StackErrors, got %T: %v", err, err)
+	}
+	if len(stackErrors) != 2 || stackErrors[0].Function != "fn2" || stackErrors[1].Function != "fn4" {
+		t.Errorf("want fn2 and fn4 to fail, got %v", stackErrors)
+	}
+	if want := "2 functions failed:\nfn2: fn2 is broken\nfn4: fn4 is broken"; err.Error() != want {
+		t.Errorf("want error:\n%s\ngot:\n%s", want, err.Error())
+	}
+
+	var statuses []string
+	for _, result := range results {
+		statuses = append(statuses, result.Function+"="+result.Status)
+	}
+	if got := strings.Join(statuses, " "); got != "fn1=built fn2=failed fn3=built fn4=failed" {
+		t.Errorf("unexpected results: %s", got)
+	}
+}
+
+func Test_RunStack_FailFast(t *testing.T) {
+	var started int32
+	task := func(ctx context.Context, index int, function stack.Function) (string, error) {
+		atomic.AddInt32(&started, 1)
+		return failing("fn1")(ctx, index, function)
+	}
+
+	results, err := RunStack(context.Background(), stackOf("fn1", "fn2", "fn3", "fn4"), 1, true, task)
+
+	stackErrors, ok := err.(StackErrors)
+	if !ok || len(stackErrors) != 1 || stackErrors[0].Function != "fn1" {
+		t.Fatalf("want only fn1 to fail, got %v", err)
+	}
+	if started != 1 {
+		t.Errorf("want no functions started after the failure, %d were started", started)
+	}
+	for _, result := range results[1:] {
+		if result.Status != StatusCancelled {
+			t.Errorf("want %s cancelled, got %s", result.Function, result.Status)
+		}
+	}
+}
+
+func Test_RunStack_Cancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := RunStack(ctx, stackOf("fn1", "fn2"), 2, false, failing())
+	if err != context.Canceled {
+		t.Errorf("want context.Canceled, got %v", err)
+	}
+}
+
+func Test_PrintStackSummary(t *testing.T) {
+	results, _ := RunStack(context.Background(), stackOf("fn1", "long-function-name"), 1, false, failing("fn1"))
+	results[0].Duration = 0
+	results[1].Duration = 0
+
+	var summary bytes.Buffer
+	PrintStackSummary(&summary, results)
+
+	want := `
+FUNCTION            IMAGE                         RESULT  DURATION
+fn1                 functions/fn1                 failed  0s
+long-function-name  functions/long-function-name  built   0s
+`
+	if summary.String() != want {
+		t.Errorf("want summary:\n%s\ngot:\n%s", want, summary.String())
+	}
+}
@@ -0,0 +1,149 @@
+// Copyright (c) OpenFaaS Project 2018. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package builder
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/openfaas/faas-cli/api/template"
+	"github.com/openfaas/faas-cli/stack"
+)
+
+// BuildStateFile is where the hash of each function's last successful build is kept, relative to the work directory
+const BuildStateFile = "build/.build-state.json"
+
+// BuildState records a content hash for every function built successfully,
+// so that unchanged functions can be skipped on the next build
+type BuildState struct {
+	Hashes map[string]string `json:"hashes"`
+
+	path string
+	lock sync.Mutex
+}
+
+// LoadBuildState reads the build state from the work directory, a missing or unreadable file gives an empty state
+func LoadBuildState() *BuildState {
+	state := &BuildState{
+		Hashes: make(map[string]string),
+		path:   filepath.Join(template.GetWorkDirectory(), BuildStateFile),
+	}
+
+	data, err := ioutil.ReadFile(state.path)
+	if err != nil {
+		return state
+	}
+
+	if err := json.Unmarshal(data, state); err != nil || state.Hashes == nil {
+		fmt.Printf("Ignoring unreadable build state %s\n", state.path)
+		state.Hashes = make(map[string]string)
+	}
+	return state
+}
+
+// UpToDate tells whether a function was last built from the same inputs
+func (s *BuildState) UpToDate(functionName string, hash string) bool {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	return s.Hashes[functionName] == hash
+}
+
+// Record saves the hash of a successful build
+func (s *BuildState) Record(functionName string, hash string) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.Hashes[functionName] = hash
+
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(s.path), 0700); err != nil {
+		return err
+	}
+
+	// write then rename so an interrupted build never leaves a truncated state file
+	tempPath := s.path + ".tmp"
+	if err := ioutil.WriteFile(tempPath, data, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tempPath, s.path)
+}
+
+// functionHash returns a hash over everything which goes into a function's
+// image: the handler folder, the language template folder and the stack
+// fields used by the build.
+func functionHash(function stack.Function, buildArgMap map[string]string, buildOptions []string, squash bool) (string, error) {
+	hash := sha256.New()
+
+	var buildArgs []string
+	for k, v := range buildArgMap {
+		buildArgs = append(buildArgs, k+"="+v)
+	}
+	sort.Strings(buildArgs)
+
+	fields := []string{
+		"lang=" + function.Language,
+		"image=" + function.Image,
+		"build_args=" + strings.Join(buildArgs, "\x00"),
+		"build_options=" + strings.Join(buildOptions, "\x00"),
+		fmt.Sprintf("squash=%t", squash),
+	}
+	for _, field := range fields {
+		fmt.Fprintf(hash, "%s\n", field)
+	}
+
+	if err := hashFolder(hash, "handler", function.Handler); err != nil {
+		return "", err
+	}
+
+	if strings.ToLower(function.Language) != "dockerfile" {
+		templatePath := filepath.Join(template.GetTemplateDirectory(), function.Language)
+		if err := hashFolder(hash, "template", templatePath); err != nil {
+			return "", err
+		}
+	}
+
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// hashFolder writes the relative path, mode and content of every file under folder to hash
+func hashFolder(hash io.Writer, label string, folder string) error {
+	return filepath.Walk(folder, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		relativePath, err := filepath.Rel(folder, path)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(hash, "%s:%s:%s\n", label, filepath.ToSlash(relativePath), info.Mode())
+
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+
+		file, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+
+		_, err = io.Copy(hash, file)
+		return err
+	})
+}
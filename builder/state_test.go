@@ -0,0 +1,200 @@
+// Copyright (c) OpenFaaS Project 2018. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package builder
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"sync"
+	"testing"
+
+	"github.com/openfaas/faas-cli/api/template"
+	"github.com/openfaas/faas-cli/stack"
+	"github.com/openfaas/faas-cli/test"
+)
+
+// fakeBuilder records the images it is asked to build
+type fakeBuilder struct {
+	built  []string
+	images map[string]bool
+	lock   sync.Mutex
+}
+
+func (b *fakeBuilder) Build(ctx context.Context, config BuildConfig) error {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	b.built = append(b.built, config.Image)
+	b.images[config.Image] = true
+	return nil
+}
+
+func (b *fakeBuilder) Push(ctx context.Context, image string) error {
+	return nil
+}
+
+func (b *fakeBuilder) ImageExists(image string) (bool, error) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	return b.images[image], nil
+}
+
+func (b *fakeBuilder) reset() []string {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	built := b.built
+	sort.Strings(built)
+	b.built = nil
+	return built
+}
+
+func Test_BuildStack_SkipsUnchangedFunctions(t *testing.T) {
+	workDir, err := ioutil.TempDir("", "faas-cli-build-state")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(workDir)
+	template.SetWorkDirectory(workDir)
+	defer template.SetWorkDirectory("./")
+
+	services := &stack.Services{Functions: map[string]stack.Function{}}
+	for _, name := range []string{"fn1", "fn2"} {
+		handler := filepath.Join(workDir, name)
+		os.MkdirAll(handler, 0700)
+		ioutil.WriteFile(filepath.Join(handler, "Dockerfile"), []byte("FROM alpine:3.7\n"), 0600)
+		services.Functions[name] = stack.Function{Language: "Dockerfile", Handler: handler, Image: "functions/" + name}
+	}
+
+	imageBuilder := &fakeBuilder{images: map[string]bool{}}
+	build := func(force bool) []string {
+		if err := BuildStack(context.Background(), imageBuilder, services, 2, false, false, false, nil, nil, force, false, true); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		return imageBuilder.reset()
+	}
+
+	if built := build(false); !reflect.DeepEqual(built, []string{"functions/fn1", "functions/fn2"}) {
+		t.Errorf("want both functions built on the first run, got %v", built)
+	}
+
+	if built := build(false); len(built) > 0 {
+		t.Errorf("want no builds when nothing changed, got %v", built)
+	}
+
+	ioutil.WriteFile(filepath.Join(workDir, "fn2", "handler.sh"), []byte("echo changed\n"), 0600)
+	if built := build(false); !reflect.DeepEqual(built, []string{"functions/fn2"}) {
+		t.Errorf("want only the changed function built, got %v", built)
+	}
+
+	delete(imageBuilder.images, "functions/fn1")
+	if built := build(false); !reflect.DeepEqual(built, []string{"functions/fn1"}) {
+		t.Errorf("want a function rebuilt when its image is missing, got %v", built)
+	}
+
+	if built := build(true); !reflect.DeepEqual(built, []string{"functions/fn1", "functions/fn2"}) {
+		t.Errorf("want every function built with force, got %v", built)
+	}
+
+	if _, err := os.Stat(filepath.Join(workDir, BuildStateFile)); err != nil {
+		t.Errorf("want build state saved to %s: %s", BuildStateFile, err)
+	}
+}
+
+func Test_functionHash(t *testing.T) {
+	handler, err := ioutil.TempDir("", "faas-cli-hash")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(handler)
+	ioutil.WriteFile(filepath.Join(handler, "Dockerfile"), []byte("FROM alpine:3.7\n"), 0600)
+
+	function := stack.Function{Language: "Dockerfile", Handler: handler, Image: "functions/fn1"}
+	hash := func(function stack.Function, buildArgs map[string]string) string {
+		value, err := functionHash(function, buildArgs, nil, false)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		return value
+	}
+
+	original := hash(function, map[string]string{"A": "1", "B": "2"})
+	if again := hash(function, map[string]string{"B": "2", "A": "1"}); again != original {
+		t.Errorf("want the same hash for the same inputs")
+	}
+	if changed := hash(function, map[string]string{"A": "1", "B": "3"}); changed == original {
+		t.Errorf("want a new hash when a build arg changes")
+	}
+
+	function.Image = "functions/fn1:0.2"
+	if changed := hash(function, map[string]string{"A": "1", "B": "2"}); changed == original {
+		t.Errorf("want a new hash when the image changes")
+	}
+}
+
+// blockingBuilder fails the build of failImage once the other builds have
+// started, they run until their context is cancelled
+type blockingBuilder struct {
+	fakeBuilder
+	failImage string
+	started   chan string
+	cancelled chan string
+}
+
+func (b *blockingBuilder) Build(ctx context.Context, config BuildConfig) error {
+	if config.Image == b.failImage {
+		<-b.started
+		return fmt.Errorf("exit status 1")
+	}
+
+	b.started <- config.Image
+	<-ctx.Done()
+	b.cancelled <- config.Image
+	return ctx.Err()
+}
+
+func Test_BuildStack_FailFastCancelsRunningBuilds(t *testing.T) {
+	workDir, err := ioutil.TempDir("", "faas-cli-build-fail-fast")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(workDir)
+	template.SetWorkDirectory(workDir)
+	defer template.SetWorkDirectory("./")
+
+	services := &stack.Services{Functions: map[string]stack.Function{}}
+	for _, name := range []string{"fn1", "fn2"} {
+		handler := filepath.Join(workDir, name)
+		os.MkdirAll(handler, 0700)
+		ioutil.WriteFile(filepath.Join(handler, "Dockerfile"), []byte("FROM alpine:3.7\n"), 0600)
+		services.Functions[name] = stack.Function{Language: "Dockerfile", Handler: handler, Image: "functions/" + name}
+	}
+
+	imageBuilder := &blockingBuilder{failImage: "functions/fn1", started: make(chan string, 1), cancelled: make(chan string, 1)}
+
+	var buildErr error
+	test.CaptureStdout(func() {
+		buildErr = BuildStack(context.Background(), imageBuilder, services, 2, false, false, false, nil, nil, true, true, true)
+	})
+
+	stackErrors, ok := buildErr.(StackErrors)
+	if !ok || len(stackErrors) != 1 || stackErrors[0].Function != "fn1" {
+		t.Fatalf("want only fn1 to fail, got %v", buildErr)
+	}
+
+	select {
+	case image := <-imageBuilder.cancelled:
+		if image != "functions/fn2" {
+			t.Errorf("want the build of functions/fn2 cancelled, got %s", image)
+		}
+	default:
+		t.Errorf("want the running build of fn2 cancelled when fn1 fails")
+	}
+}
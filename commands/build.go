@@ -4,17 +4,33 @@
 package commands
 
 import (
+	"fmt"
+	"strings"
+
 	"github.com/openfaas/faas-cli/api"
+	"github.com/openfaas/faas-cli/builder"
 	"github.com/openfaas/faas-cli/options"
 	"github.com/spf13/cobra"
 )
 
+// engineUsage describes the --engine flag shared by build and push
+var engineUsage = fmt.Sprintf("Engine used to build and push images, one of: %s (defaults to provider.builder or %s)",
+	strings.Join(builder.Engines(), ", "), builder.DefaultEngine)
+
 // Flags that are to be added to commands.
 var (
 	nocache    bool
 	squash     bool
 	parallel   int
 	shrinkwrap bool
+	engine     string
+	force      bool
+	failFast   bool
+	quiet      bool
+	frozen     bool
+
+	buildArgs    []string
+	buildOptions []string
 )
 
 func init() {
@@ -32,6 +48,16 @@ func init() {
 
 	buildCmd.Flags().BoolVar(&shrinkwrap, "shrinkwrap", false, "Just write files to ./build/ folder for shrink-wrapping")
 
+	buildCmd.Flags().StringVar(&engine, "engine", "", engineUsage)
+	buildCmd.Flags().BoolVar(&force, "force", false, "Build every function even if it has not changed since the last build")
+	buildCmd.Flags().BoolVar(&failFast, "fail-fast", false, "Stop building further functions after the first failure")
+	buildCmd.Flags().BoolVarP(&quiet, "quiet", "q", false, "Only show a progress line per function, the build output is saved under build/.logs")
+	buildCmd.Flags().BoolVar(&offline, "offline", false, offlineUsage)
+	buildCmd.Flags().BoolVar(&frozen, "frozen", false, "Fail when templates differ from template.lock instead of warning, and do not pull missing templates")
+
+	buildCmd.Flags().StringArrayVarP(&buildArgs, "build-arg", "b", []string{}, "Add a build-arg for Docker (KEY=VALUE)")
+	buildCmd.Flags().StringArrayVar(&buildOptions, "build-option", []string{}, "Set a build option declared by the template, e.g. dev")
+
 	// Set bash-completion.
 	_ = buildCmd.Flags().SetAnnotation("handler", cobra.BashCompSubdirsInDir, []string{})
 
@@ -40,7 +66,7 @@ func init() {
 
 // buildCmd allows the user to build an OpenFaaS function container
 var buildCmd = &cobra.Command{
-	Use: `build -f YAML_FILE [--no-cache] [--squash]
+	Use: `build -f YAML_FILE [--no-cache] [--squash] [--force] [--fail-fast] [--quiet] [--frozen]
   faas-cli build --image IMAGE_NAME
                  --handler HANDLER_DIR
                  --name FUNCTION_NAME
@@ -48,28 +74,72 @@ var buildCmd = &cobra.Command{
                  [--no-cache] [--squash]
                  [--regex "REGEX"]
 				 [--filter "WILDCARD"]
-				 [--parallel PARALLEL_DEPTH]`,
+				 [--parallel PARALLEL_DEPTH]
+				 [--build-arg KEY=VALUE]
+				 [--build-option OPTION]
+				 [--engine ENGINE]`,
 	Short: "Builds OpenFaaS function containers",
 	Long: `Builds OpenFaaS function containers either via the supplied YAML config using
 the "--yaml" flag (which may contain multiple function definitions), or directly
-via flags.`,
+via flags.
+
+Images are built through the Docker Engine API by default, use "--engine" or
+"builder" in the provider section of the YAML file to select docker-cli, podman
+or buildah instead.
+
+Functions whose handler, template and build settings have not changed since the
+last successful build are skipped while their image is still present locally,
+pass "--force" to build them anyway.
+
+All functions are built even if some fail, and a summary of the results is
+printed at the end. Use "--fail-fast" to stop after the first failure.
+
+The output of each function's build is saved to build/.logs/<function>.log and
+shown prefixed by the function name, or hidden with "--quiet". The end of the
+log is printed for any function which fails.
+
+Missing templates are pulled from the template cache, or the network when they
+are not cached yet, use "--offline" to never touch the network.
+
+Templates are checked against template.lock before building and a warning is
+printed for any which were modified or removed since they were pulled. Use
+"--frozen" to fail the build instead.`,
 	Example: `  faas-cli build -f https://domain/path/myfunctions.yml
   faas-cli build -f ./samples.yml --no-cache
+  faas-cli build -f ./samples.yml --force
+  faas-cli build -f ./samples.yml --parallel 4 --quiet
+  faas-cli build -f ./samples.yml --frozen
   faas-cli build -f ./samples.yml --filter "*gif*"
   faas-cli build -f ./samples.yml --regex "fn[0-9]_.*"
   faas-cli build --image=my_image --lang=python --handler=/path/to/fn/
-                 --name=my_fn --squash`,
+                 --name=my_fn --squash
+  faas-cli build -f ./samples.yml --build-arg GO_VERSION=1.9
+  faas-cli build -f ./samples.yml --build-option dev
+  faas-cli build -f ./samples.yml --engine podman`,
 	RunE: runBuild,
 }
 
 func runBuild(cmd *cobra.Command, args []string) error {
+	ctx, cancel := interruptContext()
+	defer cancel()
+
 	bargs := options.BuildOptions{
+		Context: ctx,
 		FaasOptions: getFaasOptions(),
 		SharedOptions: getSharedOptions(),
 		Nocache: nocache,
 		Squash: squash,
 		Parallel: parallel,
 		Shrinkwrap: shrinkwrap,
+		Engine: engine,
+		Force: force,
+		FailFast: failFast,
+		Quiet: quiet,
+		Frozen: frozen,
+		Offline: offline,
+
+		BuildArgs:    buildArgs,
+		BuildOptions: buildOptions,
 	}
 	return api.Build(bargs)
 }
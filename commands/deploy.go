@@ -17,6 +17,11 @@ var (
 	constraints []string
 	secrets     []string
 	labelOpts   []string
+
+	limitMemory   string
+	limitCPU      string
+	requestMemory string
+	requestCPU    string
 )
 
 func init() {
@@ -40,6 +45,13 @@ func init() {
 	deployCmd.Flags().StringArrayVar(&constraints, "constraint", []string{}, "Apply a constraint to the function")
 	deployCmd.Flags().StringArrayVar(&secrets, "secret", []string{}, "Give the function access to a secure secret")
 
+	deployCmd.Flags().StringVar(&limitMemory, "limit-memory", "", "Memory limit for the function, e.g. 128m or 1Gi")
+	deployCmd.Flags().StringVar(&limitCPU, "limit-cpu", "", "CPU limit for the function, e.g. 100m or 0.5")
+	deployCmd.Flags().StringVar(&requestMemory, "request-memory", "", "Memory requested by the function, e.g. 64m")
+	deployCmd.Flags().StringVar(&requestCPU, "request-cpu", "", "CPU requested by the function, e.g. 50m")
+
+	addRetryFlags(deployCmd.Flags())
+
 	// Set bash-completion.
 	_ = deployCmd.Flags().SetAnnotation("handler", cobra.BashCompSubdirsInDir, []string{})
 
@@ -63,7 +75,10 @@ var deployCmd = &cobra.Command{
                   [--constraint PLACEMENT_CONSTRAINT ...]
                   [--regex "REGEX"]
                   [--filter "WILDCARD"]
-				  [--secret "SECRET_NAME"]`,
+				  [--secret "SECRET_NAME"]
+                  [--limit-memory MEMORY] [--limit-cpu CPU]
+                  [--request-memory MEMORY] [--request-cpu CPU]
+                  [--retries COUNT] [--retry-timeout DURATION]`,
 
 	Short: "Deploy OpenFaaS functions",
 	Long: `Deploys OpenFaaS function containers either via the supplied YAML config using
@@ -79,11 +94,18 @@ via flags. Note: --replace and --update are mutually exclusive.`,
   faas-cli deploy --image=alexellis/faas-url-ping --name=url-ping
   faas-cli deploy --image=my_image --name=my_fn --handler=/path/to/fn/
                   --gateway=http://remote-site.com:8080 --lang=python
-                  --env=MYVAR=myval`,
+                  --env=MYVAR=myval
+  faas-cli deploy --image=my_image --name=my_fn
+                  --limit-memory=128m --limit-cpu=500m --request-cpu=100m
+  faas-cli deploy -f ./samples.yml --retries=5 --retry-timeout=2m`,
 	RunE: runDeploy,
 }
 
 func runDeploy(cmd *cobra.Command, args []string) error {
+	if err := setRetryPolicy(); err != nil {
+		return err
+	}
+
 	dargs := options.DeployOptions{
 		FaasOptions: getFaasOptions(),
 		SharedOptions: getSharedOptions(),
@@ -93,6 +115,11 @@ func runDeploy(cmd *cobra.Command, args []string) error {
 		Constraints: constraints,
 		Secrets:     secrets,
 		LabelOpts:   labelOpts,
+
+		LimitMemory:   limitMemory,
+		LimitCPU:      limitCPU,
+		RequestMemory: requestMemory,
+		RequestCPU:    requestCPU,
 	}
 	return api.Deploy(dargs)
 }
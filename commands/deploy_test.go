@@ -4,12 +4,19 @@
 package commands
 
 import (
+	"encoding/json"
+	"io/ioutil"
 	"net/http"
+	"net/http/httptest"
+	"os"
 	"regexp"
+	"strings"
 	"testing"
 
-	"github.com/openfaas/faas-cli/test"
 	"github.com/openfaas/faas-cli/api"
+	"github.com/openfaas/faas-cli/proxy"
+	"github.com/openfaas/faas-cli/test"
+	"github.com/openfaas/faas/gateway/requests"
 )
 
 func Test_getGatewayURL(t *testing.T) {
@@ -104,3 +111,132 @@ func Test_deploy(t *testing.T) {
 		t.Fatalf("Output is not as expected:\n%s", stdOut)
 	}
 }
+
+func Test_deploy_invalidResources(t *testing.T) {
+	faasCmd.SetArgs([]string{
+		"deploy",
+		"--gateway=http://127.0.0.1:8080",
+		"--image=golang",
+		"--name=test-function",
+		"--limit-cpu=lots",
+	})
+	err := faasCmd.Execute()
+	limitCPU = ""
+
+	if err == nil || !strings.Contains(err.Error(), `cpu quantity "lots" is not valid`) {
+		t.Fatalf("Expected invalid cpu quantity error, got: %v", err)
+	}
+}
+
+func Test_deploy_secretsPerFunction(t *testing.T) {
+	s := test.MockHttpServer(t, []test.Request{
+		{
+			Method:             http.MethodGet,
+			Uri:                "/system/secrets",
+			ResponseStatusCode: http.StatusOK,
+			ResponseBody:       []proxy.Secret{{Name: "db-password"}},
+		},
+		{
+			Method:             http.MethodPost,
+			Uri:                "/system/functions",
+			ResponseStatusCode: http.StatusOK,
+		},
+	})
+	defer s.Close()
+
+	stackFile, err := ioutil.TempFile("", "faas-cli-deploy-secrets")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(stackFile.Name())
+
+	stackFile.WriteString(`provider:
+  name: faas
+  gateway: ` + s.URL + `
+functions:
+  with-secrets:
+    image: golang
+    secrets:
+      - db-password
+      - api-key
+`)
+	stackFile.Close()
+
+	yamlFiles = []string{stackFile.Name()}
+	defer resetForTest()
+
+	stdOut := test.CaptureStdout(func() {
+		faasCmd.SetArgs([]string{
+			"deploy",
+			"--gateway=" + s.URL,
+			"--replace=false",
+		})
+		if err := faasCmd.Execute(); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	if found, err := regexp.MatchString(`(?m:WARNING! Secret api-key used by function with-secrets was not found)`, stdOut); err != nil || !found {
+		t.Fatalf("Output is not as expected:\n%s", stdOut)
+	}
+
+	if found, err := regexp.MatchString(`(?m:Secret db-password used)`, stdOut); err != nil || found {
+		t.Fatalf("Output is not as expected:\n%s", stdOut)
+	}
+}
+
+func Test_deploy_failedFunctionReturnsError(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var deployed requests.CreateFunctionRequest
+		if err := json.NewDecoder(r.Body).Decode(&deployed); err != nil {
+			t.Fatal(err)
+		}
+		if deployed.Service == "broken" {
+			w.WriteHeader(http.StatusInternalServerError)
+			w.Write([]byte("image not found"))
+			return
+		}
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer s.Close()
+
+	stackFile, err := ioutil.TempFile("", "faas-cli-deploy-failed")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(stackFile.Name())
+
+	stackFile.WriteString(`provider:
+  name: faas
+  gateway: ` + s.URL + `
+functions:
+  working:
+    image: golang
+  broken:
+    image: missing
+`)
+	stackFile.Close()
+
+	yamlFiles = []string{stackFile.Name()}
+	defer resetForTest()
+
+	var executeErr error
+	stdOut := test.CaptureStdout(func() {
+		faasCmd.SetArgs([]string{
+			"deploy",
+			"--gateway=" + s.URL,
+			"--replace=false",
+		})
+		executeErr = faasCmd.Execute()
+	})
+
+	if executeErr == nil || executeErr.Error() != "failed to deploy 1 of 2 functions: broken" {
+		t.Fatalf("want the failed deploy returned as an error, got: %v", executeErr)
+	}
+	if !strings.Contains(stdOut, "Unexpected status: 500, message: image not found") {
+		t.Errorf("want the failure printed, got:\n%s", stdOut)
+	}
+	if !strings.Contains(stdOut, "Deployed. 202 Accepted.\nURL: "+s.URL+"/function/working") {
+		t.Errorf("want the working function deployed, got:\n%s", stdOut)
+	}
+}
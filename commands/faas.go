@@ -4,23 +4,35 @@
 package commands
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"os"
+	"os/signal"
 	"strings"
+	"time"
 
 	"github.com/openfaas/faas-cli/api"
 	"github.com/openfaas/faas-cli/api/template"
+	"github.com/openfaas/faas-cli/config"
 	"github.com/openfaas/faas-cli/options"
+	"github.com/openfaas/faas-cli/proxy"
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 )
 
 // Flags that are to be added to all commands.
 var (
-	yamlFile string
-	regex    string
-	filter   string
-	workDir  string
+	yamlFiles []string
+	regex     string
+	filter    string
+	workDir   string
+	strict    bool
+
+	tlsNoVerify bool
+	caCert      string
+	clientCert  string
+	clientKey   string
 )
 
 // Flags that are to be added to subset of commands.
@@ -32,6 +44,8 @@ var (
 	handler      string
 	image        string
 	language     string
+	retries      int
+	retryTimeout time.Duration
 )
 
 var stat = func(filename string) (os.FileInfo, error) {
@@ -40,17 +54,37 @@ var stat = func(filename string) (os.FileInfo, error) {
 
 // TODO: remove this workaround once these vars are no longer global
 func resetForTest() {
-	yamlFile = ""
+	yamlFiles = nil
 	regex = ""
 	filter = ""
+	tlsNoVerify = false
+	caCert = ""
+	clientCert = ""
+	clientKey = ""
+	config.DefaultTLSConfig = config.TLSConfig{}
+	retries = 0
+	proxy.DefaultRetryPolicy = proxy.RetryPolicy{}
+	username, password, passwordStdin = "", "", false
+	token, tokenStdin = "", false
+	clientID, clientSecret, clientSecretStdin, tokenURL, scopes = "", "", false, "", nil
 }
 
 func getFaasOptions() options.FaasOptions {
 	return options.FaasOptions{
-		YamlFile: yamlFile,
-		Regex:    regex,
-		Filter:   filter,
-		WorkDir:  workDir,
+		YamlFiles: yamlFiles,
+		Regex:     regex,
+		Filter:    filter,
+		WorkDir:   workDir,
+		Strict:    strict,
+	}
+}
+
+func getTLSConfig() config.TLSConfig {
+	return config.TLSConfig{
+		InsecureSkipVerify: tlsNoVerify,
+		CACert:             caCert,
+		ClientCert:         clientCert,
+		ClientKey:          clientKey,
 	}
 }
 
@@ -65,11 +99,50 @@ func getSharedOptions() options.SharedOptions {
 	}
 }
 
+// addRetryFlags adds the flags which set how calls to the gateway are retried
+func addRetryFlags(flags *pflag.FlagSet) {
+	flags.IntVar(&retries, "retries", 0, "Retry calls to the gateway this many times when it is unavailable")
+	flags.DurationVar(&retryTimeout, "retry-timeout", 60*time.Second, "Stop retrying calls to the gateway after this long")
+}
+
+// setRetryPolicy retries calls to the gateway as set by --retries and --retry-timeout
+func setRetryPolicy() error {
+	if retries < 0 {
+		return fmt.Errorf("--retries must not be negative")
+	}
+	proxy.DefaultRetryPolicy = proxy.RetryPolicy{Retries: retries, Timeout: retryTimeout}
+	return nil
+}
+
+// interruptContext returns a context which is cancelled by Ctrl+C, so running
+// builds and pushes are stopped, a second Ctrl+C exits straight away
+func interruptContext() (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt)
+	go func() {
+		select {
+		case <-signals:
+			cancel()
+		case <-ctx.Done():
+		}
+		signal.Stop(signals)
+	}()
+
+	return ctx, cancel
+}
+
 func init() {
-	faasCmd.PersistentFlags().StringVarP(&yamlFile, "yaml", "f", "", "Path to YAML file describing function(s)")
+	faasCmd.PersistentFlags().StringArrayVarP(&yamlFiles, "yaml", "f", []string{}, "Path to YAML file describing function(s), repeat to merge several files")
 	faasCmd.PersistentFlags().StringVarP(&regex, "regex", "", "", "Regex to match with function names in YAML file")
 	faasCmd.PersistentFlags().StringVarP(&filter, "filter", "", "", "Wildcard to match with function names in YAML file")
 	faasCmd.PersistentFlags().StringVarP(&workDir, "workdir", "", "./", "Base directory where to store templates and build output")
+	faasCmd.PersistentFlags().BoolVar(&strict, "strict", false, "Fail on unknown keys or invalid values in the YAML file")
+	faasCmd.PersistentFlags().BoolVar(&tlsNoVerify, "tls-no-verify", false, "Skip verification of TLS certificates, this is insecure")
+	faasCmd.PersistentFlags().StringVar(&caCert, "ca-cert", "", "PEM file of the certificate authority to verify TLS certificates with")
+	faasCmd.PersistentFlags().StringVar(&clientCert, "client-cert", "", "PEM file of a client certificate for TLS, used with --client-key")
+	faasCmd.PersistentFlags().StringVar(&clientKey, "client-key", "", "PEM file of the key of the client certificate")
 
 	// Set Bash completion options
 	validYAMLFilenames := []string{"yaml", "yml"}
@@ -93,7 +166,7 @@ func Execute(customArgs []string) {
 func checkAndSetDefaultYaml() {
 	// Check if there is a default yaml file and set it
 	if _, err := stat(api.DefaultYAML); err == nil {
-		yamlFile = api.DefaultYAML
+		yamlFiles = []string{api.DefaultYAML}
 	}
 }
 
@@ -104,13 +177,19 @@ var faasCmd = &cobra.Command{
 	Short: "Manage your OpenFaaS functions from the command line",
 	Long: `
 Manage your OpenFaaS functions from the command line`,
-	PersistentPreRun: func(cmd *cobra.Command, args []string) {
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
 
 		err := template.SetWorkDirectory(workDir)
 		if err != nil {
 			log.Fatalf("Failed to set working directory: %s", err.Error())
 		}
 
+		// check the certificates can be loaded before any work is done
+		config.DefaultTLSConfig = getTLSConfig()
+		if _, err := config.DefaultTLSConfig.ClientConfig(); err != nil {
+			return err
+		}
+		return nil
 	},
 	Run: runFaas,
 }
@@ -5,12 +5,21 @@ import (
 	"os"
 	"testing"
 	"github.com/openfaas/faas-cli/api"
+	"github.com/openfaas/faas-cli/config"
 )
 
 var mockStatParams string
 
+// TestMain keeps the config and template cache of the tests out of the home directory
+func TestMain(m *testing.M) {
+	config.DefaultDir, _ = ioutil.TempDir("", "faas-cli-commands-test")
+	code := m.Run()
+	os.RemoveAll(config.DefaultDir)
+	os.Exit(code)
+}
+
 func setupFaas(statError error) {
-	yamlFile = ""
+	yamlFiles = nil
 	mockStatParams = ""
 	faasCmd.SetOutput(ioutil.Discard)
 
@@ -26,7 +35,7 @@ func TestCallsStatWithDefaulYAMLFileName(t *testing.T) {
 	Execute([]string{"help"})
 
 	if mockStatParams != api.DefaultYAML {
-		t.Fatalf("Expected yamlFile to equal %v got %v\n", api.DefaultYAML, yamlFile)
+		t.Fatalf("Expected yamlFiles to equal %v got %v\n", api.DefaultYAML, yamlFiles)
 	}
 }
 
@@ -35,8 +44,8 @@ func TestLoadsDefaultYAMLWhenPresent(t *testing.T) {
 
 	Execute([]string{"help"})
 
-	if yamlFile != api.DefaultYAML {
-		t.Fatalf("Expected yamlFile to equal %v got %v\n", api.DefaultYAML, yamlFile)
+	if len(yamlFiles) != 1 || yamlFiles[0] != api.DefaultYAML {
+		t.Fatalf("Expected yamlFiles to equal %v got %v\n", api.DefaultYAML, yamlFiles)
 	}
 }
 
@@ -45,8 +54,8 @@ func TestLoadsFromParmetersYAMLWhenPresentAndDefaultYAMLFileAlsoPresent(t *testi
 
 	Execute([]string{"help", "--yaml=myfile.yml"})
 
-	if yamlFile != "myfile.yml" {
-		t.Fatalf("Expected yamlFile to equal %v got %v\n", "myfile.yml", yamlFile)
+	if len(yamlFiles) != 1 || yamlFiles[0] != "myfile.yml" {
+		t.Fatalf("Expected yamlFiles to equal %v got %v\n", "myfile.yml", yamlFiles)
 	}
 }
 
@@ -55,7 +64,7 @@ func TestDoesNotLoadDefaultYAMLWhenMissing(t *testing.T) {
 
 	Execute([]string{"help"})
 
-	if yamlFile != "" {
-		t.Fatalf("Expected yamlFile to be blank got %v\n", yamlFile)
+	if len(yamlFiles) != 0 {
+		t.Fatalf("Expected yamlFiles to be blank got %v\n", yamlFiles)
 	}
 }
@@ -20,6 +20,7 @@ func init() {
 	listCmd.Flags().StringVarP(&gateway, "gateway", "g", api.DefaultGateway, "Gateway URL starting with http(s)://")
 
 	listCmd.Flags().BoolVar(&verboseList, "verbose", false, "Verbose output for the function list")
+	addRetryFlags(listCmd.Flags())
 
 	faasCmd.AddCommand(listCmd)
 }
@@ -35,6 +36,9 @@ var listCmd = &cobra.Command{
 }
 
 func runList(cmd *cobra.Command, args []string) error {
+	if err := setRetryPolicy(); err != nil {
+		return err
+	}
 
 	functions, err := api.List(options.ListOptions{
 		FaasOptions: getFaasOptions(),
@@ -78,3 +78,55 @@ func Test_list_errors(t *testing.T) {
 		t.Fatal("No error found while testing missing yaml")
 	}
 }
+
+func Test_list_retries(t *testing.T) {
+	s := test.MockHttpServer(t, []test.Request{
+		{
+			Method:             http.MethodGet,
+			Uri:                "/system/functions",
+			ResponseStatusCode: http.StatusBadGateway,
+		},
+		{
+			Method:             http.MethodGet,
+			Uri:                "/system/functions",
+			ResponseStatusCode: http.StatusOK,
+			ResponseBody:       []requests.Function{{Name: "function-test-1"}},
+		},
+	})
+	defer s.Close()
+
+	resetForTest()
+	defer resetForTest()
+
+	var err error
+	stdOut := test.CaptureStdout(func() {
+		faasCmd.SetArgs([]string{
+			"list",
+			"--gateway=" + s.URL,
+			"--retries=2",
+			"--retry-timeout=10s",
+		})
+		err = faasCmd.Execute()
+	})
+
+	if err != nil {
+		t.Fatalf("want the list to be retried, got %s", err)
+	}
+	if !regexp.MustCompile(`function-test-1`).MatchString(stdOut) {
+		t.Fatalf("Output is not as expected:\n%s", stdOut)
+	}
+}
+
+func Test_list_negativeRetries(t *testing.T) {
+	resetForTest()
+	defer resetForTest()
+
+	faasCmd.SetArgs([]string{
+		"list", "--retries=-1",
+	})
+	err := faasCmd.Execute()
+
+	if err == nil || err.Error() != "--retries must not be negative" {
+		t.Fatalf("want an error for negative retries, got %v", err)
+	}
+}
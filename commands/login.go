@@ -18,6 +18,15 @@ var (
 	username      string
 	password      string
 	passwordStdin bool
+
+	token      string
+	tokenStdin bool
+
+	clientID          string
+	clientSecret      string
+	clientSecretStdin bool
+	tokenURL          string
+	scopes            []string
 )
 
 func init() {
@@ -26,22 +35,51 @@ func init() {
 	loginCmd.Flags().StringVarP(&password, "password", "p", "", "Gateway password")
 	loginCmd.Flags().BoolVar(&passwordStdin, "password-stdin", false, "Reads the gateway password from stdin")
 
+	loginCmd.Flags().StringVar(&token, "token", "", "Bearer token sent to the gateway")
+	loginCmd.Flags().BoolVar(&tokenStdin, "token-stdin", false, "Reads the bearer token from stdin")
+
+	loginCmd.Flags().StringVar(&clientID, "client-id", "", "OAuth2 client ID to fetch tokens with the client credentials grant")
+	loginCmd.Flags().StringVar(&clientSecret, "client-secret", "", "OAuth2 client secret")
+	loginCmd.Flags().BoolVar(&clientSecretStdin, "client-secret-stdin", false, "Reads the OAuth2 client secret from stdin")
+	loginCmd.Flags().StringVar(&tokenURL, "token-url", "", "URL of the OAuth2 token endpoint")
+	loginCmd.Flags().StringArrayVar(&scopes, "scope", []string{}, "OAuth2 scope to request, repeat for several scopes")
+
 	faasCmd.AddCommand(loginCmd)
 }
 
 var loginCmd = &cobra.Command{
-	Use:   `login [--username USERNAME] [--password PASSWORD] [--gateway GATEWAY_URL]`,
+	Use: `login [--username USERNAME] [--password PASSWORD] [--gateway GATEWAY_URL]
+  faas-cli login --token TOKEN [--gateway GATEWAY_URL]
+  faas-cli login --client-id CLIENT_ID --client-secret CLIENT_SECRET --token-url TOKEN_URL [--scope SCOPE ...] [--gateway GATEWAY_URL]`,
 	Short: "Log in to OpenFaaS gateway",
 	Long:  "Log in to OpenFaaS gateway.\nIf no gateway is specified, the default local one will be used.",
 	Example: `  faas-cli login -u user -p password --gateway http://localhost:8080
-  cat ~/faas_pass.txt | faas-cli login -u user --password-stdin --gateway https://openfaas.mydomain.com`,
+  cat ~/faas_pass.txt | faas-cli login -u user --password-stdin --gateway https://openfaas.mydomain.com
+  cat ~/faas_pass.txt | faas-cli login -u user --password-stdin --gateway https://openfaas.mydomain.com --ca-cert ./ca.crt
+  cat ~/faas_token.txt | faas-cli login --token-stdin --gateway https://openfaas.mydomain.com
+  cat ~/faas_client_secret.txt | faas-cli login --client-id faas-cli --client-secret-stdin \
+    --token-url https://idp.mydomain.com/oauth2/token --gateway https://openfaas.mydomain.com`,
 	RunE: runLogin,
 }
 
 func runLogin(cmd *cobra.Command, args []string) error {
+	tokenLogin := len(token) > 0 || tokenStdin
+	clientCredentialsLogin := len(clientID) > 0
+
+	if (len(username) > 0 && (tokenLogin || clientCredentialsLogin)) || (tokenLogin && clientCredentialsLogin) {
+		return fmt.Errorf("--username, --token and --client-id are mutually exclusive")
+	}
+
+	if tokenLogin {
+		return runTokenLogin()
+	}
+
+	if clientCredentialsLogin {
+		return runClientCredentialsLogin()
+	}
 
 	if len(username) == 0 {
-		return fmt.Errorf("must provide --username or -u")
+		return fmt.Errorf("must provide --username or -u, --token or --client-id")
 	}
 
 	if len(password) > 0 {
@@ -81,3 +119,63 @@ func runLogin(cmd *cobra.Command, args []string) error {
 		Password: password,
 	})
 }
+
+func runTokenLogin() error {
+	bearerToken, err := readSecret("token", token, tokenStdin)
+	if err != nil {
+		return err
+	}
+
+	fmt.Println("Calling the OpenFaaS server to validate the token...")
+
+	return api.Login(options.LoginOptions{
+		SharedOptions: getSharedOptions(),
+		Token:         bearerToken,
+	})
+}
+
+func runClientCredentialsLogin() error {
+	if len(tokenURL) == 0 {
+		return fmt.Errorf("must provide --token-url with --client-id")
+	}
+
+	secret, err := readSecret("client-secret", clientSecret, clientSecretStdin)
+	if err != nil {
+		return err
+	}
+
+	fmt.Println("Fetching a token to validate the client credentials...")
+
+	return api.Login(options.LoginOptions{
+		SharedOptions: getSharedOptions(),
+		ClientID:      clientID,
+		ClientSecret:  secret,
+		TokenURL:      tokenURL,
+		Scopes:        scopes,
+	})
+}
+
+// readSecret returns the value of the flag named name, or reads it from stdin
+// when the flag of the same name suffixed with -stdin is set
+func readSecret(name string, value string, fromStdin bool) (string, error) {
+	if len(value) > 0 {
+		fmt.Printf("WARNING! Using --%s is insecure, consider using --%s-stdin\n", name, name)
+		if fromStdin {
+			return "", fmt.Errorf("--%s and --%s-stdin are mutually exclusive", name, name)
+		}
+	}
+
+	if fromStdin {
+		valueStdin, err := ioutil.ReadAll(os.Stdin)
+		if err != nil {
+			return "", err
+		}
+		value = string(valueStdin)
+	}
+
+	value = strings.TrimSpace(value)
+	if len(value) == 0 {
+		return "", fmt.Errorf("must provide a non-empty %s via --%s or --%s-stdin", name, name, name)
+	}
+	return value, nil
+}
@@ -0,0 +1,174 @@
+// Copyright (c) OpenFaaS Project 2018. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package commands
+
+import (
+	"crypto/tls"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/openfaas/faas-cli/test"
+)
+
+func Test_login_tls(t *testing.T) {
+	dir, err := ioutil.TempDir("", "faas-cli-tls-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	certFile, keyFile := test.WriteCertificate(t, dir, "gateway")
+	keyPair, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if username, password, _ := r.BasicAuth(); username != "admin" || password != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte(`[{"name": "function-test-1"}]`))
+	}))
+	s.TLS = &tls.Config{Certificates: []tls.Certificate{keyPair}}
+	s.StartTLS()
+	defer s.Close()
+
+	resetForTest()
+	defer resetForTest()
+
+	login := func(args ...string) error {
+		var err error
+		test.CaptureStdout(func() {
+			faasCmd.SetArgs(append([]string{"login", "--gateway=" + s.URL, "--username=admin", "--password=secret"}, args...))
+			err = faasCmd.Execute()
+		})
+		resetForTest()
+		return err
+	}
+
+	if err := login(); err == nil || !strings.Contains(err.Error(), "cannot connect to OpenFaaS") {
+		t.Fatalf("want the self-signed certificate rejected, got %v", err)
+	}
+
+	if err := login("--ca-cert=" + certFile); err != nil {
+		t.Fatalf("want a login with the CA of the gateway, got %s", err)
+	}
+
+	// the CA saved by login is used without the flag
+	var listErr error
+	stdOut := test.CaptureStdout(func() {
+		faasCmd.SetArgs([]string{"list", "--gateway=" + s.URL})
+		listErr = faasCmd.Execute()
+	})
+	if listErr != nil {
+		t.Fatalf("want the functions listed with the saved TLS settings, got %s", listErr)
+	}
+	if !strings.Contains(stdOut, "function-test-1") {
+		t.Fatalf("Output is not as expected:\n%s", stdOut)
+	}
+}
+
+func Test_tlsFlags_invalid(t *testing.T) {
+	resetForTest()
+	defer resetForTest()
+
+	faasCmd.SetArgs([]string{"list", "--client-cert=client.crt"})
+	err := faasCmd.Execute()
+
+	if err == nil || err.Error() != "a client certificate and its key must be given together" {
+		t.Fatalf("want an error for a client certificate without its key, got %v", err)
+	}
+}
+
+// bearerGateway responds to requests with the bearer token given and rejects any other
+func bearerGateway(token string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer "+token {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte(`[{"name": "function-test-1"}]`))
+	}))
+}
+
+// executeCommand runs a command and returns its output
+func executeCommand(args ...string) (string, error) {
+	var err error
+	stdOut := test.CaptureStdout(func() {
+		faasCmd.SetArgs(args)
+		err = faasCmd.Execute()
+	})
+	resetForTest()
+	return stdOut, err
+}
+
+func Test_login_token(t *testing.T) {
+	s := bearerGateway("static-token")
+	defer s.Close()
+
+	resetForTest()
+	defer resetForTest()
+
+	if _, err := executeCommand("login", "--gateway="+s.URL, "--token=wrong-token"); err == nil || err.Error() != "unable to login, the token was rejected" {
+		t.Fatalf("want the token rejected, got %v", err)
+	}
+
+	stdOut, err := executeCommand("login", "--gateway="+s.URL, "--token=static-token")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(stdOut, "token saved for "+s.URL) {
+		t.Fatalf("Output is not as expected:\n%s", stdOut)
+	}
+
+	if stdOut, err := executeCommand("list", "--gateway="+s.URL); err != nil || !strings.Contains(stdOut, "function-test-1") {
+		t.Fatalf("want the functions listed with the saved token, got %v:\n%s", err, stdOut)
+	}
+
+	if _, err := executeCommand("login", "--gateway="+s.URL, "--token=static-token", "--username=admin"); err == nil || err.Error() != "--username, --token and --client-id are mutually exclusive" {
+		t.Fatalf("want an error for a token and a username, got %v", err)
+	}
+}
+
+func Test_login_clientCredentials(t *testing.T) {
+	tokenServer := test.MockTokenServer(t, "faas-cli", "secret", 3600)
+	defer tokenServer.Close()
+
+	s := bearerGateway("token-1")
+	defer s.Close()
+
+	resetForTest()
+	defer resetForTest()
+
+	args := []string{"login", "--gateway=" + s.URL, "--client-id=faas-cli", "--token-url=" + tokenServer.URL + "/token", "--scope=openfaas"}
+
+	if _, err := executeCommand(append(args, "--client-secret=wrong")...); err == nil || !strings.Contains(err.Error(), "invalid_client") {
+		t.Fatalf("want the client credentials rejected, got %v", err)
+	}
+
+	stdOut, err := executeCommand(append(args, "--client-secret=secret")...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(stdOut, "client credentials for faas-cli saved for "+s.URL) || tokenServer.Scope != "openfaas" {
+		t.Fatalf("Output is not as expected, scope %q:\n%s", tokenServer.Scope, stdOut)
+	}
+
+	// the token fetched by login is cached
+	if stdOut, err := executeCommand("list", "--gateway="+s.URL); err != nil || !strings.Contains(stdOut, "function-test-1") {
+		t.Fatalf("want the functions listed with the cached token, got %v:\n%s", err, stdOut)
+	}
+	if tokenServer.Issued != 1 {
+		t.Errorf("want a single token fetched, got %d", tokenServer.Issued)
+	}
+
+	if _, err := executeCommand("login", "--gateway="+s.URL, "--client-id=faas-cli", "--client-secret=secret"); err == nil || err.Error() != "must provide --token-url with --client-id" {
+		t.Fatalf("want an error without a token URL, got %v", err)
+	}
+}
@@ -16,12 +16,17 @@ import (
 	"github.com/openfaas/faas-cli/api"
 	"github.com/openfaas/faas-cli/options"
 	"github.com/openfaas/faas-cli/api/template"
+	"github.com/openfaas/faas-cli/version"
 	"github.com/spf13/cobra"
 )
 
 var (
-	lang string
-	list bool
+	lang            string
+	list            bool
+	newTemplateURL  string
+	newTemplatePath string
+	appendFile      string
+	imagePrefix     string
 )
 
 //StrSort Implement interface for sorting array of strings
@@ -36,18 +41,33 @@ func init() {
 	newFunctionCmd.Flags().StringVarP(&gateway, "gateway", "g", api.DefaultGateway, "Gateway URL to store in YAML stack file")
 
 	newFunctionCmd.Flags().BoolVar(&list, "list", false, "List available languages")
+	newFunctionCmd.Flags().StringVar(&newTemplateURL, "template-url", "", "Repository to pull templates from, with an optional #ref")
+	newFunctionCmd.Flags().BoolVar(&offline, "offline", false, offlineUsage)
+	newFunctionCmd.Flags().StringVar(&newTemplatePath, "template-path", template.DefaultTemplatePath, "Folder of the template repository holding the templates")
+	newFunctionCmd.Flags().StringVarP(&appendFile, "append", "a", "", "Existing YAML stack file to add the function to, rather than writing FUNCTION_NAME.yml")
+	newFunctionCmd.Flags().StringVarP(&imagePrefix, "prefix", "p", "", "Registry and/or owner to prefix the image with, i.e. docker.io/alexellis")
 
 	faasCmd.AddCommand(newFunctionCmd)
 }
 
 // newFunctionCmd displays newFunction information
 var newFunctionCmd = &cobra.Command{
-	Use:   "new FUNCTION_NAME --lang=FUNCTION_LANGUAGE [--gateway=http://domain:port] | --list)",
+	Use:   "new FUNCTION_NAME --lang=FUNCTION_LANGUAGE [--gateway=http://domain:port] [--template-url=URL#ref] [--append=stack.yml] [--prefix=owner] | --list)",
 	Short: "Create a new template in the current folder with the name given as name",
 	Long: `The new command creates a new function based upon hello-world in the given
-language or type in --list for a list of languages available.`,
+language or type in --list for a list of languages available.
+
+A language which is not in ./template is pulled from the template store, see
+"faas-cli template store list", or from --template-url when it is given.
+Templates are reused from the template cache where possible, and --offline
+uses only the cache.
+
+The function is written to FUNCTION_NAME.yml, or added to the functions of an
+existing stack file with --append, keeping that file's comments and ordering.`,
 	Example: `faas-cli new chatbot --lang node
   faas-cli new textparser --lang python --gateway http://mydomain:8080
+  faas-cli new chatbot --lang node --template-url https://github.com/openfaas/faas-cli#0.6.0
+  faas-cli new textparser --lang python --append stack.yml --prefix alexellis
   faas-cli new --list`,
 	RunE: runNewFunction,
 }
@@ -84,7 +104,7 @@ the "Dockerfile" lang type in your YAML file.
 		return fmt.Errorf("you must supply a function language with the --lang flag")
 	}
 
-	if err := api.Pull(options.TemplatePullOptions{URL: ""}); err != nil {
+	if err := pullNewFunctionTemplate(lang); err != nil {
 		return err
 	}
 
@@ -92,10 +112,39 @@ the "Dockerfile" lang type in your YAML file.
 		return fmt.Errorf("%s is unavailable or not supported", lang)
 	}
 
+	langTemplate := &stack.LanguageTemplate{Language: lang}
+	if strings.ToLower(lang) != "dockerfile" {
+		var err error
+		if langTemplate, err = stack.ParseYAMLForLanguageTemplate("./template/" + lang + "/template.yml"); err != nil {
+			return fmt.Errorf("unable to read template %s: %s", lang, err)
+		}
+		if err := langTemplate.CheckCLIVersion(version.BuildVersion()); err != nil {
+			return err
+		}
+		if platform := template.GetPlatform(); !langTemplate.SupportsPlatform(platform) {
+			fmt.Printf("Warning: template %s supports %s and may not build on %s.\n", lang, strings.Join(langTemplate.Platforms, ", "), platform)
+		}
+	}
+
 	if _, err := os.Stat(functionName); err == nil {
 		return fmt.Errorf("folder: %s already exists", functionName)
 	}
 
+	function := stack.Function{
+		Name:     functionName,
+		Language: lang,
+		Handler:  "./" + functionName,
+		Image:    prefixImage(imagePrefix, functionName),
+	}
+
+	var appendedStack []byte
+	if len(appendFile) > 0 {
+		var err error
+		if appendedStack, err = appendNewFunction(appendFile, function); err != nil {
+			return err
+		}
+	}
+
 	if err := os.Mkdir("./"+functionName, 0700); err == nil {
 		fmt.Printf("Folder: %s created.\n", functionName)
 	}
@@ -106,7 +155,13 @@ the "Dockerfile" lang type in your YAML file.
 
 	// Only "template" language templates - Dockerfile must be custom, so start with empty directory.
 	if strings.ToLower(lang) != "dockerfile" {
-		builder.CopyFiles("./template/"+lang+"/function/", "./"+functionName+"/", true)
+		handlerFolder, err := langTemplate.GetHandlerFolder()
+		if err != nil {
+			return fmt.Errorf("unable to use template %s: %s", lang, err)
+		}
+		if err := builder.CopyFiles("./template/"+lang+"/"+handlerFolder+"/", "./"+functionName+"/", true); err != nil {
+			return fmt.Errorf("unable to copy the handler of template %s: %s", lang, err)
+		}
 	} else {
 		ioutil.WriteFile("./"+functionName+"/Dockerfile", []byte(`FROM alpine:3.6
 # Use any image as your base image, or "scratch"
@@ -125,30 +180,92 @@ CMD ["fwatchdog"]
 `), 0600)
 	}
 
-	stack := `provider:
-  name: faas
-  gateway: ` + gateway + `
-
-functions:
-  ` + functionName + `:
-    lang: ` + lang + `
-    handler: ./` + functionName + `
-    image: ` + functionName + `
-`
-
 	fmt.Printf(aec.BlueF.Apply(figletStr))
 	fmt.Println()
 	fmt.Printf("Function created in folder: %s\n", functionName)
 
-	stackWriteErr := ioutil.WriteFile("./"+functionName+".yml", []byte(stack), 0600)
-	if stackWriteErr != nil {
-		return fmt.Errorf("error writing stack file %s", stackWriteErr)
+	if len(appendFile) > 0 {
+		if err := ioutil.WriteFile(appendFile, appendedStack, 0600); err != nil {
+			return fmt.Errorf("error writing stack file %s", err)
+		}
+		fmt.Printf("Stack file updated: %s\n", appendFile)
+	} else {
+		services := &stack.Services{
+			Provider:  stack.Provider{Name: "faas", GatewayURL: gateway},
+			Functions: map[string]stack.Function{functionName: function},
+		}
+		if err := stack.WriteYAMLFile("./"+functionName+".yml", services); err != nil {
+			return fmt.Errorf("error writing stack file %s", err)
+		}
+
+		fmt.Printf("Stack file written: %s\n", functionName+".yml")
 	}
 
-	fmt.Printf("Stack file written: %s\n", functionName+".yml")
+	if len(langTemplate.WelcomeMessage) > 0 {
+		fmt.Println()
+		fmt.Println(strings.TrimSpace(langTemplate.WelcomeMessage))
+	}
 	return nil
 }
 
+// pullNewFunctionTemplate pulls from --template-url when it is given, otherwise
+// a missing template is pulled from the template store, falling back to the
+// default repository when the store can not provide it. The store is skipped
+// when offline as its index is not cached.
+func pullNewFunctionTemplate(lang string) error {
+	if len(newTemplateURL) > 0 {
+		return api.Pull(options.TemplatePullOptions{URL: newTemplateURL, Path: newTemplatePath, Offline: offline})
+	}
+
+	if stack.IsValidTemplate(lang) {
+		return nil
+	}
+
+	if offline {
+		return api.Pull(options.TemplatePullOptions{URL: "", Offline: true})
+	}
+
+	storeErr := api.StorePull(options.TemplateStoreOptions{Platform: template.GetPlatform()}, lang)
+	if storeErr == nil {
+		return nil
+	}
+
+	fmt.Printf("Unable to pull %s from the template store: %s\nPulling the default templates instead.\n", lang, storeErr)
+	return api.Pull(options.TemplatePullOptions{URL: ""})
+}
+
+// appendNewFunction returns the stack file with the function added, refusing to
+// replace a function defined by the file or any file it includes
+func appendNewFunction(stackFile string, function stack.Function) ([]byte, error) {
+	sources, err := stack.DefinedFunctions(stackFile)
+	if err != nil {
+		return nil, fmt.Errorf("unable to append to %s: %s", stackFile, err)
+	}
+	if source, exists := sources[function.Name]; exists {
+		return nil, fmt.Errorf("function %s already exists in %s", function.Name, source)
+	}
+
+	fileData, err := ioutil.ReadFile(stackFile)
+	if err != nil {
+		return nil, fmt.Errorf("unable to append to %s: %s", stackFile, err)
+	}
+
+	appended, err := stack.AppendFunction(fileData, function)
+	if err != nil {
+		return nil, fmt.Errorf("unable to append to %s: %s", stackFile, err)
+	}
+	return appended, nil
+}
+
+// prefixImage prefixes the image of a function with a registry and/or owner
+func prefixImage(prefix string, image string) string {
+	prefix = strings.TrimSuffix(strings.TrimSpace(prefix), "/")
+	if len(prefix) == 0 {
+		return image
+	}
+	return prefix + "/" + image
+}
+
 func printAvailableTemplates(availableTemplates []string) string {
 	var result string
 	sort.Sort(StrSort(availableTemplates))
@@ -5,6 +5,7 @@ package commands
 
 import (
 	"fmt"
+	"io/ioutil"
 	"os"
 	"path/filepath"
 	"reflect"
@@ -197,3 +198,107 @@ func Test_languageNotExists(t *testing.T) {
 		t.Fatalf("Error on cd back to commands/ directory: %v", err)
 	}
 }
+
+func Test_newFunction_appendsToStack(t *testing.T) {
+	workDir, err := ioutil.TempDir("", "faas-cli-new-append")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(workDir)
+	writeTestTemplate(t, filepath.Join(workDir, "template", "greeter"), "language: greeter\nfprocess: ./handler\n", "function")
+
+	homeDir, _ := filepath.Abs(".")
+	if err := os.Chdir(workDir); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(homeDir)
+	defer func() {
+		appendFile = ""
+		imagePrefix = ""
+	}()
+
+	existing := `provider:
+  name: faas
+  gateway: http://127.0.0.1:8080
+
+functions:
+  # the first function
+  first-fn:
+    lang: greeter
+    handler: ./first-fn
+    image: first-fn
+`
+	if err := ioutil.WriteFile("stack.yml", []byte(existing), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	faasCmd.SetArgs([]string{"new", "second-fn", "--lang", "greeter", "--list=false", "--append", "stack.yml", "--prefix", "docker.io/alexellis/"})
+	stdOut := test.CaptureStdout(func() {
+		if err := faasCmd.Execute(); err != nil {
+			t.Fatal(err)
+		}
+	})
+	if !strings.Contains(stdOut, "Stack file updated: stack.yml") {
+		t.Errorf("want stack.yml updated, got:\n%s", stdOut)
+	}
+	if _, err := os.Stat("second-fn.yml"); err == nil {
+		t.Errorf("want no second-fn.yml written when appending")
+	}
+
+	expected := existing + `  second-fn:
+    lang: greeter
+    handler: ./second-fn
+    image: docker.io/alexellis/second-fn
+`
+	if data, _ := ioutil.ReadFile("stack.yml"); string(data) != expected {
+		t.Errorf("want:\n%s\ngot:\n%s", expected, data)
+	}
+
+	faasCmd.SetArgs([]string{"new", "first-fn", "--lang", "greeter", "--list=false", "--append", "stack.yml"})
+	var executeErr error
+	test.CaptureStdout(func() {
+		executeErr = faasCmd.Execute()
+	})
+	if executeErr == nil || executeErr.Error() != "function first-fn already exists in stack.yml" {
+		t.Errorf("want an existing function refused, got: %v", executeErr)
+	}
+	if _, err := os.Stat("first-fn"); err == nil {
+		t.Errorf("want no folder created for a refused function")
+	}
+}
+
+func Test_newFunction_writesStackFile(t *testing.T) {
+	workDir, err := ioutil.TempDir("", "faas-cli-new-stack-file")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(workDir)
+	writeTestTemplate(t, filepath.Join(workDir, "template", "greeter"), "language: greeter\nfprocess: ./handler\n", "function")
+
+	homeDir, _ := filepath.Abs(".")
+	if err := os.Chdir(workDir); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(homeDir)
+	defer func() { gateway = api.DefaultGateway }()
+
+	gatewayURL := "http://gateway:8080/#admin: yes"
+	faasCmd.SetArgs([]string{"new", "greeter-fn", "--lang", "greeter", "--list=false", "--gateway", gatewayURL})
+	test.CaptureStdout(func() {
+		if err := faasCmd.Execute(); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	services, err := stack.ParseYAMLFile("greeter-fn.yml", "", "")
+	if err != nil {
+		t.Fatalf("want a valid stack file: %s", err)
+	}
+	expected := &stack.Services{
+		Provider:  stack.Provider{Name: "faas", GatewayURL: gatewayURL},
+		Functions: map[string]stack.Function{"greeter-fn": {Language: "greeter", Handler: "./greeter-fn", Image: "greeter-fn"}},
+	}
+	if !reflect.DeepEqual(services, expected) {
+		t.Errorf("want %+v, got %+v", expected, services)
+	}
+}
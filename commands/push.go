@@ -12,11 +12,13 @@ import (
 func init() {
 	faasCmd.AddCommand(pushCmd)
 	pushCmd.Flags().IntVar(&parallel, "parallel", 1, "Push images in parallel to depth specified.")
+	pushCmd.Flags().StringVar(&engine, "engine", "", engineUsage)
+	pushCmd.Flags().BoolVar(&failFast, "fail-fast", false, "Stop pushing further images after the first failure")
 }
 
 // pushCmd handles pushing function container images to a remote repo
 var pushCmd = &cobra.Command{
-	Use:   `push -f YAML_FILE [--regex "REGEX"] [--filter "WILDCARD"] [--parallel]`,
+	Use:   `push -f YAML_FILE [--regex "REGEX"] [--filter "WILDCARD"] [--parallel] [--engine ENGINE] [--fail-fast]`,
 	Short: "Push OpenFaaS functions to remote registry (Docker Hub)",
 	Long: `Pushes the OpenFaaS function container image(s) defined in the supplied YAML
 config to a remote repository.
@@ -26,18 +28,19 @@ These container images must already be present in your local image cache.`,
 	Example: `  faas-cli push -f https://domain/path/myfunctions.yml
   faas-cli push -f ./samples.yml
   faas-cli push -f ./samples.yml --parallel 4
+  faas-cli push -f ./samples.yml --engine buildah
   faas-cli push -f ./samples.yml --filter "*gif*"
   faas-cli push -f ./samples.yml --regex "fn[0-9]_.*"`,
 	RunE: runPush,
 }
 
 func runPush(cmd *cobra.Command, args []string) error {
+	ctx, cancel := interruptContext()
+	defer cancel()
+
 	return api.Push(options.PushOptions{
-		FaasOptions: options.FaasOptions{
-			YamlFile: yamlFile,
-			Regex: regex,
-			Filter: filter,
-		},
+		Context: ctx,
+		FaasOptions: getFaasOptions(),
 		SharedOptions: options.SharedOptions{
 			Network: network,
 			Image: image,
@@ -46,5 +49,7 @@ func runPush(cmd *cobra.Command, args []string) error {
 			Language: language,
 		},
 		Parallel: parallel,
+		Engine: engine,
+		FailFast: failFast,
 	})
 }
@@ -5,6 +5,8 @@ package commands
 
 import (
 	"fmt"
+	"sort"
+	"strings"
 
 	"github.com/openfaas/faas-cli/proxy"
 	"github.com/openfaas/faas-cli/stack"
@@ -15,6 +17,7 @@ import (
 func init() {
 	// Setup flags that are used by multiple commands (variables defined in faas.go)
 	removeCmd.Flags().StringVarP(&gateway, "gateway", "g", api.DefaultGateway, "Gateway URL starting with http(s)://")
+	addRetryFlags(removeCmd.Flags())
 
 	faasCmd.AddCommand(removeCmd)
 }
@@ -33,14 +36,19 @@ explicitly specifying a function name.`,
   faas-cli remove -f ./samples.yml --filter "*gif*"
   faas-cli remove -f ./samples.yml --regex "fn[0-9]_.*"
   faas-cli remove url-ping
+  faas-cli remove url-ping --retries=3
   faas-cli remove img2ansi --gateway==http://remote-site.com:8080`,
 	RunE: runDelete,
 }
 
 func runDelete(cmd *cobra.Command, args []string) error {
+	if err := setRetryPolicy(); err != nil {
+		return err
+	}
+
 	var services stack.Services
-	if len(yamlFile) > 0 {
-		parsedServices, err := stack.ParseYAMLFile(yamlFile, regex, filter)
+	if len(yamlFiles) > 0 {
+		parsedServices, err := stack.ParseYAMLFiles(yamlFiles, regex, filter)
 		if err != nil {
 			return err
 		}
@@ -55,11 +63,17 @@ func runDelete(cmd *cobra.Command, args []string) error {
 			services.Provider.Network = api.DefaultNetwork
 		}
 
+		var failed []string
 		for k, function := range services.Functions {
 			function.Name = k
-			fmt.Printf("Deleting: %s.\n", function.Name)
+			if err := deleteFunction(services.Provider.GatewayURL, function.Name); err != nil {
+				failed = append(failed, function.Name)
+			}
+		}
 
-			proxy.DeleteFunction(services.Provider.GatewayURL, function.Name)
+		if len(failed) > 0 {
+			sort.Strings(failed)
+			return fmt.Errorf("failed to remove %d of %d functions: %s", len(failed), len(services.Functions), strings.Join(failed, ", "))
 		}
 	} else {
 		if len(args) < 1 {
@@ -67,9 +81,24 @@ func runDelete(cmd *cobra.Command, args []string) error {
 		}
 
 		functionName = args[0]
-		fmt.Printf("Deleting: %s.\n", functionName)
-		proxy.DeleteFunction(gateway, functionName)
+		if err := deleteFunction(gateway, functionName); err != nil {
+			return fmt.Errorf("failed to remove %s: %s", functionName, err)
+		}
 	}
 
 	return nil
 }
+
+// deleteFunction removes a function and prints the outcome, a function which
+// is not deployed is not an error
+func deleteFunction(gatewayURL string, functionName string) error {
+	fmt.Printf("Deleting: %s.\n", functionName)
+
+	result, err := proxy.DeleteFunction(gatewayURL, functionName)
+	fmt.Println(result.Message)
+
+	if _, notFound := err.(*proxy.ErrNotFound); notFound {
+		return nil
+	}
+	return err
+}
@@ -5,6 +5,7 @@ package commands
 
 import (
 	"net/http"
+	"strings"
 	"testing"
 
 	"github.com/openfaas/faas-cli/test"
@@ -29,3 +30,58 @@ func Test_remove(t *testing.T) {
 	})
 	faasCmd.Execute()
 }
+
+func Test_remove_failureReturnsError(t *testing.T) {
+	s := test.MockHttpServer(t, []test.Request{
+		{
+			Method:             http.MethodDelete,
+			Uri:                "/system/functions",
+			ResponseStatusCode: http.StatusInternalServerError,
+		},
+	})
+	defer s.Close()
+
+	resetForTest()
+
+	var executeErr error
+	test.CaptureStdout(func() {
+		faasCmd.SetArgs([]string{
+			"remove",
+			"--gateway=" + s.URL,
+			"test-function",
+		})
+		executeErr = faasCmd.Execute()
+	})
+
+	if executeErr == nil || !strings.HasPrefix(executeErr.Error(), "failed to remove test-function: server returned unexpected status code: 500") {
+		t.Fatalf("want the failure returned as an error, got: %v", executeErr)
+	}
+}
+
+func Test_remove_notFoundIsNotAnError(t *testing.T) {
+	s := test.MockHttpServer(t, []test.Request{
+		{
+			Method:             http.MethodDelete,
+			Uri:                "/system/functions",
+			ResponseStatusCode: http.StatusNotFound,
+		},
+	})
+	defer s.Close()
+
+	resetForTest()
+
+	stdOut := test.CaptureStdout(func() {
+		faasCmd.SetArgs([]string{
+			"remove",
+			"--gateway=" + s.URL,
+			"test-function",
+		})
+		if err := faasCmd.Execute(); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	if !strings.Contains(stdOut, "No existing function to remove") {
+		t.Errorf("unexpected output:\n%s", stdOut)
+	}
+}
@@ -0,0 +1,22 @@
+// Copyright (c) OpenFaaS Project 2018. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package commands
+
+import (
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	faasCmd.AddCommand(templateCmd)
+}
+
+// templateCmd groups the commands which fetch and discover templates
+var templateCmd = &cobra.Command{
+	Use:   `template [COMMAND]`,
+	Short: "OpenFaaS template store, pull and validate commands",
+	Long:  "Allows browsing the template store and pulling templates from it or from a repository",
+	Example: `  faas-cli template pull https://github.com/openfaas/faas-cli
+  faas-cli template store list
+  faas-cli template validate template/python3`,
+}
@@ -0,0 +1,138 @@
+// Copyright (c) OpenFaaS Project 2018. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package commands
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/openfaas/faas-cli/api/template"
+	"github.com/spf13/cobra"
+)
+
+var pruneOlderThan time.Duration
+
+func init() {
+	templateCachePruneCmd.Flags().DurationVar(&pruneOlderThan, "older-than", 0, "Only remove templates fetched longer ago than this, e.g. 720h")
+
+	templateCacheCmd.AddCommand(templateCacheListCmd)
+	templateCacheCmd.AddCommand(templateCachePruneCmd)
+	templateCmd.AddCommand(templateCacheCmd)
+}
+
+// templateCacheCmd groups the commands which manage the template cache
+var templateCacheCmd = &cobra.Command{
+	Use:   `cache [COMMAND]`,
+	Short: "Manage the template cache shared by every project",
+	Long: `Templates pulled by "template pull", "new" and "build" are kept in a cache under
+~/.openfaas/templates so that other projects can reuse them, including offline.`,
+	Example: `  faas-cli template cache ls
+  faas-cli template cache prune --older-than 720h`,
+}
+
+var templateCacheListCmd = &cobra.Command{
+	Use:     `ls`,
+	Aliases: []string{"list"},
+	Short:   "List the sources in the template cache",
+	Example: `  faas-cli template cache ls`,
+	Args:    cobra.NoArgs,
+	RunE:    runTemplateCacheList,
+}
+
+var templateCachePruneCmd = &cobra.Command{
+	Use:   `prune [SOURCE] [--older-than DURATION]`,
+	Short: "Remove sources from the template cache",
+	Long: `Removes every source from the template cache, or only the SOURCE given, such as
+https://github.com/openfaas/faas-cli. Use --older-than to keep recently fetched ones.`,
+	Example: `  faas-cli template cache prune
+  faas-cli template cache prune https://github.com/openfaas/faas-cli
+  faas-cli template cache prune --older-than 720h`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runTemplateCachePrune,
+}
+
+func runTemplateCacheList(cmd *cobra.Command, args []string) error {
+	entries, err := template.ListCache()
+	if err != nil {
+		return err
+	}
+
+	if len(entries) == 0 {
+		fmt.Println("The template cache is empty.")
+		return nil
+	}
+
+	printCachedTemplates(os.Stdout, entries)
+	return nil
+}
+
+func runTemplateCachePrune(cmd *cobra.Command, args []string) error {
+	var source string
+	if len(args) > 0 {
+		source = args[0]
+	}
+
+	pruned, err := template.PruneCache(source, pruneOlderThan)
+	for _, cached := range pruned {
+		fmt.Printf("Removed %s\n", describeCachedTemplates(cached))
+	}
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Removed %d source(s) from the template cache.\n", len(pruned))
+	return nil
+}
+
+func printCachedTemplates(out io.Writer, entries []template.CachedTemplates) {
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "SOURCE\tCOMMIT\tFETCHED\tSIZE\tTEMPLATES")
+	for _, cached := range entries {
+		commit := cached.Commit
+		if len(commit) > 7 {
+			commit = commit[:7]
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n",
+			describeCachedTemplates(cached),
+			commit,
+			cached.Fetched.Local().Format("2006-01-02 15:04"),
+			formatSize(dirSize(cached.Dir)),
+			strings.Join(cached.Languages, ", "))
+	}
+	w.Flush()
+}
+
+func describeCachedTemplates(cached template.CachedTemplates) string {
+	source := template.LockedTemplate{Source: cached.Source, Ref: cached.Ref}.String()
+	if cached.Path != template.DefaultTemplatePath {
+		source += " --path " + cached.Path
+	}
+	return source
+}
+
+func dirSize(dir string) int64 {
+	var size int64
+	filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err == nil && info.Mode().IsRegular() {
+			size += info.Size()
+		}
+		return nil
+	})
+	return size
+}
+
+func formatSize(size int64) string {
+	switch {
+	case size >= 1024*1024:
+		return fmt.Sprintf("%.1fMB", float64(size)/(1024*1024))
+	case size >= 1024:
+		return fmt.Sprintf("%.1fKB", float64(size)/1024)
+	}
+	return fmt.Sprintf("%dB", size)
+}
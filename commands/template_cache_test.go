@@ -0,0 +1,65 @@
+// Copyright (c) OpenFaaS Project 2018. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package commands
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/openfaas/faas-cli/api/template"
+	"github.com/openfaas/faas-cli/test"
+)
+
+func Test_templateCache(t *testing.T) {
+	workDir, err := ioutil.TempDir("", "faas-cli-template-cache")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(workDir)
+	template.SetWorkDirectory(workDir)
+	defer template.SetWorkDirectory("./")
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.ServeFile(w, r, storeTestArchive)
+	}))
+	defer ts.Close()
+
+	repository := ts.URL + "/owner/repo"
+	if err := template.FetchTemplates(repository+"#0.6.0", "", false, template.PreferCache); err != nil {
+		t.Fatal(err)
+	}
+
+	stdOut := test.CaptureStdout(func() {
+		faasCmd.SetArgs([]string{"template", "cache", "ls"})
+		if err := faasCmd.Execute(); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	lines := strings.Split(stdOut, "\n")
+	if !strings.HasPrefix(lines[0], "SOURCE") || !strings.HasPrefix(lines[1], repository+"#0.6.0  75adf28  ") {
+		t.Errorf("unexpected cache listing:\n%s", stdOut)
+	}
+	if !strings.HasSuffix(lines[1], "csharp, node, node-armhf, python, python-armhf, ruby") {
+		t.Errorf("want the cached templates listed, got:\n%s", stdOut)
+	}
+
+	stdOut = test.CaptureStdout(func() {
+		faasCmd.SetArgs([]string{"template", "cache", "prune", repository})
+		if err := faasCmd.Execute(); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	if !strings.Contains(stdOut, "Removed 1 source(s) from the template cache.") {
+		t.Errorf("unexpected prune output:\n%s", stdOut)
+	}
+	if entries, _ := template.ListCache(); len(entries) != 0 {
+		t.Errorf("want an empty cache after pruning, got %v", entries)
+	}
+}
@@ -6,67 +6,106 @@ package commands
 import (
 	"fmt"
 	"regexp"
+	"strings"
 
 	"github.com/spf13/cobra"
 	"github.com/openfaas/faas-cli/options"
 	"github.com/openfaas/faas-cli/api"
+	"github.com/openfaas/faas-cli/api/template"
 )
 
 const (
-	repositoryRegexpMockedServer = `^http://127.0.0.1:\d+/([a-z0-9-]+)/([a-z0-9-]+)$`
-	repositoryRegexpGithub       = `^https://github.com/([a-z0-9-]+)/([a-z0-9-]+)/?$`
+	repositoryRegexpMockedServer = `^http://127.0.0.1:\d+/([a-z0-9-]+)/([a-z0-9-]+)(#[A-Za-z0-9._/-]+)?$`
+	repositoryRegexpGithub       = `^https://github.com/([a-z0-9-]+)/([a-z0-9-]+)/?(#[A-Za-z0-9._/-]+)?$`
+	repositoryRegexpGit          = `^([A-Za-z0-9._-]+@[A-Za-z0-9.-]+:|(ssh|git)://)[A-Za-z0-9._/:@~-]+(#[A-Za-z0-9._/-]+)?$`
+	repositoryRegexpArchive      = `^(https?|file)://[^#\s]+\.(zip|tar\.gz|tgz)$`
+	repositoryRegexpDirectory    = `^file://[^#\s]+$`
 )
 
 var (
-	repository string
-	overwrite  bool
+	repository   string
+	overwrite    bool
+	templatePath string
+	offline      bool
 )
 
-var supportedVerbs = [...]string{"pull"}
+// offlineUsage describes the --offline flag shared by the commands which pull templates
+const offlineUsage = "Only use templates from the template cache, never the network"
 
 func init() {
 	templatePullCmd.Flags().BoolVar(&overwrite, "overwrite", false, "Overwrite existing templates?")
+	templatePullCmd.Flags().BoolVar(&frozen, "frozen", false, "Restore exactly the templates recorded in "+template.LockFile)
+	templatePullCmd.Flags().BoolVar(&offline, "offline", false, offlineUsage)
+	templatePullCmd.Flags().StringVar(&templatePath, "path", template.DefaultTemplatePath, "Folder of the repository holding the templates")
 
-	faasCmd.AddCommand(templatePullCmd)
+	templateCmd.AddCommand(templatePullCmd)
 }
 
 // templatePullCmd allows the user to fetch a template from a repository
 var templatePullCmd = &cobra.Command{
-	Use: "template pull <repository URL>",
+	Use: "pull [REPOSITORY_URL[#ref]] [--path PATH] | --frozen",
 	Args: func(cmd *cobra.Command, args []string) error {
-		msg := fmt.Sprintf(`Must use a supported verb for 'faas-cli template'
-Currently supported verbs: %v`, supportedVerbs)
-
-		if len(args) == 0 {
-			return fmt.Errorf(msg)
+		if len(args) > 1 {
+			return fmt.Errorf("only one repository URL may be given")
 		}
 
-		if args[0] != "pull" {
-			return fmt.Errorf(msg)
+		if len(args) > 0 && frozen {
+			return fmt.Errorf("--frozen restores the templates recorded in %s and does not take a repository URL", template.LockFile)
 		}
 
-		if len(args) > 1 {
-			var validURL = regexp.MustCompile(repositoryRegexpGithub + "|" + repositoryRegexpMockedServer)
+		if len(args) > 0 {
+			var validURL = regexp.MustCompile(strings.Join([]string{
+				repositoryRegexpGithub,
+				repositoryRegexpGit,
+				repositoryRegexpArchive,
+				repositoryRegexpDirectory,
+				repositoryRegexpMockedServer,
+			}, "|"))
 
-			if !validURL.MatchString(args[1]) {
-				return fmt.Errorf("The repository URL must be in the format https://github.com/<owner>/<repository>")
+			if !validURL.MatchString(args[0]) {
+				return fmt.Errorf(`The repository URL must be in one of the formats:
+  https://github.com/<owner>/<repository>[#ref]
+  git@<host>:<repository>[#ref]
+  https://<host>/<path>.zip or .tar.gz
+  file://<directory or archive>`)
 			}
 		}
 		return nil
 	},
 	Short: "Downloads templates from the specified github repo",
 	Long: `Downloads the compressed github repo specified by [URL], and extracts the 'template'
-	directory from the root of the repo, if it exists.`,
-	Example: "faas-cli template pull https://github.com/openfaas/faas-cli",
-	Run:     runTemplatePull,
+	directory from the root of the repo, if it exists. Repositories reached over SSH such
+	as git@host:repo are cloned with git, and templates may also be read from a .zip or
+	.tar.gz URL or from a local directory or archive with file://.
+
+	A branch or tag other than master may be given after a '#' for GitHub and git
+	repositories, and --path picks a different folder of the repo. The source
+	of each template is recorded in ` + template.LockFile + `, use --frozen to restore exactly
+	those templates.
+
+	Templates are kept in a cache shared by every project, see "faas-cli template cache".
+	Pulling refreshes the cache, use --offline to pull from the cache without the network.`,
+	Example: `  faas-cli template pull https://github.com/openfaas/faas-cli
+  faas-cli template pull https://github.com/openfaas/faas-cli#0.6.0
+  faas-cli template pull https://github.com/owner/repo --path templates/openfaas
+  faas-cli template pull git@git.example.com:team/templates.git#v1.2.0
+  faas-cli template pull https://example.com/templates.tar.gz
+  faas-cli template pull file://../templates
+  faas-cli template pull --frozen
+  faas-cli template pull https://github.com/openfaas/faas-cli --offline`,
+	RunE:    runTemplatePull,
 }
 
-func runTemplatePull(cmd *cobra.Command, args []string) {
-	if len(args) > 1 {
-		repository = args[1]
+func runTemplatePull(cmd *cobra.Command, args []string) error {
+	if len(args) > 0 {
+		repository = args[0]
 	}
-	api.Pull(options.TemplatePullOptions{
+	return api.Pull(options.TemplatePullOptions{
 		URL: repository,
+		Path: templatePath,
 		Overwrite: overwrite,
+		Frozen: frozen,
+		Offline: offline,
+		Refresh: true,
 	})
 }
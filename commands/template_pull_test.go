@@ -9,6 +9,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"path/filepath"
 	"regexp"
 	"strings"
 	"testing"
@@ -89,15 +90,26 @@ func Test_templatePull_no_arg(t *testing.T) {
 func Test_templatePull_error_not_valid_url(t *testing.T) {
 	var buf bytes.Buffer
 
-	faasCmd.SetArgs([]string{"template", "pull", "git@github.com:openfaas/faas-cli.git"})
+	faasCmd.SetArgs([]string{"template", "pull", "http://github.com/openfaas/faas-cli"})
 	faasCmd.SetOutput(&buf)
 	err := faasCmd.Execute()
 
-	if !strings.Contains(err.Error(), "The repository URL must be in the format https://github.com/<owner>/<repository>") {
+	if !strings.Contains(err.Error(), "The repository URL must be in one of the formats:\n  https://github.com/<owner>/<repository>[#ref]") {
 		t.Fatal("Output does not contain the required string", err.Error())
 	}
 }
 
+func Test_templatePull_frozen_with_url(t *testing.T) {
+	defer func() { frozen = false }()
+
+	faasCmd.SetArgs([]string{"template", "pull", "https://github.com/openfaas/faas-cli", "--frozen"})
+	err := faasCmd.Execute()
+
+	if err == nil || !strings.Contains(err.Error(), "--frozen restores the templates recorded in template.lock") {
+		t.Fatal("want an error for a repository URL with --frozen, got", err)
+	}
+}
+
 // httpTestServer returns a testing http server
 func httpTestServer(t *testing.T) *httptest.Server {
 	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -145,6 +157,52 @@ func Test_repositoryUrlRegExp(t *testing.T) {
 	if !r.MatchString(url) {
 		t.Errorf("Url %s must be valid", url)
 	}
+
+	url = "https://github.com/owner/repo#0.6.0"
+	if !r.MatchString(url) {
+		t.Errorf("Url %s must be valid", url)
+	}
+
+	url = "https://github.com/owner/repo#feature/templates"
+	if !r.MatchString(url) {
+		t.Errorf("Url %s must be valid", url)
+	}
+
+	url = "https://github.com/owner/repo#"
+	if r.MatchString(url) {
+		t.Errorf("Url %s must name a ref after #", url)
+	}
+}
+
+func Test_repositoryUrlRegExp_sources(t *testing.T) {
+	r := regexp.MustCompile(strings.Join([]string{repositoryRegexpGit, repositoryRegexpArchive, repositoryRegexpDirectory}, "|"))
+
+	valid := []string{
+		"git@github.com:openfaas/faas-cli.git",
+		"git@git.internal:team/templates#v1.2.0",
+		"ssh://git@git.internal:2222/team/templates.git",
+		"https://example.com/releases/templates.tar.gz",
+		"http://example.com/templates.zip",
+		"file://../templates",
+		"file:///home/alex/templates.tgz",
+	}
+	for _, url := range valid {
+		if !r.MatchString(url) {
+			t.Errorf("Url %s must be valid", url)
+		}
+	}
+
+	invalid := []string{
+		"https://example.com/templates",
+		"https://example.com/templates.tar.gz#v1",
+		"file://../templates#master",
+		"git@git.internal:",
+	}
+	for _, url := range invalid {
+		if r.MatchString(url) {
+			t.Errorf("Url %s must not be valid", url)
+		}
+	}
 }
 
 func Test_PullTemplates(t *testing.T) {
@@ -152,18 +210,38 @@ func Test_PullTemplates(t *testing.T) {
 
 	// Create fake server for testing.
 	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/archive/0.6.0.zip" {
+			t.Errorf("want the archive of the ref requested, got %s", r.URL.Path)
+		}
 		http.ServeFile(w, r, testdataPath)
 	}))
 	defer ts.Close()
 
 	err := api.Pull(options.TemplatePullOptions{
-		URL: ts.URL,
+		URL: ts.URL + "#0.6.0",
 	})
 
 	if err != nil {
 		t.Error(err)
 	}
 
+	lock, err := template.ReadLock()
+	if err != nil {
+		t.Fatal(err)
+	}
+	locked, ok := lock.Get("csharp")
+	if !ok {
+		t.Fatalf("want csharp recorded in %s, got %v", template.LockFile, lock.Templates)
+	}
+	digest, err := template.TreeDigest(filepath.Join(template.GetTemplateDirectory(), "csharp"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := template.LockedTemplate{Name: "csharp", Source: ts.URL, Ref: "0.6.0", Commit: "75adf28c188ed298f221ea58c8433e447a236f87", Path: "template", Digest: digest}
+	if locked != want {
+		t.Errorf("want %#v, got %#v", want, locked)
+	}
+
 }
 
 // tearDown_fetch_templates cleans all files and directories created by the test
@@ -192,6 +270,11 @@ func tearDown_fetch_templates(t *testing.T) {
 		t.Logf("Directory template was not created: %s", err)
 	}
 
+	// Remove the lock written by the pull
+	if err := os.Remove(template.GetLockPath()); err != nil && !os.IsNotExist(err) {
+		t.Log(err)
+	}
+
 	// Verify the downloaded archive
 	archive := "template-owner-repo.zip"
 	if _, err := os.Stat(archive); err == nil {
@@ -0,0 +1,43 @@
+// Copyright (c) OpenFaaS Project 2018. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package commands
+
+import (
+	"github.com/openfaas/faas-cli/api/template"
+	"github.com/openfaas/faas-cli/options"
+	"github.com/spf13/cobra"
+)
+
+// Flags shared by the template store commands
+var (
+	storeURL      string
+	storePlatform string
+)
+
+func init() {
+	templateStoreCmd.PersistentFlags().StringVarP(&storeURL, "url", "u", "", "URL or file of the template store index (defaults to $"+template.StoreURLEnvironment+" or "+template.DefaultStoreURL+")")
+	templateStoreCmd.PersistentFlags().StringVarP(&storePlatform, "platform", "p", template.GetPlatform(), "Platform of the templates, e.g. armhf, or an empty string for every platform")
+
+	templateCmd.AddCommand(templateStoreCmd)
+}
+
+// templateStoreCmd groups the commands which browse the template store
+var templateStoreCmd = &cobra.Command{
+	Use:   `store [COMMAND]`,
+	Short: "Command for browsing and pulling templates from the template store",
+	Long: `Browse the index of community and internal templates, show their details and
+pull them. The index is read from --url, the ` + template.StoreURLEnvironment + `
+environment variable or the official store.`,
+	Example: `  faas-cli template store list
+  faas-cli template store describe golang-http
+  faas-cli template store pull golang-http`,
+}
+
+func getTemplateStoreOptions() options.TemplateStoreOptions {
+	return options.TemplateStoreOptions{
+		URL:       storeURL,
+		Platform:  storePlatform,
+		Overwrite: overwrite,
+	}
+}
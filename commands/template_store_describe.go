@@ -0,0 +1,49 @@
+// Copyright (c) OpenFaaS Project 2018. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package commands
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+
+	"github.com/openfaas/faas-cli/api"
+	"github.com/openfaas/faas-cli/api/template"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	templateStoreCmd.AddCommand(templateStoreDescribeCmd)
+}
+
+var templateStoreDescribeCmd = &cobra.Command{
+	Use:   `describe TEMPLATE_NAME [--url STORE_URL] [--platform PLATFORM]`,
+	Short: "Describe a template in the template store",
+	Long:  `Shows the description, repository and maintainer of a template in the template store`,
+	Example: `  faas-cli template store describe golang-http
+  faas-cli template store describe node --platform armhf`,
+	Args: cobra.ExactArgs(1),
+	RunE: runTemplateStoreDescribe,
+}
+
+func runTemplateStoreDescribe(cmd *cobra.Command, args []string) error {
+	storeTemplate, err := api.StoreDescribe(getTemplateStoreOptions(), args[0])
+	if err != nil {
+		return err
+	}
+
+	printStoreTemplate(os.Stdout, storeTemplate)
+	return nil
+}
+
+func printStoreTemplate(out io.Writer, storeTemplate template.StoreTemplate) {
+	w := tabwriter.NewWriter(out, 0, 0, 1, ' ', 0)
+	fmt.Fprintf(w, "Name:\t%s\n", storeTemplate.Name)
+	fmt.Fprintf(w, "Platform:\t%s\n", storeTemplate.Platform)
+	fmt.Fprintf(w, "Description:\t%s\n", storeTemplate.Description)
+	fmt.Fprintf(w, "Repository:\t%s\n", storeTemplate.Repository)
+	fmt.Fprintf(w, "Maintainer:\t%s\n", storeTemplate.Maintainer)
+	w.Flush()
+}
@@ -0,0 +1,53 @@
+// Copyright (c) OpenFaaS Project 2018. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package commands
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+
+	"github.com/openfaas/faas-cli/api"
+	"github.com/openfaas/faas-cli/api/template"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	templateStoreCmd.AddCommand(templateStoreListCmd)
+}
+
+var templateStoreListCmd = &cobra.Command{
+	Use:     `list [--url STORE_URL] [--platform PLATFORM]`,
+	Aliases: []string{"ls"},
+	Short:   "List templates in the template store",
+	Long:    `Lists the templates in the template store which are available for the platform`,
+	Example: `  faas-cli template store list
+  faas-cli template store list --platform armhf
+  faas-cli template store list --url https://templates.example.com/index.json`,
+	RunE: runTemplateStoreList,
+}
+
+func runTemplateStoreList(cmd *cobra.Command, args []string) error {
+	templates, err := api.StoreList(getTemplateStoreOptions())
+	if err != nil {
+		return err
+	}
+
+	if len(templates) == 0 {
+		return fmt.Errorf("no templates were found in the store for platform %s", storePlatform)
+	}
+
+	printStoreTemplates(os.Stdout, templates)
+	return nil
+}
+
+func printStoreTemplates(out io.Writer, templates []template.StoreTemplate) {
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tPLATFORM\tMAINTAINER\tDESCRIPTION")
+	for _, storeTemplate := range templates {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", storeTemplate.Name, storeTemplate.Platform, storeTemplate.Maintainer, storeTemplate.Description)
+	}
+	w.Flush()
+}
@@ -0,0 +1,29 @@
+// Copyright (c) OpenFaaS Project 2018. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package commands
+
+import (
+	"github.com/openfaas/faas-cli/api"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	templateStorePullCmd.Flags().BoolVar(&overwrite, "overwrite", false, "Overwrite existing templates?")
+
+	templateStoreCmd.AddCommand(templateStorePullCmd)
+}
+
+var templateStorePullCmd = &cobra.Command{
+	Use:   `pull TEMPLATE_NAME [--url STORE_URL] [--platform PLATFORM] [--overwrite]`,
+	Short: "Pull a template from the template store",
+	Long:  `Pulls the repository which provides a template in the template store`,
+	Example: `  faas-cli template store pull golang-http
+  faas-cli template store pull node --platform armhf --overwrite`,
+	Args: cobra.ExactArgs(1),
+	RunE: runTemplateStorePull,
+}
+
+func runTemplateStorePull(cmd *cobra.Command, args []string) error {
+	return api.StorePull(getTemplateStoreOptions(), args[0])
+}
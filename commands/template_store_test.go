@@ -0,0 +1,126 @@
+// Copyright (c) OpenFaaS Project 2018. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package commands
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/openfaas/faas-cli/api/template"
+	"github.com/openfaas/faas-cli/test"
+)
+
+// storeTestArchive is resolved before any test changes the working directory
+var storeTestArchive, _ = filepath.Abs(testdataPath)
+
+// writeStoreIndex writes a store index whose templates are read from the test archive
+func writeStoreIndex(t *testing.T, dir string) string {
+	archive := storeTestArchive
+	index := fmt.Sprintf(`[
+  {"name": "csharp", "platform": "x86_64", "repo": "file://%s", "description": "C# templates", "maintainer": "openfaas"},
+  {"name": "ruby", "platform": "armhf", "repo": "file://%s", "description": "Ruby for Raspberry Pi", "maintainer": "alexellis"}
+]`, archive, archive)
+
+	indexPath := filepath.Join(dir, "templates.json")
+	if err := ioutil.WriteFile(indexPath, []byte(index), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return indexPath
+}
+
+func resetStoreFlags() {
+	storeURL = ""
+	storePlatform = template.GetPlatform()
+}
+
+func Test_templateStore_platformDefault(t *testing.T) {
+	if platform := templateStoreCmd.PersistentFlags().Lookup("platform").DefValue; platform != template.GetPlatform() {
+		t.Errorf("want the platform of this machine by default, got %s", platform)
+	}
+}
+
+func Test_templateStoreList(t *testing.T) {
+	defer resetStoreFlags()
+	indexPath := writeStoreIndex(t, os.TempDir())
+	defer os.Remove(indexPath)
+
+	stdOut := test.CaptureStdout(func() {
+		faasCmd.SetArgs([]string{"template", "store", "list", "--url", indexPath})
+		if err := faasCmd.Execute(); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	want := `NAME    PLATFORM  MAINTAINER  DESCRIPTION
+csharp  x86_64    openfaas    C# templates
+`
+	if stdOut != want {
+		t.Errorf("want:\n%s\ngot:\n%s", want, stdOut)
+	}
+}
+
+func Test_templateStoreDescribe(t *testing.T) {
+	defer resetStoreFlags()
+	indexPath := writeStoreIndex(t, os.TempDir())
+	defer os.Remove(indexPath)
+
+	stdOut := test.CaptureStdout(func() {
+		faasCmd.SetArgs([]string{"template", "store", "describe", "ruby", "--url", indexPath, "--platform", "armhf"})
+		if err := faasCmd.Execute(); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	if !strings.Contains(stdOut, "Description: Ruby for Raspberry Pi\n") || !strings.Contains(stdOut, "Maintainer:  alexellis\n") {
+		t.Errorf("unexpected description:\n%s", stdOut)
+	}
+
+	faasCmd.SetArgs([]string{"template", "store", "describe", "ruby", "--url", indexPath, "--platform", "x86_64"})
+	if err := faasCmd.Execute(); err == nil || !strings.Contains(err.Error(), "template ruby was not found in the store for platform x86_64") {
+		t.Errorf("want ruby missing for x86_64, got %v", err)
+	}
+}
+
+func Test_newFunction_pullsFromStore(t *testing.T) {
+	defer resetStoreFlags()
+
+	workDir, err := ioutil.TempDir("", "faas-cli-new-from-store")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(workDir)
+
+	indexPath := writeStoreIndex(t, workDir)
+	os.Setenv(template.StoreURLEnvironment, indexPath)
+	defer os.Unsetenv(template.StoreURLEnvironment)
+
+	homeDir, _ := filepath.Abs(".")
+	if err := os.Chdir(workDir); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(homeDir)
+
+	faasCmd.SetArgs([]string{"new", "store-fn", "--lang", "csharp", "--list=false"})
+	stdOut := test.CaptureStdout(func() {
+		if err := faasCmd.Execute(); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	if !strings.Contains(stdOut, "Function created in folder: store-fn") {
+		t.Errorf("want the function created, got:\n%s", stdOut)
+	}
+	if _, err := os.Stat(filepath.Join(workDir, "template", "csharp", "template.yml")); err != nil {
+		t.Errorf("want csharp pulled from the store: %s", err)
+	}
+
+	lock, _ := template.ReadLock()
+	if locked, ok := lock.Get("csharp"); !ok || !strings.HasSuffix(locked.Source, "master_test.zip") {
+		t.Errorf("want csharp recorded from the store's repository, got %v", locked)
+	}
+}
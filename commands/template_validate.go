@@ -0,0 +1,55 @@
+// Copyright (c) OpenFaaS Project 2018. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package commands
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/openfaas/faas-cli/stack"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	templateCmd.AddCommand(templateValidateCmd)
+}
+
+var templateValidateCmd = &cobra.Command{
+	Use:   `validate TEMPLATE_DIR [TEMPLATE_DIR...]`,
+	Short: "Validate language templates before they are published",
+	Long: `Checks that each template folder has a Dockerfile, a folder holding the function
+and a template.yml which can be parsed, reporting every problem found.`,
+	Example: `  faas-cli template validate template/python3
+  faas-cli template validate template/*`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: runTemplateValidate,
+}
+
+func runTemplateValidate(cmd *cobra.Command, args []string) error {
+	if invalid := validateTemplates(os.Stdout, args); invalid > 0 {
+		return fmt.Errorf("%d of %d templates failed validation", invalid, len(args))
+	}
+	return nil
+}
+
+// validateTemplates prints the problems found in each template folder and
+// returns how many of them are invalid
+func validateTemplates(out io.Writer, dirs []string) int {
+	invalid := 0
+	for _, dir := range dirs {
+		errs := stack.ValidateLanguageTemplate(dir)
+		if len(errs) == 0 {
+			fmt.Fprintf(out, "%s: OK\n", dir)
+			continue
+		}
+
+		invalid++
+		fmt.Fprintf(out, "%s: invalid\n", dir)
+		for _, validationErr := range errs {
+			fmt.Fprintf(out, "  %s\n", validationErr.Error())
+		}
+	}
+	return invalid
+}
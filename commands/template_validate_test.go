@@ -0,0 +1,120 @@
+// Copyright (c) OpenFaaS Project 2018. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package commands
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/openfaas/faas-cli/test"
+	"github.com/openfaas/faas-cli/version"
+)
+
+const greeterTemplateYAML = `language: greeter
+fprocess: ./handler
+handler_folder: src
+minimum_cli_version: 0.6.0
+welcome_message: |
+  Edit src/handler.sh to greet your users.
+`
+
+// writeTestTemplate writes a language template holding a handler in handlerFolder
+func writeTestTemplate(t *testing.T, dir string, templateYAML string, handlerFolder string) {
+	if err := os.MkdirAll(filepath.Join(dir, handlerFolder), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	files := map[string]string{
+		"Dockerfile":   "FROM alpine:3.6\n",
+		"template.yml": templateYAML,
+		filepath.Join(handlerFolder, "handler.sh"): "echo hello\n",
+	}
+	for name, content := range files {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func Test_templateValidate(t *testing.T) {
+	workDir, err := ioutil.TempDir("", "faas-cli-template-validate")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(workDir)
+
+	validDir := filepath.Join(workDir, "greeter")
+	writeTestTemplate(t, validDir, greeterTemplateYAML, "src")
+
+	invalidDir := filepath.Join(workDir, "broken")
+	writeTestTemplate(t, invalidDir, "language: broken\nfprocess: cat\n", "handler")
+	os.Remove(filepath.Join(invalidDir, "Dockerfile"))
+
+	var executeErr error
+	stdOut := test.CaptureStdout(func() {
+		faasCmd.SetArgs([]string{"template", "validate", validDir, invalidDir})
+		executeErr = faasCmd.Execute()
+	})
+
+	if executeErr == nil || executeErr.Error() != "1 of 2 templates failed validation" {
+		t.Errorf("want one template to fail validation, got: %v", executeErr)
+	}
+	if !strings.Contains(stdOut, validDir+": OK") {
+		t.Errorf("want %s to be valid, got:\n%s", validDir, stdOut)
+	}
+	for _, want := range []string{
+		invalidDir + ": invalid",
+		filepath.Join(invalidDir, "Dockerfile") + ": a template must have a Dockerfile",
+		filepath.Join(invalidDir, "function") + ": a template must have a function folder holding the function",
+	} {
+		if !strings.Contains(stdOut, want) {
+			t.Errorf("want %q in the output, got:\n%s", want, stdOut)
+		}
+	}
+}
+
+func Test_newFunction_templateMetadata(t *testing.T) {
+	workDir, err := ioutil.TempDir("", "faas-cli-new-template-metadata")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(workDir)
+	writeTestTemplate(t, filepath.Join(workDir, "template", "greeter"), greeterTemplateYAML, "src")
+
+	homeDir, _ := filepath.Abs(".")
+	if err := os.Chdir(workDir); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(homeDir)
+
+	defer func(current string) { version.Version = current }(version.Version)
+	version.Version = "0.5.2"
+
+	faasCmd.SetArgs([]string{"new", "old-cli-fn", "--lang", "greeter", "--list=false"})
+	var executeErr error
+	test.CaptureStdout(func() {
+		executeErr = faasCmd.Execute()
+	})
+	if executeErr == nil || executeErr.Error() != "template greeter requires faas-cli 0.6.0 or newer, this is faas-cli 0.5.2" {
+		t.Errorf("want the minimum CLI version enforced, got: %v", executeErr)
+	}
+
+	version.Version = "0.6.1"
+	faasCmd.SetArgs([]string{"new", "greeter-fn", "--lang", "greeter", "--list=false"})
+	stdOut := test.CaptureStdout(func() {
+		if err := faasCmd.Execute(); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	if !strings.HasSuffix(stdOut, "Edit src/handler.sh to greet your users.\n") {
+		t.Errorf("want the welcome message printed, got:\n%s", stdOut)
+	}
+	if _, err := os.Stat(filepath.Join(workDir, "greeter-fn", "handler.sh")); err != nil {
+		t.Errorf("want the handler copied from the src folder: %s", err)
+	}
+}
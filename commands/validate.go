@@ -0,0 +1,62 @@
+// Copyright (c) OpenFaaS Project 2018. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package commands
+
+import (
+	"fmt"
+
+	"github.com/openfaas/faas-cli/api"
+	"github.com/openfaas/faas-cli/stack"
+	"github.com/spf13/cobra"
+)
+
+var (
+	printSchema bool
+)
+
+func init() {
+	validateCmd.Flags().BoolVar(&printSchema, "schema", false, "Print the JSON Schema for stack files instead of validating")
+
+	faasCmd.AddCommand(validateCmd)
+}
+
+// validateCmd checks stack files for mistakes before they are used
+var validateCmd = &cobra.Command{
+	Use: `validate -f YAML_FILE
+  faas-cli validate --schema`,
+	Short: "Validate OpenFaaS stack files",
+	Long: `Strictly validates the stack file(s) supplied via the "--yaml" flag. Unknown
+keys are reported with their line number, along with missing images or handlers,
+unknown languages and malformed memory or CPU quantities.
+
+The --schema flag prints a JSON Schema for stack files which can be used by
+editors for validation and autocompletion.`,
+	Example: `  faas-cli validate -f ./samples.yml
+  faas-cli validate -f stack.yml -f stack.prod.yml
+  faas-cli validate --schema > stack.schema.json`,
+	RunE: runValidate,
+}
+
+func runValidate(cmd *cobra.Command, args []string) error {
+	if printSchema {
+		schema, err := stack.GenerateJSONSchema()
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(schema))
+		return nil
+	}
+
+	err := api.Validate(getFaasOptions())
+	if problems, ok := err.(stack.ValidationErrors); ok {
+		fmt.Println(problems.Error())
+		return fmt.Errorf("found %d problem(s) in the YAML file", len(problems))
+	}
+	if err != nil {
+		return err
+	}
+
+	fmt.Println("YAML file is valid.")
+	return nil
+}
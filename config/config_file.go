@@ -13,6 +13,7 @@ import (
 	"path"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/mitchellh/go-homedir"
 	"gopkg.in/yaml.v2"
@@ -29,10 +30,45 @@ type ConfigFile struct {
 	FilePath    string       `yaml:"-"`
 }
 
+// Types of authentication saved in AuthConfig.Auth
+const (
+	// BasicAuthType sends a username and password, Token holds both encoded with EncodeAuth
+	BasicAuthType = "basic"
+	// BearerAuthType sends the static token held in Token
+	BearerAuthType = "bearer"
+	// OAuth2AuthType fetches tokens with the client credentials in OAuth2 and
+	// caches the last one in Token
+	OAuth2AuthType = "oauth2"
+)
+
 type AuthConfig struct {
 	Gateway string `yaml:"gateway,omitempty"`
 	Auth    string `yaml:"auth,omitempty"`
 	Token   string `yaml:"token,omitempty"`
+	// OAuth2 client credentials when Auth is oauth2
+	OAuth2 *OAuth2Config `yaml:"oauth2,omitempty"`
+	// TLS settings used for the gateway, see TLSConfig
+	TLS *TLSConfig `yaml:"tls,omitempty"`
+}
+
+// OAuth2Config holds the client credentials used to fetch tokens for a gateway
+type OAuth2Config struct {
+	TokenURL     string   `yaml:"token_url"`
+	ClientID     string   `yaml:"client_id"`
+	ClientSecret string   `yaml:"client_secret"`
+	Scopes       []string `yaml:"scopes,omitempty"`
+	// Expiry of the token cached in AuthConfig.Token, in RFC 3339 format
+	Expiry string `yaml:"expiry,omitempty"`
+}
+
+// ExpiryTime returns the expiry of the cached token, the zero time when the
+// token does not expire
+func (o *OAuth2Config) ExpiryTime() time.Time {
+	expiry, err := time.Parse(time.RFC3339, o.Expiry)
+	if err != nil {
+		return time.Time{}
+	}
+	return expiry
 }
 
 // New initializes a config file for the given file path
@@ -150,11 +186,6 @@ func DecodeAuth(input string) (string, string, error) {
 
 // UpdateAuthConfig creates or updates the username and password for a given gateway
 func UpdateAuthConfig(gateway string, username string, password string) error {
-	_, err := url.ParseRequestURI(gateway)
-	if err != nil || len(gateway) < 1 {
-		return fmt.Errorf("invalid gateway URL")
-	}
-
 	if len(username) < 1 {
 		return fmt.Errorf("username can't be an empty string")
 	}
@@ -163,6 +194,56 @@ func UpdateAuthConfig(gateway string, username string, password string) error {
 		return fmt.Errorf("password can't be an empty string")
 	}
 
+	return saveAuthConfig(AuthConfig{
+		Gateway: gateway,
+		Auth:    BasicAuthType,
+		Token:   EncodeAuth(username, password),
+	})
+}
+
+// UpdateBearerAuthConfig creates or updates the static bearer token for a given gateway
+func UpdateBearerAuthConfig(gateway string, token string) error {
+	if len(token) < 1 {
+		return fmt.Errorf("token can't be an empty string")
+	}
+
+	return saveAuthConfig(AuthConfig{
+		Gateway: gateway,
+		Auth:    BearerAuthType,
+		Token:   token,
+	})
+}
+
+// UpdateOAuth2AuthConfig creates or updates the OAuth2 client credentials for a
+// given gateway, tokens are cached with UpdateOAuth2Token
+func UpdateOAuth2AuthConfig(gateway string, oauth2 OAuth2Config) error {
+	if _, err := url.ParseRequestURI(oauth2.TokenURL); err != nil {
+		return fmt.Errorf("invalid token URL")
+	}
+
+	if len(oauth2.ClientID) < 1 {
+		return fmt.Errorf("client ID can't be an empty string")
+	}
+
+	if len(oauth2.ClientSecret) < 1 {
+		return fmt.Errorf("client secret can't be an empty string")
+	}
+
+	return saveAuthConfig(AuthConfig{
+		Gateway: gateway,
+		Auth:    OAuth2AuthType,
+		OAuth2:  &oauth2,
+	})
+}
+
+// saveAuthConfig creates or replaces the credentials of a gateway, the TLS
+// settings saved for it are kept
+func saveAuthConfig(auth AuthConfig) error {
+	_, err := url.ParseRequestURI(auth.Gateway)
+	if err != nil || len(auth.Gateway) < 1 {
+		return fmt.Errorf("invalid gateway URL")
+	}
+
 	configPath, err := EnsureFile()
 	if err != nil {
 		return err
@@ -177,15 +258,9 @@ func UpdateAuthConfig(gateway string, username string, password string) error {
 		return err
 	}
 
-	auth := AuthConfig{
-		Gateway: gateway,
-		Auth:    "basic",
-		Token:   EncodeAuth(username, password),
-	}
-
 	index := -1
 	for i, v := range cfg.AuthConfigs {
-		if gateway == v.Gateway {
+		if auth.Gateway == v.Gateway {
 			index = i
 			break
 		}
@@ -194,6 +269,7 @@ func UpdateAuthConfig(gateway string, username string, password string) error {
 	if index == -1 {
 		cfg.AuthConfigs = append(cfg.AuthConfigs, auth)
 	} else {
+		auth.TLS = cfg.AuthConfigs[index].TLS
 		cfg.AuthConfigs[index] = auth
 	}
 
@@ -204,37 +280,79 @@ func UpdateAuthConfig(gateway string, username string, password string) error {
 	return nil
 }
 
+// UpdateOAuth2Token caches a token fetched with the OAuth2 client credentials of
+// a given gateway, a zero expiry means the token does not expire
+func UpdateOAuth2Token(gateway string, token string, expiry time.Time) error {
+	configPath, err := EnsureFile()
+	if err != nil {
+		return err
+	}
+
+	cfg, err := New(configPath)
+	if err != nil {
+		return err
+	}
+
+	if err := cfg.load(); err != nil {
+		return err
+	}
+
+	for i, v := range cfg.AuthConfigs {
+		if gateway == v.Gateway && v.OAuth2 != nil {
+			cfg.AuthConfigs[i].Token = token
+			cfg.AuthConfigs[i].OAuth2.Expiry = ""
+			if !expiry.IsZero() {
+				cfg.AuthConfigs[i].OAuth2.Expiry = expiry.UTC().Format(time.RFC3339)
+			}
+			return cfg.save()
+		}
+	}
+
+	return fmt.Errorf("no oauth2 config found for %s", gateway)
+}
+
 // LookupAuthConfig returns the username and password for a given gateway
 func LookupAuthConfig(gateway string) (string, string, error) {
+	auth, err := LookupGatewayAuth(gateway)
+	if err != nil {
+		return "", "", err
+	}
+
+	if auth.Auth != BasicAuthType {
+		return "", "", fmt.Errorf("no basic auth config found for %s", gateway)
+	}
+	return DecodeAuth(auth.Token)
+}
+
+// LookupGatewayAuth returns the credentials saved for a given gateway, whichever
+// type of authentication they are for
+func LookupGatewayAuth(gateway string) (AuthConfig, error) {
 	if !fileExists() {
-		return "", "", fmt.Errorf("config file not found")
+		return AuthConfig{}, fmt.Errorf("config file not found")
 	}
 
 	configPath, err := EnsureFile()
 	if err != nil {
-		return "", "", err
+		return AuthConfig{}, err
 	}
 
 	cfg, err := New(configPath)
 	if err != nil {
-		return "", "", err
+		return AuthConfig{}, err
 	}
 
 	if err := cfg.load(); err != nil {
-		return "", "", err
+		return AuthConfig{}, err
 	}
 
 	for _, v := range cfg.AuthConfigs {
-		if gateway == v.Gateway {
-			user, pass, err := DecodeAuth(v.Token)
-			if err != nil {
-				return "", "", err
-			}
-			return user, pass, nil
+		// a gateway may only have TLS settings saved
+		if gateway == v.Gateway && len(v.Auth) > 0 {
+			return v, nil
 		}
 	}
 
-	return "", "", fmt.Errorf("no auth config found for %s", gateway)
+	return AuthConfig{}, fmt.Errorf("no auth config found for %s", gateway)
 }
 
 // RemoveAuthConfig deletes the username and password for a given gateway
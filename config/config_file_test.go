@@ -9,6 +9,7 @@ import (
 	"regexp"
 	"strings"
 	"testing"
+	"time"
 )
 
 func Test_LookupAuthConfig_WithNoConfigFile(t *testing.T) {
@@ -217,3 +218,74 @@ func Test_RemoveAuthConfig_WithUnknownGateway(t *testing.T) {
 		t.Errorf("Error not matched: %s", err.Error())
 	}
 }
+
+func Test_UpdateBearerAuthConfig(t *testing.T) {
+	DefaultDir, _ = ioutil.TempDir("", "faas-cli-file-test")
+	DefaultFile = "bearer.yml"
+	gatewayURL := "https://openfaas.test"
+
+	if err := UpdateBearerAuthConfig(gatewayURL, ""); err == nil || err.Error() != "token can't be an empty string" {
+		t.Errorf("want an error for an empty token, got %v", err)
+	}
+
+	if err := UpdateBearerAuthConfig(gatewayURL, "static-token"); err != nil {
+		t.Fatal(err)
+	}
+
+	auth, err := LookupGatewayAuth(gatewayURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if auth.Auth != BearerAuthType || auth.Token != "static-token" {
+		t.Errorf("want the bearer token saved, got %+v", auth)
+	}
+
+	if _, _, err := LookupAuthConfig(gatewayURL); err == nil {
+		t.Errorf("want no username and password for a bearer token")
+	}
+}
+
+func Test_UpdateOAuth2AuthConfig(t *testing.T) {
+	DefaultDir, _ = ioutil.TempDir("", "faas-cli-file-test")
+	DefaultFile = "oauth2.yml"
+	gatewayURL := "https://openfaas.test"
+	oauth2 := OAuth2Config{TokenURL: "https://idp.test/token", ClientID: "faas-cli", ClientSecret: "secret", Scopes: []string{"openfaas"}}
+
+	invalid := oauth2
+	invalid.TokenURL = "idp.test"
+	if err := UpdateOAuth2AuthConfig(gatewayURL, invalid); err == nil || err.Error() != "invalid token URL" {
+		t.Errorf("want an error for an invalid token URL, got %v", err)
+	}
+
+	if err := UpdateOAuth2Token(gatewayURL, "access-token", time.Now()); err == nil {
+		t.Errorf("want an error caching a token without client credentials")
+	}
+
+	if err := UpdateOAuth2AuthConfig(gatewayURL, oauth2); err != nil {
+		t.Fatal(err)
+	}
+
+	expiry := time.Date(2030, 1, 2, 3, 4, 5, 0, time.UTC)
+	if err := UpdateOAuth2Token(gatewayURL, "access-token", expiry); err != nil {
+		t.Fatal(err)
+	}
+
+	auth, err := LookupGatewayAuth(gatewayURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if auth.Auth != OAuth2AuthType || auth.Token != "access-token" || auth.OAuth2.ClientID != "faas-cli" {
+		t.Errorf("want the client credentials and token saved, got %+v", auth)
+	}
+	if !auth.OAuth2.ExpiryTime().Equal(expiry) {
+		t.Errorf("want the token to expire at %s, got %s", expiry, auth.OAuth2.ExpiryTime())
+	}
+
+	if err := UpdateOAuth2Token(gatewayURL, "no-expiry", time.Time{}); err != nil {
+		t.Fatal(err)
+	}
+	auth, _ = LookupGatewayAuth(gatewayURL)
+	if !auth.OAuth2.ExpiryTime().IsZero() {
+		t.Errorf("want no expiry, got %s", auth.OAuth2.Expiry)
+	}
+}
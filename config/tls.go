@@ -0,0 +1,176 @@
+// Copyright (c) OpenFaaS Project 2018. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package config
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+)
+
+// DefaultTLSConfig is used by every HTTP client of the CLI, it is set from the
+// --tls-no-verify, --ca-cert, --client-cert and --client-key flags
+var DefaultTLSConfig TLSConfig
+
+// TLSConfig holds the TLS settings of a HTTP client
+type TLSConfig struct {
+	// InsecureSkipVerify accepts any certificate, including self-signed ones
+	InsecureSkipVerify bool `yaml:"insecure_skip_verify,omitempty"`
+	// CACert is a PEM file of the certificate authorities to trust instead of the system ones
+	CACert string `yaml:"ca_cert,omitempty"`
+	// ClientCert and ClientKey are the PEM files of a client certificate
+	ClientCert string `yaml:"client_cert,omitempty"`
+	ClientKey  string `yaml:"client_key,omitempty"`
+}
+
+// IsZero tells whether the defaults of Go are kept
+func (t TLSConfig) IsZero() bool {
+	return t == TLSConfig{}
+}
+
+// Merge returns the settings of t overridden by those set in override, the
+// certificate is only verified when neither skips verification
+func (t TLSConfig) Merge(override TLSConfig) TLSConfig {
+	merged := t
+	merged.InsecureSkipVerify = t.InsecureSkipVerify || override.InsecureSkipVerify
+	if len(override.CACert) > 0 {
+		merged.CACert = override.CACert
+	}
+	if len(override.ClientCert) > 0 || len(override.ClientKey) > 0 {
+		merged.ClientCert = override.ClientCert
+		merged.ClientKey = override.ClientKey
+	}
+	return merged
+}
+
+// ClientConfig loads the certificates and returns the configuration of a TLS
+// client, nil is returned when the defaults of Go are kept
+func (t TLSConfig) ClientConfig() (*tls.Config, error) {
+	if t.IsZero() {
+		return nil, nil
+	}
+
+	tlsConfig := &tls.Config{
+		InsecureSkipVerify: t.InsecureSkipVerify,
+	}
+
+	if len(t.CACert) > 0 {
+		caCert, err := ioutil.ReadFile(t.CACert)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read CA certificate: %s", err)
+		}
+
+		tlsConfig.RootCAs = x509.NewCertPool()
+		if !tlsConfig.RootCAs.AppendCertsFromPEM(caCert) {
+			return nil, fmt.Errorf("no PEM certificates found in CA certificate %s", t.CACert)
+		}
+	}
+
+	if len(t.ClientCert) > 0 || len(t.ClientKey) > 0 {
+		if len(t.ClientCert) == 0 || len(t.ClientKey) == 0 {
+			return nil, fmt.Errorf("a client certificate and its key must be given together")
+		}
+
+		clientCert, err := tls.LoadX509KeyPair(t.ClientCert, t.ClientKey)
+		if err != nil {
+			return nil, fmt.Errorf("unable to load client certificate: %s", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{clientCert}
+	}
+
+	return tlsConfig, nil
+}
+
+// AbsPaths returns the settings with the certificate files as absolute paths,
+// so they can be used from any directory once saved
+func (t TLSConfig) AbsPaths() (TLSConfig, error) {
+	for _, path := range []*string{&t.CACert, &t.ClientCert, &t.ClientKey} {
+		if len(*path) == 0 {
+			continue
+		}
+
+		absPath, err := filepath.Abs(*path)
+		if err != nil {
+			return t, err
+		}
+		*path = absPath
+	}
+	return t, nil
+}
+
+// UpdateTLSConfig saves the TLS settings used for a gateway, zero settings
+// remove them
+func UpdateTLSConfig(gateway string, tlsConfig TLSConfig) error {
+	tlsConfig, err := tlsConfig.AbsPaths()
+	if err != nil {
+		return err
+	}
+
+	configPath, err := EnsureFile()
+	if err != nil {
+		return err
+	}
+
+	cfg, err := New(configPath)
+	if err != nil {
+		return err
+	}
+
+	if err := cfg.load(); err != nil {
+		return err
+	}
+
+	var saved *TLSConfig
+	if !tlsConfig.IsZero() {
+		saved = &tlsConfig
+	}
+
+	index := -1
+	for i, v := range cfg.AuthConfigs {
+		if gateway == v.Gateway {
+			index = i
+			break
+		}
+	}
+
+	if index == -1 {
+		cfg.AuthConfigs = append(cfg.AuthConfigs, AuthConfig{Gateway: gateway, TLS: saved})
+	} else {
+		cfg.AuthConfigs[index].TLS = saved
+	}
+
+	return cfg.save()
+}
+
+// GatewayTLSConfig returns the TLS settings saved for a gateway overridden by
+// DefaultTLSConfig
+func GatewayTLSConfig(gateway string) (TLSConfig, error) {
+	if !fileExists() {
+		return DefaultTLSConfig, nil
+	}
+
+	configPath, err := EnsureFile()
+	if err != nil {
+		return DefaultTLSConfig, err
+	}
+
+	cfg, err := New(configPath)
+	if err != nil {
+		return DefaultTLSConfig, err
+	}
+
+	if err := cfg.load(); err != nil {
+		return DefaultTLSConfig, err
+	}
+
+	for _, v := range cfg.AuthConfigs {
+		if gateway == v.Gateway && v.TLS != nil {
+			return v.TLS.Merge(DefaultTLSConfig), nil
+		}
+	}
+
+	return DefaultTLSConfig, nil
+}
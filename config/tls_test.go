@@ -0,0 +1,137 @@
+// Copyright (c) OpenFaaS Project 2018. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package config
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/openfaas/faas-cli/test"
+)
+
+func Test_TLSConfig_Merge(t *testing.T) {
+	saved := TLSConfig{CACert: "/saved/ca.crt", ClientCert: "/saved/client.crt", ClientKey: "/saved/client.key"}
+
+	tests := []struct {
+		name     string
+		override TLSConfig
+		want     TLSConfig
+	}{
+		{name: "no override", override: TLSConfig{}, want: saved},
+		{
+			name:     "CA overridden",
+			override: TLSConfig{CACert: "ca.crt"},
+			want:     TLSConfig{CACert: "ca.crt", ClientCert: "/saved/client.crt", ClientKey: "/saved/client.key"},
+		},
+		{
+			name:     "client certificate overridden as a pair",
+			override: TLSConfig{ClientCert: "client.crt"},
+			want:     TLSConfig{CACert: "/saved/ca.crt", ClientCert: "client.crt"},
+		},
+		{
+			name:     "verification skipped",
+			override: TLSConfig{InsecureSkipVerify: true},
+			want:     TLSConfig{InsecureSkipVerify: true, CACert: "/saved/ca.crt", ClientCert: "/saved/client.crt", ClientKey: "/saved/client.key"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if merged := saved.Merge(test.override); merged != test.want {
+				t.Errorf("want %+v, got %+v", test.want, merged)
+			}
+		})
+	}
+}
+
+func Test_TLSConfig_ClientConfig(t *testing.T) {
+	dir, err := ioutil.TempDir("", "faas-cli-tls-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	certFile, keyFile := test.WriteCertificate(t, dir, "client")
+	notPEM := filepath.Join(dir, "not-pem.crt")
+	ioutil.WriteFile(notPEM, []byte("not a certificate"), 0600)
+
+	if tlsConfig, err := (TLSConfig{}).ClientConfig(); tlsConfig != nil || err != nil {
+		t.Errorf("want the defaults of Go kept, got %+v %v", tlsConfig, err)
+	}
+
+	tlsConfig, err := TLSConfig{CACert: certFile, ClientCert: certFile, ClientKey: keyFile}.ClientConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tlsConfig.RootCAs == nil || len(tlsConfig.Certificates) != 1 || tlsConfig.InsecureSkipVerify {
+		t.Errorf("want the CA and client certificate loaded, got %+v", tlsConfig)
+	}
+
+	errTests := []struct {
+		tlsConfig TLSConfig
+		want      string
+	}{
+		{tlsConfig: TLSConfig{CACert: filepath.Join(dir, "missing.crt")}, want: "unable to read CA certificate"},
+		{tlsConfig: TLSConfig{CACert: notPEM}, want: "no PEM certificates found"},
+		{tlsConfig: TLSConfig{ClientCert: certFile}, want: "a client certificate and its key must be given together"},
+		{tlsConfig: TLSConfig{ClientCert: certFile, ClientKey: notPEM}, want: "unable to load client certificate"},
+	}
+	for _, errTest := range errTests {
+		if _, err := errTest.tlsConfig.ClientConfig(); err == nil || !strings.HasPrefix(err.Error(), errTest.want) {
+			t.Errorf("%+v: want an error starting with %q, got %v", errTest.tlsConfig, errTest.want, err)
+		}
+	}
+}
+
+func Test_UpdateTLSConfig(t *testing.T) {
+	DefaultDir, _ = ioutil.TempDir("", "faas-cli-file-test")
+	DefaultFile = "tls.yml"
+	defer os.RemoveAll(DefaultDir)
+	defer func() { DefaultTLSConfig = TLSConfig{} }()
+
+	gatewayURL := "https://openfaas.test"
+	if err := UpdateAuthConfig(gatewayURL, "admin", "pass"); err != nil {
+		t.Fatal(err)
+	}
+	if err := UpdateTLSConfig(gatewayURL, TLSConfig{CACert: "ca.crt"}); err != nil {
+		t.Fatal(err)
+	}
+
+	absCACert, _ := filepath.Abs("ca.crt")
+	saved, err := GatewayTLSConfig(gatewayURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if saved != (TLSConfig{CACert: absCACert}) {
+		t.Errorf("want the CA saved with an absolute path, got %+v", saved)
+	}
+
+	// logging in again keeps the TLS settings
+	if err := UpdateAuthConfig(gatewayURL, "admin", "new pass"); err != nil {
+		t.Fatal(err)
+	}
+	DefaultTLSConfig = TLSConfig{InsecureSkipVerify: true}
+	merged, _ := GatewayTLSConfig(gatewayURL)
+	if merged != (TLSConfig{InsecureSkipVerify: true, CACert: absCACert}) {
+		t.Errorf("want the saved settings merged with the flags, got %+v", merged)
+	}
+
+	if other, _ := GatewayTLSConfig("https://other.test"); other != DefaultTLSConfig {
+		t.Errorf("want the flags used for other gateways, got %+v", other)
+	}
+
+	if err := UpdateTLSConfig(gatewayURL, TLSConfig{}); err != nil {
+		t.Fatal(err)
+	}
+	cfg, _ := New(filepath.Join(DefaultDir, DefaultFile))
+	cfg.load()
+	want := []AuthConfig{{Gateway: gatewayURL, Auth: "basic", Token: EncodeAuth("admin", "new pass")}}
+	if !reflect.DeepEqual(cfg.AuthConfigs, want) {
+		t.Errorf("want the TLS settings removed and the credentials kept, got %+v", cfg.AuthConfigs)
+	}
+}
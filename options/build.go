@@ -1,11 +1,24 @@
 package options
 
+import "context"
+
 //BuildOptions a set of arguments used for build
 type BuildOptions struct {
 	FaasOptions
 	SharedOptions
+	// Context stops the builds when cancelled, context.Background() when nil
+	Context    context.Context
 	Nocache    bool
 	Squash     bool
 	Parallel   int
 	Shrinkwrap bool
+	Engine     string
+	Force      bool
+	FailFast   bool
+	Quiet      bool
+	Frozen     bool
+	Offline    bool
+
+	BuildArgs    []string
+	BuildOptions []string
 }
@@ -10,4 +10,9 @@ type DeployOptions struct {
 	Constraints []string
 	Secrets     []string
 	LabelOpts   []string
+
+	LimitMemory   string
+	LimitCPU      string
+	RequestMemory string
+	RequestCPU    string
 }
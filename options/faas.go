@@ -4,11 +4,12 @@ import "github.com/openfaas/faas-cli/stack"
 
 //FaasOptions contains flags for all commands
 type FaasOptions struct {
-	YamlFile string
-	Regex    string
-	Filter   string
-	WorkDir  string
-	Services *stack.Services
+	YamlFiles []string
+	Regex     string
+	Filter    string
+	WorkDir   string
+	Strict    bool
+	Services  *stack.Services
 }
 
 //SharedOptions contains flags for subset of commands
@@ -5,4 +5,13 @@ type LoginOptions struct {
 	SharedOptions
 	Username string
 	Password string
+
+	// Token is a static bearer token
+	Token string
+
+	// OAuth2 client credentials
+	ClientID     string
+	ClientSecret string
+	TokenURL     string
+	Scopes       []string
 }
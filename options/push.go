@@ -1,8 +1,14 @@
 package options
 
+import "context"
+
 // PushOptions store flags for the push  command
 type PushOptions struct {
 	FaasOptions
 	SharedOptions
+	// Context stops the pushes when cancelled, context.Background() when nil
+	Context  context.Context
 	Parallel int
+	Engine   string
+	FailFast bool
 }
@@ -3,5 +3,11 @@ package options
 //TemplatePullOptions contains flag used to pull a set of templates
 type TemplatePullOptions struct {
 	URL       string
+	Path      string
 	Overwrite bool
+	Frozen    bool
+	// Offline only uses the template cache
+	Offline bool
+	// Refresh fetches templates even when they are cached
+	Refresh bool
 }
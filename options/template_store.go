@@ -0,0 +1,8 @@
+package options
+
+//TemplateStoreOptions contains flags used to browse and pull from the template store
+type TemplateStoreOptions struct {
+	URL       string
+	Platform  string
+	Overwrite bool
+}
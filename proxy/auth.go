@@ -4,18 +4,144 @@
 package proxy
 
 import (
+	"context"
+	"fmt"
 	"net/http"
+	"sync"
 
 	"github.com/openfaas/faas-cli/config"
 )
 
-//SetAuth sets basic auth for the given gateway
-func SetAuth(req *http.Request, gateway string) {
-	username, password, err := config.LookupAuthConfig(gateway)
+//SetAuth sets the credentials saved by faas-cli login for the given gateway,
+// a token is fetched and saved when the one cached for OAuth2 is about to expire
+func SetAuth(req *http.Request, gateway string) error {
+	auth, err := config.LookupGatewayAuth(gateway)
 	if err != nil {
 		// no auth info found
-		return
+		return nil
 	}
 
-	req.SetBasicAuth(username, password)
+	switch auth.Auth {
+	case config.BasicAuthType:
+		username, password, err := config.DecodeAuth(auth.Token)
+		if err != nil {
+			return err
+		}
+		req.SetBasicAuth(username, password)
+	case config.BearerAuthType:
+		setBearerToken(req, auth.Token)
+	case config.OAuth2AuthType:
+		token, err := configToken(req.Context(), auth)
+		if err != nil {
+			return err
+		}
+		setBearerToken(req, token.AccessToken)
+	default:
+		return fmt.Errorf("unsupported auth type %s saved for %s", auth.Auth, gateway)
+	}
+	return nil
+}
+
+// configToken returns the OAuth2 token cached for a gateway, or fetches and
+// caches a new one when it is about to expire
+func configToken(ctx context.Context, auth config.AuthConfig) (Token, error) {
+	if auth.OAuth2 == nil {
+		return Token{}, fmt.Errorf("no oauth2 client credentials saved for %s", auth.Gateway)
+	}
+
+	token := Token{AccessToken: auth.Token, Expiry: auth.OAuth2.ExpiryTime()}
+	if token.Valid() {
+		return token, nil
+	}
+
+	gatewayTLS, err := config.GatewayTLSConfig(auth.Gateway)
+	if err != nil {
+		return Token{}, err
+	}
+
+	credentials := ClientCredentials{
+		TokenURL:     auth.OAuth2.TokenURL,
+		ClientID:     auth.OAuth2.ClientID,
+		ClientSecret: auth.OAuth2.ClientSecret,
+		Scopes:       auth.OAuth2.Scopes,
+		TLS:          gatewayTLS,
+	}
+	token, err = credentials.Token(ctx)
+	if err != nil {
+		return Token{}, err
+	}
+
+	if err := config.UpdateOAuth2Token(auth.Gateway, token.AccessToken, token.Expiry); err != nil {
+		return Token{}, err
+	}
+	return token, nil
+}
+
+// setBearerToken sets the Authorization header of a request to a bearer token
+func setBearerToken(req *http.Request, token string) {
+	req.Header.Set("Authorization", "Bearer "+token)
+}
+
+// ClientAuth sets the credentials of a request to the gateway
+type ClientAuth interface {
+	Set(req *http.Request) error
+}
+
+// ConfigAuth uses the credentials saved for a gateway by faas-cli login
+type ConfigAuth struct {
+	Gateway string
+}
+
+// Set adds the credentials saved for the gateway to the request, if any
+func (a *ConfigAuth) Set(req *http.Request) error {
+	return SetAuth(req, a.Gateway)
+}
+
+// BasicAuth sets the same username and password on every request
+type BasicAuth struct {
+	Username string
+	Password string
+}
+
+// Set adds basic auth to the request
+func (a *BasicAuth) Set(req *http.Request) error {
+	req.SetBasicAuth(a.Username, a.Password)
+	return nil
+}
+
+// BearerAuth sets the same static token on every request
+type BearerAuth struct {
+	Token string
+}
+
+// Set adds the token to the request
+func (a *BearerAuth) Set(req *http.Request) error {
+	setBearerToken(req, a.Token)
+	return nil
+}
+
+// ClientCredentialsAuth fetches tokens with the OAuth2 client credentials grant
+// and keeps each one in memory until it is about to expire
+type ClientCredentialsAuth struct {
+	Credentials ClientCredentials
+
+	mutex sync.Mutex
+	token Token
+}
+
+// Set adds the current token to the request, fetching a new one when needed
+func (a *ClientCredentialsAuth) Set(req *http.Request) error {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	if !a.token.Valid() {
+		token, err := a.Credentials.Token(req.Context())
+		if err != nil {
+			return err
+		}
+		a.token = token
+	}
+
+	setBearerToken(req, a.token.AccessToken)
+	return nil
 }
@@ -4,13 +4,18 @@
 package proxy
 
 import (
+	"context"
+	"crypto/tls"
 	"net/http"
+	"os"
 	"strings"
 	"testing"
+	"time"
 
 	"io/ioutil"
 
 	"github.com/openfaas/faas-cli/config"
+	"github.com/openfaas/faas-cli/test"
 )
 
 func Test_SetAuth_AuthorizationHeader(t *testing.T) {
@@ -45,3 +50,155 @@ func Test_SetAuth_SkipAuthorization(t *testing.T) {
 		t.Errorf("got header %q, want none", header)
 	}
 }
+
+func Test_SetAuth_BearerToken(t *testing.T) {
+	config.DefaultDir, _ = ioutil.TempDir("", "faas-cli-auth-test")
+	config.DefaultFile = "authtest3.yml"
+	gatewayURL := "http://openfaas.test"
+	config.UpdateBearerAuthConfig(gatewayURL, "static-token")
+
+	req, _ := http.NewRequest("GET", gatewayURL, nil)
+	if err := SetAuth(req, gatewayURL); err != nil {
+		t.Fatal(err)
+	}
+	if header := req.Header.Get("Authorization"); header != "Bearer static-token" {
+		t.Errorf("got header %q, want %q", header, "Bearer static-token")
+	}
+}
+
+func Test_SetAuth_OAuth2(t *testing.T) {
+	config.DefaultDir, _ = ioutil.TempDir("", "faas-cli-auth-test")
+	config.DefaultFile = "authtest4.yml"
+	gatewayURL := "http://openfaas.test"
+
+	tokenServer := test.MockTokenServer(t, "faas-cli", "secret", 3600)
+	defer tokenServer.Close()
+
+	config.UpdateOAuth2AuthConfig(gatewayURL, config.OAuth2Config{
+		TokenURL:     tokenServer.URL + "/token",
+		ClientID:     "faas-cli",
+		ClientSecret: "secret",
+		Scopes:       []string{"openfaas", "deploy"},
+	})
+
+	setAuth := func() string {
+		req, _ := http.NewRequest("GET", gatewayURL, nil)
+		if err := SetAuth(req, gatewayURL); err != nil {
+			t.Fatal(err)
+		}
+		return req.Header.Get("Authorization")
+	}
+
+	if header := setAuth(); header != "Bearer token-1" || tokenServer.Scope != "openfaas deploy" {
+		t.Errorf("want a token fetched for the scopes, got header %q and scope %q", header, tokenServer.Scope)
+	}
+
+	// the token is cached in the config file until it is about to expire
+	if header := setAuth(); header != "Bearer token-1" || tokenServer.Issued != 1 {
+		t.Errorf("want the cached token, got header %q after %d tokens", header, tokenServer.Issued)
+	}
+	auth, _ := config.LookupGatewayAuth(gatewayURL)
+	if auth.Token != "token-1" || auth.OAuth2.ExpiryTime().Before(time.Now().Add(59*time.Minute)) {
+		t.Errorf("want the token saved with its expiry, got %q expiring %s", auth.Token, auth.OAuth2.Expiry)
+	}
+
+	config.UpdateOAuth2Token(gatewayURL, "token-1", time.Now().Add(tokenRefreshMargin/2))
+	if header := setAuth(); header != "Bearer token-2" {
+		t.Errorf("want a new token before the cached one expires, got header %q", header)
+	}
+}
+
+func Test_SetAuth_OAuth2_InvalidClient(t *testing.T) {
+	config.DefaultDir, _ = ioutil.TempDir("", "faas-cli-auth-test")
+	config.DefaultFile = "authtest5.yml"
+	gatewayURL := "http://openfaas.test"
+
+	tokenServer := test.MockTokenServer(t, "faas-cli", "secret", 3600)
+	defer tokenServer.Close()
+
+	config.UpdateOAuth2AuthConfig(gatewayURL, config.OAuth2Config{
+		TokenURL:     tokenServer.URL + "/token",
+		ClientID:     "faas-cli",
+		ClientSecret: "wrong",
+	})
+
+	req, _ := http.NewRequest("GET", gatewayURL, nil)
+	err := SetAuth(req, gatewayURL)
+	want := "unable to fetch a token from " + tokenServer.URL + "/token: invalid_client client authentication failed"
+	if err == nil || err.Error() != want {
+		t.Errorf("want %q, got %v", want, err)
+	}
+}
+
+func Test_SetAuth_OAuth2_GatewayTLS(t *testing.T) {
+	config.DefaultDir, _ = ioutil.TempDir("", "faas-cli-auth-test")
+	config.DefaultFile = "authtest6.yml"
+	gatewayURL := "https://openfaas.test"
+
+	certFile, keyFile := test.WriteCertificate(t, config.DefaultDir, "token")
+	defer os.RemoveAll(config.DefaultDir)
+	keyPair, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tokenServer := test.MockTLSTokenServer(t, keyPair, "faas-cli", "secret", 3600)
+	defer tokenServer.Close()
+
+	config.UpdateOAuth2AuthConfig(gatewayURL, config.OAuth2Config{
+		TokenURL:     tokenServer.URL + "/token",
+		ClientID:     "faas-cli",
+		ClientSecret: "secret",
+	})
+
+	// the token endpoint is not trusted without the CA saved for the gateway
+	req, _ := http.NewRequest("GET", gatewayURL, nil)
+	if err := SetAuth(req, gatewayURL); err == nil || !strings.Contains(err.Error(), "unable to fetch a token") {
+		t.Fatalf("want the certificate of the token endpoint rejected, got %v", err)
+	}
+
+	config.UpdateTLSConfig(gatewayURL, config.TLSConfig{CACert: certFile})
+	req, _ = http.NewRequest("GET", gatewayURL, nil)
+	if err := SetAuth(req, gatewayURL); err != nil {
+		t.Fatal(err)
+	}
+	if header := req.Header.Get("Authorization"); header != "Bearer token-1" {
+		t.Errorf("want a token fetched with the TLS settings of the gateway, got header %q", header)
+	}
+}
+
+func Test_ClientCredentialsAuth(t *testing.T) {
+	tokenServer := test.MockTokenServer(t, "faas-cli", "secret", 3600)
+	defer tokenServer.Close()
+
+	auth := &ClientCredentialsAuth{
+		Credentials: ClientCredentials{TokenURL: tokenServer.URL + "/token", ClientID: "faas-cli", ClientSecret: "secret"},
+	}
+
+	for i := 0; i < 3; i++ {
+		req, _ := http.NewRequest("GET", "http://openfaas.test", nil)
+		if err := auth.Set(req); err != nil {
+			t.Fatal(err)
+		}
+		if header := req.Header.Get("Authorization"); header != "Bearer token-1" {
+			t.Errorf("got header %q, want %q", header, "Bearer token-1")
+		}
+	}
+	if tokenServer.Issued != 1 {
+		t.Errorf("want the token kept in memory, got %d tokens", tokenServer.Issued)
+	}
+}
+
+func Test_ClientCredentials_TokenWithoutExpiry(t *testing.T) {
+	tokenServer := test.MockTokenServer(t, "faas-cli", "secret", 0)
+	defer tokenServer.Close()
+
+	credentials := ClientCredentials{TokenURL: tokenServer.URL + "/token", ClientID: "faas-cli", ClientSecret: "secret"}
+	token, err := credentials.Token(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !token.Expiry.IsZero() || !token.Valid() {
+		t.Errorf("want a token which does not expire, got %+v", token)
+	}
+}
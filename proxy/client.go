@@ -0,0 +1,252 @@
+// Copyright (c) OpenFaaS Project 2018. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package proxy
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/openfaas/faas-cli/config"
+	"github.com/openfaas/faas-cli/version"
+	"github.com/openfaas/faas/gateway/requests"
+)
+
+// Client calls the API of an OpenFaaS gateway. Errors are returned as
+// *ErrUnauthorized, *ErrNotFound or *StatusError when the gateway responds with
+// a status code other than 2xx, and as *ErrGatewayUnreachable when it could not
+// be reached. A malformed gateway URL is reported as a plain error, which is
+// never retried. Nothing is printed.
+type Client struct {
+	// GatewayURL is the base URL of the gateway, i.e. http://127.0.0.1:8080
+	GatewayURL string
+	// Transport sends the requests, when nil a copy of http.DefaultTransport
+	// using the TLS settings is made for the first request
+	Transport http.RoundTripper
+	// TLS settings used when Transport is nil, errors loading the certificates
+	// are returned by the first request
+	TLS config.TLSConfig
+	// Auth sets the credentials of each request, none are sent when nil
+	Auth ClientAuth
+	// UserAgent is sent with every request
+	UserAgent string
+	// Timeout limits each request, in addition to the deadline of its context
+	Timeout time.Duration
+	// Retry repeats calls which fail while the gateway is unavailable, calls
+	// are only repeated when that is safe
+	Retry RetryPolicy
+
+	transportOnce sync.Once
+	tlsTransport  http.RoundTripper
+	tlsErr        error
+}
+
+// NewClient returns a client for a gateway which uses the credentials and TLS
+// settings saved by faas-cli login, overridden by the global TLS flags
+func NewClient(gatewayURL string) *Client {
+	gatewayURL = strings.TrimRight(gatewayURL, "/")
+
+	// as with credentials, the saved settings are left out when the config
+	// file cannot be read, the global flags still apply
+	gatewayTLS, _ := config.GatewayTLSConfig(gatewayURL)
+
+	return &Client{
+		GatewayURL: gatewayURL,
+		TLS:        gatewayTLS,
+		Auth:       &ConfigAuth{Gateway: gatewayURL},
+		UserAgent:  fmt.Sprintf("%s/%s", version.UserAgent, version.BuildVersion()),
+		Retry:      DefaultRetryPolicy,
+	}
+}
+
+// DeployFunction creates a function, or updates an existing one when update is set,
+// and returns the status code of the gateway's response. A function is only
+// created again when the gateway could not be connected to, an update is also
+// retried when the gateway is unavailable.
+func (c *Client) DeployFunction(ctx context.Context, function requests.CreateFunctionRequest, update bool) (int, error) {
+	method, mode := http.MethodPost, retryOnConnect
+	if update {
+		method, mode = http.MethodPut, retryIdempotent
+	}
+
+	_, statusCode, err := c.doJSON(ctx, method, "/system/functions", function, mode)
+	return statusCode, err
+}
+
+// DeleteFunction removes a function and returns the status code of the gateway's
+// response, *ErrNotFound is returned when the function does not exist
+func (c *Client) DeleteFunction(ctx context.Context, functionName string) (int, error) {
+	_, statusCode, err := c.doJSON(ctx, http.MethodDelete, "/system/functions", requests.DeleteFunctionRequest{FunctionName: functionName}, retryIdempotent)
+	return statusCode, err
+}
+
+// ListFunctions returns the functions deployed on the gateway
+func (c *Client) ListFunctions(ctx context.Context) ([]requests.Function, error) {
+	var functions []requests.Function
+	if err := c.getJSON(ctx, "/system/functions", &functions); err != nil {
+		return nil, err
+	}
+	return functions, nil
+}
+
+// ListSecrets returns the secrets available on the gateway, values are never returned
+func (c *Client) ListSecrets(ctx context.Context) ([]Secret, error) {
+	var secrets []Secret
+	if err := c.getJSON(ctx, "/system/secrets", &secrets); err != nil {
+		return nil, err
+	}
+	return secrets, nil
+}
+
+// InvokeFunction calls a function with the body given, query holds key=value pairs
+// for the query string. Invocations are never retried.
+func (c *Client) InvokeFunction(ctx context.Context, functionName string, body []byte, contentType string, query []string) ([]byte, error) {
+	qs, err := buildQueryString(query)
+	if err != nil {
+		return nil, err
+	}
+
+	bytesOut, _, err := c.do(ctx, http.MethodPost, "/function/"+functionName+qs, body, contentType, retryNever)
+	return bytesOut, err
+}
+
+// getJSON decodes the response of a GET request into result
+func (c *Client) getJSON(ctx context.Context, path string, result interface{}) error {
+	bytesOut, _, err := c.do(ctx, http.MethodGet, path, nil, "", retryIdempotent)
+	if err != nil {
+		return err
+	}
+
+	if err := json.Unmarshal(bytesOut, result); err != nil {
+		return fmt.Errorf("cannot parse result from OpenFaaS on URL: %s\n%s", c.GatewayURL, err.Error())
+	}
+	return nil
+}
+
+// doJSON sends body encoded as JSON
+func (c *Client) doJSON(ctx context.Context, method string, path string, body interface{}, mode retryMode) ([]byte, int, error) {
+	reqBytes, err := json.Marshal(body)
+	if err != nil {
+		return nil, 0, err
+	}
+	return c.do(ctx, method, path, reqBytes, "application/json", mode)
+}
+
+// do sends a request to the gateway and returns the body of a 2xx response and
+// the status code, which is 0 when no response was received. Failed attempts
+// are repeated as the retry policy of the client and mode allow.
+func (c *Client) do(ctx context.Context, method string, path string, body []byte, contentType string, mode retryMode) ([]byte, int, error) {
+	var deadline time.Time
+	if c.Retry.Timeout > 0 {
+		deadline = time.Now().Add(c.Retry.Timeout)
+	}
+
+	attempts := 1
+	if mode != retryNever && c.Retry.Retries > 0 {
+		attempts += c.Retry.Retries
+	}
+
+	for attempt := 1; ; attempt++ {
+		bytesOut, statusCode, err := c.send(ctx, method, path, body, contentType)
+		if err == nil {
+			debugPrint(fmt.Sprintf("%s %s: attempt %d of %d succeeded with status %d", method, path, attempt, attempts, statusCode))
+			return bytesOut, statusCode, nil
+		}
+		debugPrint(fmt.Sprintf("%s %s: attempt %d of %d failed: %s", method, path, attempt, attempts, err))
+
+		if attempt >= attempts || !mode.shouldRetry(err) {
+			return bytesOut, statusCode, err
+		}
+
+		wait := c.Retry.backoff(attempt)
+		if !deadline.IsZero() && time.Now().Add(wait).After(deadline) {
+			debugPrint(fmt.Sprintf("%s %s: not retrying, the retry timeout of %s would be exceeded", method, path, c.Retry.Timeout))
+			return bytesOut, statusCode, err
+		}
+		debugPrint(fmt.Sprintf("%s %s: retrying in %s", method, path, wait))
+
+		select {
+		case <-ctx.Done():
+			return nil, 0, ctx.Err()
+		case <-time.After(wait):
+		}
+	}
+}
+
+// send makes a single attempt at a request
+func (c *Client) send(ctx context.Context, method string, path string, body []byte, contentType string) ([]byte, int, error) {
+	gatewayURL := strings.TrimRight(c.GatewayURL, "/")
+
+	var reqBody io.Reader
+	if body != nil {
+		reqBody = bytes.NewReader(body)
+	}
+
+	req, err := http.NewRequest(method, gatewayURL+path, reqBody)
+	if err != nil {
+		// a malformed URL is not retried, it would fail the same way every time
+		return nil, 0, fmt.Errorf("cannot connect to OpenFaaS on URL: %s, %s", gatewayURL, err)
+	}
+	req = req.WithContext(ctx)
+
+	if len(contentType) > 0 {
+		req.Header.Set("Content-Type", contentType)
+	}
+	if len(c.UserAgent) > 0 {
+		req.Header.Set("User-Agent", c.UserAgent)
+	}
+	if c.Auth != nil {
+		if err := c.Auth.Set(req); err != nil {
+			return nil, 0, err
+		}
+	}
+
+	transport, err := c.transport()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	client := http.Client{Transport: transport, Timeout: c.Timeout}
+	res, err := client.Do(req)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, 0, ctx.Err()
+		}
+		return nil, 0, &ErrGatewayUnreachable{URL: gatewayURL, Err: err}
+	}
+	defer res.Body.Close()
+
+	bytesOut, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return nil, res.StatusCode, fmt.Errorf("cannot read result from OpenFaaS on URL: %s %s", gatewayURL, err)
+	}
+
+	if res.StatusCode < http.StatusOK || res.StatusCode >= http.StatusMultipleChoices {
+		return nil, res.StatusCode, newStatusError(res.StatusCode, bytesOut)
+	}
+	return bytesOut, res.StatusCode, nil
+}
+
+// transport returns Transport, or the transport made from the TLS settings of
+// the client the first time it is needed
+func (c *Client) transport() (http.RoundTripper, error) {
+	if c.Transport != nil {
+		return c.Transport, nil
+	}
+
+	c.transportOnce.Do(func() {
+		var tlsConfig *tls.Config
+		tlsConfig, c.tlsErr = c.TLS.ClientConfig()
+		c.tlsTransport = makeTransport(tlsConfig)
+	})
+	return c.tlsTransport, c.tlsErr
+}
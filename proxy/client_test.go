@@ -0,0 +1,183 @@
+// Copyright (c) OpenFaaS Project 2018. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package proxy
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/openfaas/faas-cli/config"
+	"github.com/openfaas/faas-cli/test"
+	"github.com/openfaas/faas/gateway/requests"
+)
+
+func Test_Client_DeployFunction(t *testing.T) {
+	var method, userAgent, username string
+	var deployed requests.CreateFunctionRequest
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		method = r.Method
+		userAgent = r.Header.Get("User-Agent")
+		username, _, _ = r.BasicAuth()
+		if err := json.NewDecoder(r.Body).Decode(&deployed); err != nil {
+			t.Fatal(err)
+		}
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer s.Close()
+
+	client := NewClient(s.URL + "/")
+	client.Auth = &BasicAuth{Username: "admin", Password: "secret"}
+	client.UserAgent = "faas-cli-test"
+
+	statusCode, err := client.DeployFunction(context.Background(), requests.CreateFunctionRequest{Service: "echo", Image: "echo:latest"}, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if statusCode != http.StatusAccepted {
+		t.Errorf("want status %d, got %d", http.StatusAccepted, statusCode)
+	}
+
+	if method != http.MethodPut {
+		t.Errorf("want an update sent with PUT, got %s", method)
+	}
+	if deployed.Service != "echo" || deployed.Image != "echo:latest" {
+		t.Errorf("unexpected function deployed: %+v", deployed)
+	}
+	if userAgent != "faas-cli-test" || username != "admin" {
+		t.Errorf("want the user agent and credentials of the client, got %q and %q", userAgent, username)
+	}
+}
+
+func Test_Client_TypedErrors(t *testing.T) {
+	tests := []struct {
+		statusCode int
+		check      func(err error) bool
+	}{
+		{statusCode: http.StatusUnauthorized, check: func(err error) bool { _, ok := err.(*ErrUnauthorized); return ok }},
+		{statusCode: http.StatusNotFound, check: func(err error) bool { _, ok := err.(*ErrNotFound); return ok }},
+		{statusCode: http.StatusInternalServerError, check: func(err error) bool { _, ok := err.(*StatusError); return ok }},
+	}
+
+	for _, test := range tests {
+		t.Run(http.StatusText(test.statusCode), func(t *testing.T) {
+			s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(test.statusCode)
+				w.Write([]byte("gateway says no"))
+			}))
+			defer s.Close()
+
+			_, err := NewClient(s.URL).ListFunctions(context.Background())
+			if !test.check(err) {
+				t.Fatalf("unexpected error type %T: %v", err, err)
+			}
+
+			statusErr, ok := AsStatusError(err)
+			if !ok || statusErr.StatusCode != test.statusCode || statusErr.Body != "gateway says no" {
+				t.Errorf("want status %d and the body of the response, got %+v", test.statusCode, statusErr)
+			}
+		})
+	}
+}
+
+func Test_Client_GatewayUnreachable(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	gatewayURL := s.URL
+	s.Close()
+
+	_, err := NewClient(gatewayURL).ListSecrets(context.Background())
+	unreachable, ok := err.(*ErrGatewayUnreachable)
+	if !ok {
+		t.Fatalf("want *ErrGatewayUnreachable, got %T: %v", err, err)
+	}
+	if unreachable.URL != gatewayURL || !strings.HasPrefix(err.Error(), "cannot connect to OpenFaaS on URL: "+gatewayURL) {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func Test_Client_ContextCancelled(t *testing.T) {
+	release := make(chan struct{})
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer s.Close()
+	defer close(release)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	_, err := NewClient(s.URL).InvokeFunction(ctx, "slow", []byte("data"), "text/plain", nil)
+	if err != context.DeadlineExceeded {
+		t.Errorf("want the deadline of the context, got %T: %v", err, err)
+	}
+}
+
+func Test_Client_TLS(t *testing.T) {
+	dir, err := ioutil.TempDir("", "faas-cli-tls-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	defer func() { config.DefaultTLSConfig = config.TLSConfig{} }()
+
+	serverCert, serverKey := test.WriteCertificate(t, dir, "gateway")
+	clientCert, clientKey := test.WriteCertificate(t, dir, "client")
+
+	// the gateway requires a client certificate
+	keyPair, err := tls.LoadX509KeyPair(serverCert, serverKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	clientCAs := x509.NewCertPool()
+	clientCA, _ := ioutil.ReadFile(clientCert)
+	clientCAs.AppendCertsFromPEM(clientCA)
+
+	s := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("[]"))
+	}))
+	s.TLS = &tls.Config{Certificates: []tls.Certificate{keyPair}, ClientAuth: tls.RequireAndVerifyClientCert, ClientCAs: clientCAs}
+	s.StartTLS()
+	defer s.Close()
+
+	tests := []struct {
+		name      string
+		tlsConfig config.TLSConfig
+		wantErr   bool
+	}{
+		{name: "system CAs", tlsConfig: config.TLSConfig{}, wantErr: true},
+		{name: "no client certificate", tlsConfig: config.TLSConfig{CACert: serverCert}, wantErr: true},
+		{name: "CA and client certificate", tlsConfig: config.TLSConfig{CACert: serverCert, ClientCert: clientCert, ClientKey: clientKey}},
+		{name: "no verification", tlsConfig: config.TLSConfig{InsecureSkipVerify: true, ClientCert: clientCert, ClientKey: clientKey}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			config.DefaultTLSConfig = test.tlsConfig
+
+			_, err := NewClient(s.URL).ListFunctions(context.Background())
+			if test.wantErr {
+				if _, ok := err.(*ErrGatewayUnreachable); !ok {
+					t.Errorf("want *ErrGatewayUnreachable, got %T: %v", err, err)
+				}
+			} else if err != nil {
+				t.Errorf("want the functions listed, got %v", err)
+			}
+		})
+	}
+
+	// the certificates are loaded by the first request
+	config.DefaultTLSConfig = config.TLSConfig{ClientCert: clientCert}
+	_, err = NewClient(s.URL).ListFunctions(context.Background())
+	if err == nil || err.Error() != "a client certificate and its key must be given together" {
+		t.Errorf("want an error for a client certificate without its key, got %v", err)
+	}
+}
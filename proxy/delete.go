@@ -4,59 +4,29 @@
 package proxy
 
 import (
-	"bytes"
-	"encoding/json"
+	"context"
 	"fmt"
-	"io/ioutil"
-	"net/http"
 	"strings"
-
-	"github.com/openfaas/faas/gateway/requests"
 )
 
-// DeleteFunction delete a function from the FaaS server
-func DeleteFunction(gateway string, functionName string) error {
+// DeleteFunction delete a function from the FaaS server. A function which does
+// not exist is reported with *ErrNotFound.
+func DeleteFunction(gateway string, functionName string) (FunctionResult, error) {
 	gateway = strings.TrimRight(gateway, "/")
-	delReq := requests.DeleteFunctionRequest{FunctionName: functionName}
-	reqBytes, _ := json.Marshal(&delReq)
-	reader := bytes.NewReader(reqBytes)
-
-	c := http.Client{}
-	req, err := http.NewRequest("DELETE", gateway+"/system/functions", reader)
-	if err != nil {
-		fmt.Println(err)
-		return err
-	}
-	req.Header.Set("Content-Type", "application/json")
-	SetAuth(req, gateway)
-	delRes, delErr := c.Do(req)
-
-	if delErr != nil {
-		fmt.Printf("Error removing existing function: %s, gateway=%s, functionName=%s\n", delErr.Error(), gateway, functionName)
-		return delErr
+	result := FunctionResult{
+		FunctionName: functionName,
+		URL:          fmt.Sprintf("%s/function/%s", gateway, functionName),
 	}
 
-	if delRes.Body != nil {
-		defer delRes.Body.Close()
-	}
-
-	switch delRes.StatusCode {
-	case http.StatusOK, http.StatusCreated, http.StatusAccepted:
-		fmt.Println("Removing old function.")
-	case http.StatusNotFound:
-		fmt.Println("No existing function to remove")
-	case http.StatusUnauthorized:
-		fmt.Println("unauthorized access, run \"faas-cli login\" to setup authentication for this server")
+	var err error
+	result.StatusCode, err = NewClient(gateway).DeleteFunction(context.Background(), functionName)
+	switch err.(type) {
+	case nil:
+		result.Message = "Removed"
+	case *ErrNotFound:
+		result.Message = "No existing function to remove"
 	default:
-		var bodyReadErr error
-		bytesOut, bodyReadErr := ioutil.ReadAll(delRes.Body)
-		if bodyReadErr != nil {
-			err = bodyReadErr
-		} else {
-			err = fmt.Errorf("server returned unexpected status code %d %s", delRes.StatusCode, string(bytesOut))
-			fmt.Println("Server returned unexpected status code", delRes.StatusCode, string(bytesOut))
-		}
+		result.Message = err.Error()
 	}
-
-	return err
+	return result, err
 }
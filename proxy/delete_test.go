@@ -18,13 +18,19 @@ func Test_DeleteFunction(t *testing.T) {
 	s := test.MockHttpServerStatus(t, http.StatusOK)
 	defer s.Close()
 
-	stdout := test.CaptureStdout(func() {
-		DeleteFunction(s.URL, "function-to-delete")
-	})
+	result, err := DeleteFunction(s.URL, "function-to-delete")
+	if err != nil {
+		t.Fatalf("Error returned: %s", err)
+	}
 
-	r := regexp.MustCompile(`(?m:Removing old function.)`)
-	if !r.MatchString(stdout) {
-		t.Fatalf("Want: %s, got: %s", "Removing old function", stdout)
+	expected := FunctionResult{
+		FunctionName: "function-to-delete",
+		URL:          s.URL + "/function/function-to-delete",
+		StatusCode:   http.StatusOK,
+		Message:      "Removed",
+	}
+	if result != expected {
+		t.Fatalf("want %+v, got %+v", expected, result)
 	}
 }
 
@@ -32,13 +38,14 @@ func Test_DeleteFunction_404(t *testing.T) {
 	s := test.MockHttpServerStatus(t, http.StatusNotFound)
 	defer s.Close()
 
-	stdout := test.CaptureStdout(func() {
-		DeleteFunction(s.URL, "function-to-delete")
-	})
+	result, err := DeleteFunction(s.URL, "function-to-delete")
+	if _, ok := err.(*ErrNotFound); !ok {
+		t.Fatalf("want *ErrNotFound, got %T: %v", err, err)
+	}
 
 	r := regexp.MustCompile(`(?m:No existing function to remove)`)
-	if !r.MatchString(stdout) {
-		t.Fatalf("Want: %s, got: %s", "No existing function to remove", stdout)
+	if !r.MatchString(result.Message) {
+		t.Fatalf("Want: %s, got: %s", "No existing function to remove", result.Message)
 	}
 }
 
@@ -46,26 +53,28 @@ func Test_DeleteFunction_Not2xxAnd404(t *testing.T) {
 	s := test.MockHttpServerStatus(t, http.StatusInternalServerError)
 	defer s.Close()
 
-	stdout := test.CaptureStdout(func() {
-		DeleteFunction(s.URL, "function-to-delete")
-	})
+	result, err := DeleteFunction(s.URL, "function-to-delete")
+	if err == nil {
+		t.Fatalf("Error was not returned")
+	}
 
-	r := regexp.MustCompile(`(?m:Server returned unexpected status code)`)
-	if !r.MatchString(stdout) {
-		t.Fatalf("Output not matched: %s", stdout)
+	r := regexp.MustCompile(`(?m:server returned unexpected status code: 500)`)
+	if !r.MatchString(result.Message) || result.StatusCode != http.StatusInternalServerError {
+		t.Fatalf("Result not matched: %+v", result)
 	}
 }
 
 func Test_DeleteFunction_MissingURLPrefix(t *testing.T) {
 	url := "127.0.0.1:8080"
 
-	stdout := test.CaptureStdout(func() {
-		DeleteFunction(url, "function-to-delete")
-	})
+	_, err := DeleteFunction(url, "function-to-delete")
+	if err == nil {
+		t.Fatalf("Error was not returned")
+	}
 
 	expectedErrMsg := "first path segment in URL cannot contain colon"
 	r := regexp.MustCompile(fmt.Sprintf("(?m:%s)", expectedErrMsg))
-	if !r.MatchString(stdout) {
-		t.Fatalf("Want: %s\nGot: %s", expectedErrMsg, stdout)
+	if !r.MatchString(err.Error()) {
+		t.Fatalf("Want: %s\nGot: %s", expectedErrMsg, err)
 	}
 }
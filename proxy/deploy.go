@@ -4,11 +4,8 @@
 package proxy
 
 import (
-	"bytes"
-	"encoding/json"
+	"context"
 	"fmt"
-	"io/ioutil"
-	"net/http"
 	"strings"
 	"time"
 
@@ -21,10 +18,22 @@ type FunctionResourceRequest struct {
 	Requests *stack.FunctionResources
 }
 
-// DeployFunction call FaaS server to deploy a new function
+// FunctionResult is the outcome of deploying or removing a function
+type FunctionResult struct {
+	FunctionName string
+	// URL the function is invoked on
+	URL string
+	// StatusCode of the gateway's response, 0 when no response was received
+	StatusCode int
+	// Message describes the outcome
+	Message string
+}
+
+// DeployFunction call FaaS server to deploy a new function. An error is returned
+// along with the result when the function could not be deployed.
 func DeployFunction(fprocess string, gateway string, functionName string, image string,
 	language string, replace bool, envVars map[string]string, network string,
-	constraints []string, update bool, secrets []string, labels map[string]string, functionResourceRequest1 FunctionResourceRequest) {
+	constraints []string, update bool, secrets []string, labels map[string]string, functionResourceRequest1 FunctionResourceRequest) (FunctionResult, error) {
 
 	// Need to alter Gateway to allow nil/empty string as fprocess, to avoid this repetition.
 	var fprocessTemplate string
@@ -33,9 +42,19 @@ func DeployFunction(fprocess string, gateway string, functionName string, image
 	}
 
 	gateway = strings.TrimRight(gateway, "/")
+	result := FunctionResult{
+		FunctionName: functionName,
+		URL:          fmt.Sprintf("%s/function/%s", gateway, functionName),
+	}
 
 	if replace {
-		DeleteFunction(gateway, functionName)
+		if deleteResult, err := DeleteFunction(gateway, functionName); err != nil {
+			if _, notFound := err.(*ErrNotFound); !notFound {
+				result.StatusCode = deleteResult.StatusCode
+				result.Message = fmt.Sprintf("unable to remove the existing function: %s", err)
+				return result, err
+			}
+		}
 	}
 
 	req := requests.CreateFunctionRequest{
@@ -49,68 +68,33 @@ func DeployFunction(fprocess string, gateway string, functionName string, image
 		Labels:      &labels,
 	}
 
-	if functionResourceRequest1.Limits != nil && len(functionResourceRequest1.Limits.Memory) > 0 {
-		req.Limits = &requests.FunctionResources{
-			Memory: functionResourceRequest1.Limits.Memory,
-		}
-	}
+	req.Limits = toRequestResources(functionResourceRequest1.Limits)
+	req.Requests = toRequestResources(functionResourceRequest1.Requests)
 
-	if functionResourceRequest1.Requests != nil && len(functionResourceRequest1.Requests.Memory) > 0 {
-		req.Requests = &requests.FunctionResources{
-			Memory: functionResourceRequest1.Requests.Memory,
-		}
-	}
-
-	reqBytes, _ := json.Marshal(&req)
-	reader := bytes.NewReader(reqBytes)
-	var request *http.Request
-
-	timeout := 60 * time.Second
-	client := MakeHTTPClient(&timeout)
-
-	method := http.MethodPost
-	// "application/json"
-	if update {
-		method = http.MethodPut
-	}
+	client := NewClient(gateway)
+	client.Timeout = 60 * time.Second
 
 	var err error
-	request, err = http.NewRequest(method, gateway+"/system/functions", reader)
-	SetAuth(request, gateway)
-	if err != nil {
-		fmt.Println(err)
-		return
-	}
-
-	res, err := client.Do(request)
-	if err != nil {
-		fmt.Println("Is FaaS deployed? Do you need to specify the --gateway flag?")
-		fmt.Println(err)
-		return
+	result.StatusCode, err = client.DeployFunction(context.Background(), req, update)
+	switch {
+	case err != nil:
+		result.Message = err.Error()
+	case update:
+		result.Message = "Updated"
+	default:
+		result.Message = "Deployed"
 	}
+	return result, err
+}
 
-	if res.Body != nil {
-		defer res.Body.Close()
+// toRequestResources converts stack resources for the gateway API, returning nil when neither memory nor CPU is set
+func toRequestResources(resources *stack.FunctionResources) *requests.FunctionResources {
+	if resources == nil || (len(resources.Memory) == 0 && len(resources.CPU) == 0) {
+		return nil
 	}
 
-	switch res.StatusCode {
-	case http.StatusOK, http.StatusCreated, http.StatusAccepted:
-		if update {
-			fmt.Println("Updated.")
-		} else {
-			fmt.Println("Deployed.")
-		}
-
-		deployedURL := fmt.Sprintf("URL: %s/function/%s\n", gateway, functionName)
-		fmt.Println(deployedURL)
-	case http.StatusUnauthorized:
-		fmt.Println("unauthorized access, run \"faas-cli login\" to setup authentication for this server")
-	default:
-		bytesOut, err := ioutil.ReadAll(res.Body)
-		if err == nil {
-			fmt.Printf("Unexpected status: %d, message: %s\n", res.StatusCode, string(bytesOut))
-		}
+	return &requests.FunctionResources{
+		Memory: resources.Memory,
+		CPU:    resources.CPU,
 	}
-
-	fmt.Println(res.Status)
 }
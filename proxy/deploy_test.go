@@ -4,14 +4,19 @@
 package proxy
 
 import (
+	"encoding/json"
 	"fmt"
 	"net/http"
+	"net/http/httptest"
 
 	"testing"
 
 	"regexp"
+	"strings"
 
+	"github.com/openfaas/faas-cli/stack"
 	"github.com/openfaas/faas-cli/test"
+	"github.com/openfaas/faas/gateway/requests"
 )
 
 func Test_DeployFunction(t *testing.T) {
@@ -22,27 +27,33 @@ func Test_DeployFunction(t *testing.T) {
 	)
 	defer s.Close()
 
-	stdout := test.CaptureStdout(func() {
-		DeployFunction(
-			"fproces",
-			s.URL,
-			"function",
-			"image",
-			"language",
-			true,
-			nil,
-			"network",
-			[]string{},
-			false,
-			[]string{},
-			map[string]string{},
-			FunctionResourceRequest{},
-		)
-	})
-
-	r := regexp.MustCompile(`(?m:Deployed.)`)
-	if !r.MatchString(stdout) {
-		t.Fatalf("Output not matched: %s", stdout)
+	result, err := DeployFunction(
+		"fproces",
+		s.URL,
+		"function",
+		"image",
+		"language",
+		true,
+		nil,
+		"network",
+		[]string{},
+		false,
+		[]string{},
+		map[string]string{},
+		FunctionResourceRequest{},
+	)
+	if err != nil {
+		t.Fatalf("Error returned: %s", err)
+	}
+
+	expected := FunctionResult{
+		FunctionName: "function",
+		URL:          s.URL + "/function/function",
+		StatusCode:   http.StatusOK,
+		Message:      "Deployed",
+	}
+	if result != expected {
+		t.Fatalf("want %+v, got %+v", expected, result)
 	}
 }
 
@@ -54,54 +65,130 @@ func Test_DeployFunction_Not2xx(t *testing.T) {
 	)
 	defer s.Close()
 
-	stdout := test.CaptureStdout(func() {
-		DeployFunction(
-			"fproces",
-			s.URL,
-			"function",
-			"image",
-			"language",
-			true,
-			nil,
-			"network",
-			[]string{},
-			false,
-			[]string{},
-			map[string]string{},
-			FunctionResourceRequest{},
-		)
-	})
-
-	r := regexp.MustCompile(`(?m:Unexpected status: 404)`)
-	if !r.MatchString(stdout) {
-		t.Fatalf("Output not matched: %s", stdout)
+	result, err := DeployFunction(
+		"fproces",
+		s.URL,
+		"function",
+		"image",
+		"language",
+		true,
+		nil,
+		"network",
+		[]string{},
+		false,
+		[]string{},
+		map[string]string{},
+		FunctionResourceRequest{},
+	)
+
+	if _, ok := err.(*ErrNotFound); !ok {
+		t.Fatalf("want *ErrNotFound, got %T: %v", err, err)
+	}
+	if result.StatusCode != http.StatusNotFound {
+		t.Fatalf("want status 404, got %d", result.StatusCode)
 	}
 }
 
 func Test_DeployFunction_MissingURLPrefix(t *testing.T) {
 	url := "127.0.0.1:8080"
 
-	stdout := test.CaptureStdout(func() {
-		DeployFunction(
-			"fprocess",
-			url,
-			"function",
-			"image",
-			"language",
-			false,
-			nil,
-			"network",
-			[]string{},
-			false,
-			[]string{},
-			map[string]string{},
-			FunctionResourceRequest{},
-		)
-	})
+	result, err := DeployFunction(
+		"fprocess",
+		url,
+		"function",
+		"image",
+		"language",
+		false,
+		nil,
+		"network",
+		[]string{},
+		false,
+		[]string{},
+		map[string]string{},
+		FunctionResourceRequest{},
+	)
+
+	if err == nil {
+		t.Fatalf("Error was not returned")
+	}
+	if _, ok := err.(*ErrGatewayUnreachable); ok {
+		t.Fatalf("want a malformed URL not reported as an unreachable gateway, got %v", err)
+	}
 
 	expectedErrMsg := "first path segment in URL cannot contain colon"
 	r := regexp.MustCompile(fmt.Sprintf("(?m:%s)", expectedErrMsg))
-	if !r.MatchString(stdout) {
-		t.Fatalf("Want: %s\nGot: %s", expectedErrMsg, stdout)
+	if !r.MatchString(result.Message) {
+		t.Fatalf("Want: %s\nGot: %s", expectedErrMsg, result.Message)
+	}
+}
+
+func Test_DeployFunction_Resources(t *testing.T) {
+	var deployed requests.CreateFunctionRequest
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(&deployed); err != nil {
+			t.Fatal(err)
+		}
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer s.Close()
+
+	_, err := DeployFunction(
+		"fprocess",
+		s.URL,
+		"function",
+		"image",
+		"language",
+		false,
+		nil,
+		"network",
+		[]string{},
+		false,
+		[]string{},
+		map[string]string{},
+		FunctionResourceRequest{
+			Limits:   &stack.FunctionResources{Memory: "128m", CPU: "500m"},
+			Requests: &stack.FunctionResources{CPU: "100m"},
+		},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if deployed.Limits == nil || deployed.Limits.Memory != "128m" || deployed.Limits.CPU != "500m" {
+		t.Errorf("want limits memory=128m cpu=500m, got %+v", deployed.Limits)
+	}
+	if deployed.Requests == nil || deployed.Requests.Memory != "" || deployed.Requests.CPU != "100m" {
+		t.Errorf("want requests cpu=100m, got %+v", deployed.Requests)
+	}
+}
+
+func Test_DeployFunction_ReplaceFails(t *testing.T) {
+	s := test.MockHttpServerStatus(
+		t,
+		http.StatusInternalServerError, // DeleteFunction
+	)
+	defer s.Close()
+
+	result, err := DeployFunction(
+		"fprocess",
+		s.URL,
+		"function",
+		"image",
+		"language",
+		true,
+		nil,
+		"network",
+		[]string{},
+		false,
+		[]string{},
+		map[string]string{},
+		FunctionResourceRequest{},
+	)
+
+	if _, ok := err.(*StatusError); !ok {
+		t.Fatalf("want *StatusError, got %T: %v", err, err)
+	}
+	if result.StatusCode != http.StatusInternalServerError || !strings.HasPrefix(result.Message, "unable to remove the existing function") {
+		t.Fatalf("unexpected result: %+v", result)
 	}
 }
@@ -0,0 +1,69 @@
+// Copyright (c) OpenFaaS Project 2018. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package proxy
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// StatusError is a response from the gateway with a status code other than 2xx
+type StatusError struct {
+	StatusCode int
+	Body       string
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("server returned unexpected status code: %d - %s", e.StatusCode, e.Body)
+}
+
+// ErrUnauthorized is returned when the gateway rejects the credentials of a request
+type ErrUnauthorized struct {
+	StatusError
+}
+
+func (e *ErrUnauthorized) Error() string {
+	return "unauthorized access, run \"faas-cli login\" to setup authentication for this server"
+}
+
+// ErrNotFound is returned when the function or endpoint requested does not exist on the gateway
+type ErrNotFound struct {
+	StatusError
+}
+
+// ErrGatewayUnreachable is returned when no response could be read from the gateway
+type ErrGatewayUnreachable struct {
+	URL string
+	Err error
+}
+
+func (e *ErrGatewayUnreachable) Error() string {
+	return fmt.Sprintf("cannot connect to OpenFaaS on URL: %s, %s", e.URL, e.Err)
+}
+
+// newStatusError returns the typed error for a response with a status code other than 2xx
+func newStatusError(statusCode int, body []byte) error {
+	statusErr := StatusError{StatusCode: statusCode, Body: string(body)}
+	switch statusCode {
+	case http.StatusUnauthorized:
+		return &ErrUnauthorized{statusErr}
+	case http.StatusNotFound:
+		return &ErrNotFound{statusErr}
+	}
+	return &statusErr
+}
+
+// AsStatusError returns the status code and body of an error response from the
+// gateway, whichever type of error it was returned as
+func AsStatusError(err error) (*StatusError, bool) {
+	switch statusErr := err.(type) {
+	case *StatusError:
+		return statusErr, true
+	case *ErrUnauthorized:
+		return &statusErr.StatusError, true
+	case *ErrNotFound:
+		return &statusErr.StatusError, true
+	}
+	return nil, false
+}
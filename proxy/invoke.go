@@ -4,71 +4,17 @@
 package proxy
 
 import (
-	"bytes"
-
+	"context"
 	"fmt"
-	"io/ioutil"
-	"net/http"
 	"strings"
-	"time"
 )
 
 // InvokeFunction a function
 func InvokeFunction(gateway string, name string, bytesIn *[]byte, contentType string, query []string) (*[]byte, error) {
-	var resBytes []byte
-
-	gateway = strings.TrimRight(gateway, "/")
-
-	reader := bytes.NewReader(*bytesIn)
-
-	var timeout *time.Duration
-	client := MakeHTTPClient(timeout)
-
-	qs, qsErr := buildQueryString(query)
-	if qsErr != nil {
-		return nil, qsErr
-	}
-
-	gatewayURL := gateway + "/function/" + name + qs
-
-	req, err := http.NewRequest(http.MethodPost, gatewayURL, reader)
-	if err != nil {
-		fmt.Println()
-		fmt.Println(err)
-		return nil, fmt.Errorf("cannot connect to OpenFaaS on URL: %s", gateway)
-	}
-
-	req.Header.Add("Content-Type", contentType)
-	SetAuth(req, gateway)
-
-	res, err := client.Do(req)
-
+	resBytes, err := NewClient(gateway).InvokeFunction(context.Background(), name, *bytesIn, contentType, query)
 	if err != nil {
-		fmt.Println()
-		fmt.Println(err)
-		return nil, fmt.Errorf("cannot connect to OpenFaaS on URL: %s", gateway)
-	}
-
-	if res.Body != nil {
-		defer res.Body.Close()
+		return nil, err
 	}
-
-	switch res.StatusCode {
-	case http.StatusOK:
-		var readErr error
-		resBytes, readErr = ioutil.ReadAll(res.Body)
-		if readErr != nil {
-			return nil, fmt.Errorf("cannot read result from OpenFaaS on URL: %s %s", gateway, readErr)
-		}
-	case http.StatusUnauthorized:
-		return nil, fmt.Errorf("unauthorized access, run \"faas-cli login\" to setup authentication for this server")
-	default:
-		bytesOut, err := ioutil.ReadAll(res.Body)
-		if err == nil {
-			return nil, fmt.Errorf("Server returned unexpected status code: %d - %s", res.StatusCode, string(bytesOut))
-		}
-	}
-
 	return &resBytes, nil
 }
 
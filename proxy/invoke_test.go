@@ -49,7 +49,11 @@ func Test_InvokeFunction_Not2xx(t *testing.T) {
 		t.Fatalf("Error was not returned")
 	}
 
-	r := regexp.MustCompile(`(?m:Server returned unexpected status code)`)
+	if _, ok := err.(*ErrNotFound); !ok {
+		t.Fatalf("want *ErrNotFound, got %T", err)
+	}
+
+	r := regexp.MustCompile(`(?m:server returned unexpected status code: 404)`)
 	if !r.MatchString(err.Error()) {
 		t.Fatalf("Error not matched: %s", err)
 	}
@@ -4,12 +4,7 @@
 package proxy
 
 import (
-	"encoding/json"
-
-	"fmt"
-	"io/ioutil"
-	"net/http"
-	"strings"
+	"context"
 	"time"
 
 	"github.com/openfaas/faas/gateway/requests"
@@ -17,46 +12,8 @@ import (
 
 // ListFunctions list deployed functions
 func ListFunctions(gateway string) ([]requests.Function, error) {
-	var results []requests.Function
-
-	gateway = strings.TrimRight(gateway, "/")
-
-	timeout := 60 * time.Second
-	client := MakeHTTPClient(&timeout)
-
-	getRequest, err := http.NewRequest(http.MethodGet, gateway+"/system/functions", nil)
-	SetAuth(getRequest, gateway)
-	if err != nil {
-		return nil, fmt.Errorf("cannot connect to OpenFaaS on URL: %s", gateway)
-	}
-
-	res, err := client.Do(getRequest)
-	if err != nil {
-		return nil, fmt.Errorf("cannot connect to OpenFaaS on URL: %s", gateway)
-	}
-
-	if res.Body != nil {
-		defer res.Body.Close()
-	}
-
-	switch res.StatusCode {
-	case http.StatusOK:
+	client := NewClient(gateway)
+	client.Timeout = 60 * time.Second
 
-		bytesOut, err := ioutil.ReadAll(res.Body)
-		if err != nil {
-			return nil, fmt.Errorf("cannot read result from OpenFaaS on URL: %s", gateway)
-		}
-		jsonErr := json.Unmarshal(bytesOut, &results)
-		if jsonErr != nil {
-			return nil, fmt.Errorf("cannot parse result from OpenFaaS on URL: %s\n%s", gateway, jsonErr.Error())
-		}
-	case http.StatusUnauthorized:
-		return nil, fmt.Errorf("unauthorized access, run \"faas-cli login\" to setup authentication for this server")
-	default:
-		bytesOut, err := ioutil.ReadAll(res.Body)
-		if err == nil {
-			return nil, fmt.Errorf("server returned unexpected status code: %d - %s", res.StatusCode, string(bytesOut))
-		}
-	}
-	return results, nil
+	return client.ListFunctions(context.Background())
 }
@@ -0,0 +1,116 @@
+// Copyright (c) OpenFaaS Project 2018. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package proxy
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/openfaas/faas-cli/config"
+)
+
+// tokenRefreshMargin is how long before its expiry a token is replaced, so it
+// does not expire while a request is on its way
+const tokenRefreshMargin = 30 * time.Second
+
+// ClientCredentials fetches tokens with the OAuth2 client credentials grant
+type ClientCredentials struct {
+	TokenURL     string
+	ClientID     string
+	ClientSecret string
+	Scopes       []string
+	// TLS is used to reach the token endpoint, usually the settings of the gateway
+	TLS config.TLSConfig
+}
+
+// Token is an access token fetched from a token endpoint
+type Token struct {
+	AccessToken string
+	// Expiry is the zero time when the token does not expire
+	Expiry time.Time
+}
+
+// Valid tells whether the token can still be used, it is refreshed some time
+// before it expires
+func (t Token) Valid() bool {
+	return len(t.AccessToken) > 0 && (t.Expiry.IsZero() || time.Now().Add(tokenRefreshMargin).Before(t.Expiry))
+}
+
+// tokenResponse is a successful response from a token endpoint, RFC 6749 5.1
+type tokenResponse struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	ExpiresIn   int64  `json:"expires_in"`
+}
+
+// tokenErrorResponse is an error response from a token endpoint, RFC 6749 5.2
+type tokenErrorResponse struct {
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description"`
+}
+
+// Token fetches a new access token
+func (c *ClientCredentials) Token(ctx context.Context) (Token, error) {
+	form := url.Values{"grant_type": {"client_credentials"}}
+	if len(c.Scopes) > 0 {
+		form.Set("scope", strings.Join(c.Scopes, " "))
+	}
+
+	req, err := http.NewRequest(http.MethodPost, c.TokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return Token{}, err
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.SetBasicAuth(url.QueryEscape(c.ClientID), url.QueryEscape(c.ClientSecret))
+
+	tlsConfig, err := c.TLS.ClientConfig()
+	if err != nil {
+		return Token{}, err
+	}
+	client := http.Client{Timeout: 30 * time.Second, Transport: makeTransport(tlsConfig)}
+
+	issued := time.Now()
+	res, err := client.Do(req)
+	if err != nil {
+		return Token{}, fmt.Errorf("unable to fetch a token from %s: %s", c.TokenURL, err)
+	}
+	defer res.Body.Close()
+
+	bytesOut, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return Token{}, fmt.Errorf("unable to fetch a token from %s: %s", c.TokenURL, err)
+	}
+
+	if res.StatusCode != http.StatusOK {
+		var tokenErr tokenErrorResponse
+		if json.Unmarshal(bytesOut, &tokenErr) == nil && len(tokenErr.Error) > 0 {
+			return Token{}, fmt.Errorf("unable to fetch a token from %s: %s %s", c.TokenURL, tokenErr.Error, tokenErr.ErrorDescription)
+		}
+		return Token{}, fmt.Errorf("unable to fetch a token from %s: %d - %s", c.TokenURL, res.StatusCode, string(bytesOut))
+	}
+
+	var tokenRes tokenResponse
+	if err := json.Unmarshal(bytesOut, &tokenRes); err != nil {
+		return Token{}, fmt.Errorf("cannot parse token from %s: %s", c.TokenURL, err)
+	}
+	if len(tokenRes.AccessToken) == 0 {
+		return Token{}, fmt.Errorf("no access token returned by %s", c.TokenURL)
+	}
+	if len(tokenRes.TokenType) > 0 && !strings.EqualFold(tokenRes.TokenType, "bearer") {
+		return Token{}, fmt.Errorf("unsupported token type %s returned by %s", tokenRes.TokenType, c.TokenURL)
+	}
+
+	token := Token{AccessToken: tokenRes.AccessToken}
+	if tokenRes.ExpiresIn > 0 {
+		token.Expiry = issued.Add(time.Duration(tokenRes.ExpiresIn) * time.Second)
+	}
+	return token, nil
+}
@@ -4,13 +4,22 @@
 package proxy
 
 import (
+	"crypto/tls"
 	"net"
 	"net/http"
 	"time"
+
+	"github.com/openfaas/faas-cli/config"
 )
 
-// MakeHTTPClient makes a HTTP client with good defaults for timeouts.
-func MakeHTTPClient(timeout *time.Duration) http.Client {
+// MakeHTTPClient makes a HTTP client with good defaults for timeouts, which
+// uses the TLS settings of config.DefaultTLSConfig.
+func MakeHTTPClient(timeout *time.Duration) (http.Client, error) {
+	tlsConfig, err := config.DefaultTLSConfig.ClientConfig()
+	if err != nil {
+		return http.Client{}, err
+	}
+
 	if timeout != nil {
 		return http.Client{
 			Timeout: *timeout,
@@ -24,10 +33,23 @@ func MakeHTTPClient(timeout *time.Duration) http.Client {
 				// DisableKeepAlives:     true,
 				IdleConnTimeout:       120 * time.Millisecond,
 				ExpectContinueTimeout: 1500 * time.Millisecond,
+				TLSClientConfig:       tlsConfig,
 			},
-		}
+		}, nil
 	}
 
 	// This should be used for faas-cli invoke etc.
-	return http.Client{}
+	return http.Client{Transport: makeTransport(tlsConfig)}, nil
+}
+
+// makeTransport returns a copy of http.DefaultTransport using tlsConfig, or nil
+// to use http.DefaultTransport itself when tlsConfig is nil
+func makeTransport(tlsConfig *tls.Config) http.RoundTripper {
+	if tlsConfig == nil {
+		return nil
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = tlsConfig
+	return transport
 }
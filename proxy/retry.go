@@ -0,0 +1,108 @@
+// Copyright (c) OpenFaaS Project 2018. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package proxy
+
+import (
+	"fmt"
+	"math/rand"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+)
+
+const (
+	defaultBackoff    = 500 * time.Millisecond
+	defaultMaxBackoff = 10 * time.Second
+)
+
+// DefaultRetryPolicy is used by clients created with NewClient, faas-cli sets
+// it from the --retries and --retry-timeout flags
+var DefaultRetryPolicy RetryPolicy
+
+// RetryPolicy decides how often a failed call to the gateway is repeated. The
+// zero value never retries.
+type RetryPolicy struct {
+	// Retries is the number of times a call is repeated after the first attempt
+	Retries int
+	// Timeout bounds the time spent on a call and its retries, no limit when 0
+	Timeout time.Duration
+	// Backoff is the wait before the first retry, doubled for every retry after
+	// it, 500ms when 0
+	Backoff time.Duration
+	// MaxBackoff caps the wait between two attempts, 10s when 0
+	MaxBackoff time.Duration
+}
+
+// retryMode tells which failures of a call are safe to retry
+type retryMode int
+
+const (
+	// retryNever sends the request once
+	retryNever retryMode = iota
+	// retryOnConnect retries when no connection could be made, so the request
+	// is known not to have reached the gateway
+	retryOnConnect
+	// retryIdempotent also retries any other connection error and the responses
+	// sent while the gateway or the functions behind it are unavailable
+	retryIdempotent
+)
+
+// shouldRetry tells whether an attempt which failed with err is repeated
+func (mode retryMode) shouldRetry(err error) bool {
+	switch err := err.(type) {
+	case *ErrGatewayUnreachable:
+		return mode == retryIdempotent || (mode == retryOnConnect && isConnectError(err.Err))
+	case *StatusError:
+		if mode != retryIdempotent {
+			return false
+		}
+		switch err.StatusCode {
+		case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+			return true
+		}
+	}
+	return false
+}
+
+// isConnectError tells whether a request failed before a connection was made
+func isConnectError(err error) bool {
+	if urlErr, ok := err.(*url.Error); ok {
+		err = urlErr.Err
+	}
+	opErr, ok := err.(*net.OpError)
+	return ok && opErr.Op == "dial"
+}
+
+// backoff returns the wait before the retry numbered attempt, counting from 1.
+// The wait grows exponentially and a random jitter of up to half of it is taken
+// off, so clients which failed together do not retry together.
+func (policy RetryPolicy) backoff(attempt int) time.Duration {
+	wait := policy.Backoff
+	if wait <= 0 {
+		wait = defaultBackoff
+	}
+	maxWait := policy.MaxBackoff
+	if maxWait <= 0 {
+		maxWait = defaultMaxBackoff
+	}
+
+	for i := 1; i < attempt && wait < maxWait; i++ {
+		wait *= 2
+	}
+	if wait > maxWait {
+		wait = maxWait
+	}
+
+	half := int64(wait / 2)
+	return time.Duration(half + rand.Int63n(half+1))
+}
+
+// debugPrint logs a message when the debug environment variable is set
+func debugPrint(message string) {
+	if val, exists := os.LookupEnv("debug"); exists && (val == "1" || val == "true") {
+		fmt.Println(message)
+	}
+}
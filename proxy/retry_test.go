@@ -0,0 +1,229 @@
+// Copyright (c) OpenFaaS Project 2018. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package proxy
+
+import (
+	"context"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/openfaas/faas/gateway/requests"
+)
+
+var testRetryPolicy = RetryPolicy{Retries: 3, Backoff: time.Millisecond}
+
+// flakyServer responds with failStatus to the first failures requests and with
+// 200 OK after that, calls counts the requests received
+func flakyServer(failures int, failStatus int, calls *int) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*calls++
+		if *calls <= failures {
+			w.WriteHeader(failStatus)
+			return
+		}
+		w.Write([]byte("[]"))
+	}))
+}
+
+// refusingTransport fails the first failures requests as if the connection was refused
+type refusingTransport struct {
+	failures int
+	calls    int
+}
+
+func (t *refusingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	t.calls++
+	if t.calls <= t.failures {
+		return nil, &net.OpError{Op: "dial", Net: "tcp", Err: &net.AddrError{Err: "connection refused", Addr: req.URL.Host}}
+	}
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+func Test_Client_RetriesIdempotentCalls(t *testing.T) {
+	tests := []struct {
+		name string
+		call func(client *Client) error
+	}{
+		{name: "list", call: func(client *Client) error { _, err := client.ListFunctions(context.Background()); return err }},
+		{name: "secrets", call: func(client *Client) error { _, err := client.ListSecrets(context.Background()); return err }},
+		{name: "delete", call: func(client *Client) error { _, err := client.DeleteFunction(context.Background(), "echo"); return err }},
+		{name: "update", call: func(client *Client) error {
+			_, err := client.DeployFunction(context.Background(), requests.CreateFunctionRequest{Service: "echo"}, true)
+			return err
+		}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var calls int
+			s := flakyServer(2, http.StatusBadGateway, &calls)
+			defer s.Close()
+
+			client := NewClient(s.URL)
+			client.Retry = testRetryPolicy
+
+			if err := test.call(client); err != nil {
+				t.Fatalf("want the call to succeed once the gateway is back, got %v", err)
+			}
+			if calls != 3 {
+				t.Errorf("want 3 attempts, got %d", calls)
+			}
+		})
+	}
+}
+
+func Test_Client_DoesNotRetryCreateOrInvoke(t *testing.T) {
+	var calls int
+	s := flakyServer(1, http.StatusBadGateway, &calls)
+	defer s.Close()
+
+	client := NewClient(s.URL)
+	client.Retry = testRetryPolicy
+
+	_, err := client.DeployFunction(context.Background(), requests.CreateFunctionRequest{Service: "echo"}, false)
+	if statusErr, ok := err.(*StatusError); !ok || statusErr.StatusCode != http.StatusBadGateway {
+		t.Fatalf("want the 502 of the first attempt, got %v", err)
+	}
+
+	_, err = client.InvokeFunction(context.Background(), "echo", nil, "text/plain", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if calls != 2 {
+		t.Errorf("want a single attempt for each call, got %d", calls)
+	}
+}
+
+func Test_Client_RetriesCreateWhenConnectionRefused(t *testing.T) {
+	var received []byte
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received, _ = ioutil.ReadAll(r.Body)
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer s.Close()
+
+	transport := &refusingTransport{failures: 2}
+	client := NewClient(s.URL)
+	client.Transport = transport
+	client.Retry = testRetryPolicy
+
+	statusCode, err := client.DeployFunction(context.Background(), requests.CreateFunctionRequest{Service: "echo"}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if statusCode != http.StatusAccepted || transport.calls != 3 {
+		t.Errorf("want the function created on the 3rd attempt, got status %d after %d attempts", statusCode, transport.calls)
+	}
+	if len(received) == 0 {
+		t.Errorf("want the body of the request sent again on retry")
+	}
+}
+
+func Test_Client_RetriesGiveUp(t *testing.T) {
+	var calls int
+	s := flakyServer(10, http.StatusServiceUnavailable, &calls)
+	defer s.Close()
+
+	client := NewClient(s.URL)
+	client.Retry = RetryPolicy{Retries: 2, Backoff: time.Millisecond}
+
+	_, err := client.ListFunctions(context.Background())
+	if statusErr, ok := err.(*StatusError); !ok || statusErr.StatusCode != http.StatusServiceUnavailable {
+		t.Fatalf("want the 503 of the last attempt, got %v", err)
+	}
+	if calls != 3 {
+		t.Errorf("want 3 attempts, got %d", calls)
+	}
+}
+
+func Test_Client_RetryTimeout(t *testing.T) {
+	var calls int
+	s := flakyServer(10, http.StatusBadGateway, &calls)
+	defer s.Close()
+
+	client := NewClient(s.URL)
+	client.Retry = RetryPolicy{Retries: 5, Timeout: 50 * time.Millisecond, Backoff: time.Second}
+
+	start := time.Now()
+	if _, err := client.ListFunctions(context.Background()); err == nil {
+		t.Fatal("want an error")
+	}
+	if calls != 1 || time.Since(start) > time.Second {
+		t.Errorf("want no retry which would exceed the timeout, got %d attempts in %s", calls, time.Since(start))
+	}
+}
+
+func Test_Client_NoRetriesByDefault(t *testing.T) {
+	var calls int
+	s := flakyServer(1, http.StatusBadGateway, &calls)
+	defer s.Close()
+
+	if _, err := NewClient(s.URL).ListFunctions(context.Background()); err == nil {
+		t.Fatal("want an error")
+	}
+	if calls != 1 {
+		t.Errorf("want a single attempt, got %d", calls)
+	}
+}
+
+func Test_RetryPolicy_backoff(t *testing.T) {
+	policy := RetryPolicy{Backoff: 100 * time.Millisecond, MaxBackoff: time.Second}
+
+	tests := []struct {
+		attempt int
+		max     time.Duration
+	}{
+		{attempt: 1, max: 100 * time.Millisecond},
+		{attempt: 2, max: 200 * time.Millisecond},
+		{attempt: 3, max: 400 * time.Millisecond},
+		{attempt: 5, max: time.Second},
+		{attempt: 50, max: time.Second},
+	}
+
+	for _, test := range tests {
+		for i := 0; i < 20; i++ {
+			wait := policy.backoff(test.attempt)
+			if wait < test.max/2 || wait > test.max {
+				t.Errorf("attempt %d: want a wait between %s and %s, got %s", test.attempt, test.max/2, test.max, wait)
+			}
+		}
+	}
+}
+
+func Test_isConnectError(t *testing.T) {
+	refused := &net.OpError{Op: "dial", Net: "tcp", Err: &net.AddrError{Err: "connection refused"}}
+	reset := &net.OpError{Op: "read", Net: "tcp", Err: &net.AddrError{Err: "connection reset by peer"}}
+
+	if !isConnectError(&url.Error{Op: "Post", URL: "http://gateway", Err: refused}) {
+		t.Errorf("want a refused connection to be a connect error")
+	}
+	if isConnectError(&url.Error{Op: "Post", URL: "http://gateway", Err: reset}) {
+		t.Errorf("want a reset connection not to be a connect error, the request may have been received")
+	}
+}
+
+func Test_Client_DoesNotRetryMalformedURL(t *testing.T) {
+	transport := &refusingTransport{}
+	client := NewClient("127.0.0.1:8080")
+	client.Transport = transport
+	client.Retry = RetryPolicy{Retries: 3, Backoff: time.Second}
+
+	start := time.Now()
+	_, err := client.ListFunctions(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "first path segment in URL cannot contain colon") {
+		t.Fatalf("want the URL parse error, got %v", err)
+	}
+	if _, unreachable := err.(*ErrGatewayUnreachable); unreachable {
+		t.Errorf("want a malformed URL not reported as an unreachable gateway")
+	}
+	if transport.calls != 0 || time.Since(start) > 500*time.Millisecond {
+		t.Errorf("want no request sent nor retried, got %d requests in %s", transport.calls, time.Since(start))
+	}
+}
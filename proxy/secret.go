@@ -0,0 +1,22 @@
+// Copyright (c) OpenFaaS Project 2018. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package proxy
+
+import (
+	"context"
+	"time"
+)
+
+// Secret as returned by the gateway's system/secrets endpoint
+type Secret struct {
+	Name string `json:"name"`
+}
+
+// ListSecrets list the secrets available on the gateway, values are never returned
+func ListSecrets(gateway string) ([]Secret, error) {
+	client := NewClient(gateway)
+	client.Timeout = 60 * time.Second
+
+	return client.ListSecrets(context.Background())
+}
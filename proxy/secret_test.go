@@ -0,0 +1,51 @@
+// Copyright (c) OpenFaaS Project 2018. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package proxy
+
+import (
+	"net/http"
+	"reflect"
+	"regexp"
+	"testing"
+
+	"github.com/openfaas/faas-cli/test"
+)
+
+func Test_ListSecrets(t *testing.T) {
+	expected := []Secret{{Name: "db-password"}, {Name: "api-key"}}
+
+	s := test.MockHttpServer(t, []test.Request{
+		{
+			Method:             http.MethodGet,
+			Uri:                "/system/secrets",
+			ResponseStatusCode: http.StatusOK,
+			ResponseBody:       expected,
+		},
+	})
+	defer s.Close()
+
+	result, err := ListSecrets(s.URL)
+	if err != nil {
+		t.Fatalf("Error returned: %s", err)
+	}
+
+	if !reflect.DeepEqual(result, expected) {
+		t.Fatalf("Expected: %#v - Actual: %#v", expected, result)
+	}
+}
+
+func Test_ListSecrets_Not200(t *testing.T) {
+	s := test.MockHttpServerStatus(t, http.StatusNotFound)
+	defer s.Close()
+
+	_, err := ListSecrets(s.URL)
+	if err == nil {
+		t.Fatalf("Error was not returned")
+	}
+
+	r := regexp.MustCompile(`(?m:server returned unexpected status code: 404)`)
+	if !r.MatchString(err.Error()) {
+		t.Fatalf("Error not matched: %s", err)
+	}
+}
@@ -0,0 +1,165 @@
+// Copyright (c) OpenFaaS Project 2018. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package stack
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	yaml "gopkg.in/yaml.v2"
+)
+
+// functionsKeyPattern matches the functions key of a stack file, the value
+// is empty for a block mapping and given for a flow mapping such as {}
+var functionsKeyPattern = regexp.MustCompile(`^functions\s*:(\s+[^\s#].*?)?\s*(#.*)?$`)
+
+// emptyFlowPattern matches the value of an empty functions section written in flow style
+var emptyFlowPattern = regexp.MustCompile(`^\s*(\{\s*\}|~|null)$`)
+
+// stackOutline is the part of a stack file read before appending to it, the
+// other keys are left out so references such as ${REPLICAS} need no value
+type stackOutline struct {
+	Functions map[string]interface{} `yaml:"functions"`
+	Include   []string               `yaml:"include"`
+}
+
+// DefinedFunctions returns the name of every function defined by a stack file
+// or the files it includes, mapped to the file which defines it. Environment
+// variables are not substituted, so a stack parameterised for CI can be
+// checked without setting them.
+func DefinedFunctions(yamlFile string) (map[string]string, error) {
+	sources := map[string]string{}
+	return sources, definedFunctions(yamlFile, nil, sources)
+}
+
+func definedFunctions(yamlFile string, parents []string, sources map[string]string) error {
+	for _, parent := range parents {
+		if parent == yamlFile {
+			return fmt.Errorf("include cycle detected: %s -> %s", strings.Join(parents, " -> "), yamlFile)
+		}
+	}
+
+	fileData, err := readYAML(yamlFile)
+	if err != nil {
+		return err
+	}
+
+	var outline stackOutline
+	if err := yaml.Unmarshal(fileData, &outline); err != nil {
+		return fmt.Errorf("unable to parse %s: %v", yamlFile, err)
+	}
+
+	for name := range outline.Functions {
+		if _, found := sources[name]; !found {
+			sources[name] = yamlFile
+		}
+	}
+	for _, include := range outline.Include {
+		if err := definedFunctions(resolveInclude(yamlFile, include), append(parents, yamlFile), sources); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// AppendFunction adds a function to the end of the functions section of a stack
+// file. Only the function is marshalled, the rest of the file is kept as text so
+// its comments and the order of its keys are preserved. An error is returned when
+// the file already defines a function of the same name.
+func AppendFunction(fileData []byte, function Function) ([]byte, error) {
+	var outline stackOutline
+	if err := yaml.Unmarshal(fileData, &outline); err != nil {
+		return nil, fmt.Errorf("unable to parse the stack file: %v", err)
+	}
+	if _, exists := outline.Functions[function.Name]; exists {
+		return nil, fmt.Errorf("function %s already exists in the stack file", function.Name)
+	}
+
+	lines := strings.Split(strings.TrimRight(string(fileData), "\n"), "\n")
+
+	functionsLine := -1
+	var functionsValue, functionsComment string
+	for i, line := range lines {
+		if match := functionsKeyPattern.FindStringSubmatch(line); match != nil {
+			functionsLine = i
+			functionsValue, functionsComment = match[1], match[2]
+			break
+		}
+	}
+
+	// an empty flow mapping is replaced by a block mapping, any other can not
+	// be appended to without marshalling the whole file and losing its comments
+	if functionsLine >= 0 && len(functionsValue) > 0 {
+		if !emptyFlowPattern.MatchString(functionsValue) {
+			return nil, fmt.Errorf("unable to append to the functions section of the stack file, it must be a block mapping, not %s", strings.TrimSpace(functionsValue))
+		}
+		lines[functionsLine] = strings.TrimSpace("functions: " + functionsComment)
+	}
+
+	if functionsLine < 0 && len(outline.Functions) > 0 {
+		return nil, fmt.Errorf("unable to append to the functions section of the stack file, it must be a block mapping")
+	}
+	if functionsLine < 0 {
+		appendedLines, err := functionLines(function, "  ")
+		if err != nil {
+			return nil, err
+		}
+		lines = append(append(lines, "", "functions:"), appendedLines...)
+		return []byte(strings.Join(lines, "\n") + "\n"), nil
+	}
+
+	// the section ends at the next top-level key, the function is written after
+	// its last indented line so that comments of the next section stay with it
+	insertAt := functionsLine + 1
+	indent := ""
+	for i := functionsLine + 1; i < len(lines); i++ {
+		line := lines[i]
+		trimmed := strings.TrimLeft(line, " ")
+		if len(trimmed) == 0 {
+			continue
+		}
+		if len(trimmed) == len(line) {
+			if strings.HasPrefix(trimmed, "#") {
+				continue
+			}
+			break
+		}
+
+		insertAt = i + 1
+		if len(indent) == 0 && !strings.HasPrefix(trimmed, "#") {
+			indent = line[:len(line)-len(trimmed)]
+		}
+	}
+	if len(indent) == 0 {
+		indent = "  "
+	}
+
+	appendedLines, err := functionLines(function, indent)
+	if err != nil {
+		return nil, err
+	}
+
+	appended := append([]string{}, lines[:insertAt]...)
+	appended = append(appended, appendedLines...)
+	appended = append(appended, lines[insertAt:]...)
+	return []byte(strings.Join(appended, "\n") + "\n"), nil
+}
+
+// functionLines marshals a function with the stack writer, indent is the
+// indentation of a function's name within the functions section
+func functionLines(function Function, indent string) ([]string, error) {
+	fileData, err := yaml.Marshal(marshalFunctions(map[string]Function{function.Name: function}))
+	if err != nil {
+		return nil, err
+	}
+
+	lines := strings.Split(strings.TrimRight(string(fileData), "\n"), "\n")
+	for i, line := range lines {
+		if len(line) > 0 {
+			lines[i] = indent + line
+		}
+	}
+	return lines, nil
+}
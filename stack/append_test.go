@@ -0,0 +1,180 @@
+// Copyright (c) OpenFaaS Project 2018. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package stack
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func Test_AppendFunction(t *testing.T) {
+	function := Function{Name: "echo", Language: "python", Handler: "./echo", Image: "alexellis/echo"}
+
+	tests := []struct {
+		title    string
+		input    string
+		expected string
+	}{
+		{
+			title: "appended after the last function, keeping comments",
+			input: `# Functions of the shop
+provider:
+  name: faas
+  gateway: http://127.0.0.1:8080 # local gateway
+
+functions:
+  # takes payments
+  checkout:
+    lang: node
+    handler: ./checkout
+    image: checkout
+`,
+			expected: `# Functions of the shop
+provider:
+  name: faas
+  gateway: http://127.0.0.1:8080 # local gateway
+
+functions:
+  # takes payments
+  checkout:
+    lang: node
+    handler: ./checkout
+    image: checkout
+  echo:
+    lang: python
+    handler: ./echo
+    image: alexellis/echo
+`,
+		},
+		{
+			title: "appended before the next section, at the indentation of its functions",
+			input: `functions:
+    checkout:
+        lang: node
+        handler: ./checkout
+        image: checkout
+
+# the gateway
+provider:
+    name: faas
+`,
+			expected: `functions:
+    checkout:
+        lang: node
+        handler: ./checkout
+        image: checkout
+    echo:
+      lang: python
+      handler: ./echo
+      image: alexellis/echo
+
+# the gateway
+provider:
+    name: faas
+`,
+		},
+		{
+			title: "functions section added when there is none",
+			input: `provider:
+  name: faas
+`,
+			expected: `provider:
+  name: faas
+
+functions:
+  echo:
+    lang: python
+    handler: ./echo
+    image: alexellis/echo
+`,
+		},
+		{
+			title: "empty flow style functions section replaced",
+			input: `provider:
+  name: faas
+functions: {} # none yet
+`,
+			expected: `provider:
+  name: faas
+functions: # none yet
+  echo:
+    lang: python
+    handler: ./echo
+    image: alexellis/echo
+`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.title, func(t *testing.T) {
+			appended, err := AppendFunction([]byte(test.input), function)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(appended) != test.expected {
+				t.Errorf("want:\n%s\ngot:\n%s", test.expected, appended)
+			}
+
+			services, err := ParseYAMLData(appended, "", "")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if services.Functions["echo"].Image != "alexellis/echo" {
+				t.Errorf("want the appended function to be parsed, got %v", services.Functions)
+			}
+		})
+	}
+}
+
+func Test_AppendFunction_Errors(t *testing.T) {
+	function := Function{Name: "checkout", Language: "python", Handler: "./checkout", Image: "checkout"}
+
+	_, err := AppendFunction([]byte("functions:\n  checkout:\n    lang: node\n"), function)
+	if err == nil || err.Error() != "function checkout already exists in the stack file" {
+		t.Errorf("want an existing function refused, got %v", err)
+	}
+
+	appended, err := AppendFunction([]byte("functions:\n  echo:\n    lang: node\n    skip_build: ${SKIP_BUILD}\n"), function)
+	if err != nil || len(appended) == 0 {
+		t.Errorf("want a stack appended to without its environment set, got %v", err)
+	}
+
+	_, err = AppendFunction([]byte("functions: {echo: {lang: node}}\n"), function)
+	if err == nil || err.Error() != "unable to append to the functions section of the stack file, it must be a block mapping, not {echo: {lang: node}}" {
+		t.Errorf("want a flow style functions section refused, got %v", err)
+	}
+}
+
+func Test_DefinedFunctions(t *testing.T) {
+	dir, err := ioutil.TempDir("", "stack-defined-functions")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	os.Unsetenv("FAAS_TEST_REPLICAS")
+	stackFile := filepath.Join(dir, "stack.yml")
+	ioutil.WriteFile(stackFile, []byte(`include:
+  - ./common.yml
+functions:
+  checkout:
+    lang: node
+    image: ${REGISTRY}/checkout
+    environment:
+      replicas: ${FAAS_TEST_REPLICAS}
+`), 0600)
+	ioutil.WriteFile(filepath.Join(dir, "common.yml"), []byte("functions:\n  echo:\n    lang: python\n    limits:\n      memory: ${MEMORY}\n"), 0600)
+
+	sources, err := DefinedFunctions(stackFile)
+	if err != nil {
+		t.Fatalf("want the functions found without the environment set, got %s", err)
+	}
+	want := map[string]string{"checkout": stackFile, "echo": filepath.Join(dir, "common.yml")}
+	if !reflect.DeepEqual(sources, want) {
+		t.Errorf("want %v, got %v", want, sources)
+	}
+}
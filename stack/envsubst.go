@@ -0,0 +1,59 @@
+// Copyright (c) OpenFaaS Project 2018. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package stack
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// envVarPattern matches ${VAR} and ${VAR:-default}
+var envVarPattern = regexp.MustCompile(`\$\{([a-zA-Z_][a-zA-Z0-9_]*)(:-([^}]*))?\}`)
+
+var lookupEnv = func(key string) (string, bool) {
+	return os.LookupEnv(key)
+}
+
+// substituteEnvironment expands ${VAR} and ${VAR:-default} references in the
+// YAML data using the process environment. A reference without a default is
+// required and an error naming every unset variable is returned. Comment lines
+// are left untouched.
+func substituteEnvironment(fileData []byte) ([]byte, error) {
+	missing := map[string]bool{}
+
+	lines := strings.Split(string(fileData), "\n")
+	for i, line := range lines {
+		if strings.HasPrefix(strings.TrimSpace(line), "#") {
+			continue
+		}
+
+		lines[i] = envVarPattern.ReplaceAllStringFunc(line, func(match string) string {
+			parts := envVarPattern.FindStringSubmatch(match)
+			name, hasDefault, defaultValue := parts[1], len(parts[2]) > 0, parts[3]
+
+			value, found := lookupEnv(name)
+			if hasDefault && len(value) == 0 {
+				return defaultValue
+			}
+			if !found {
+				missing[name] = true
+			}
+			return value
+		})
+	}
+
+	if len(missing) > 0 {
+		var names []string
+		for name := range missing {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return nil, fmt.Errorf("required environment variable(s) not set: %s - set them or provide a default with ${VAR:-default}", strings.Join(names, ", "))
+	}
+
+	return []byte(strings.Join(lines, "\n")), nil
+}
@@ -0,0 +1,99 @@
+// Copyright (c) OpenFaaS Project 2018. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package stack
+
+import (
+	"strings"
+	"testing"
+)
+
+const envsubstStack = `provider:
+  name: faas
+  gateway: ${GATEWAY:-http://localhost:8080}
+
+functions:
+  url-ping:
+    lang: python
+    handler: ./sample/url-ping
+    image: ${REGISTRY}/url-ping:${TAG:-latest}
+    environment:
+      mode: ${MODE:-dev}
+    labels:
+      team: ${TEAM}
+    constraints:
+      - "node.role == ${ROLE:-worker}"
+`
+
+func mockLookupEnv(env map[string]string) func() {
+	original := lookupEnv
+	lookupEnv = func(key string) (string, bool) {
+		value, found := env[key]
+		return value, found
+	}
+	return func() {
+		lookupEnv = original
+	}
+}
+
+func Test_ParseYAMLData_EnvironmentSubstitution(t *testing.T) {
+	defer mockLookupEnv(map[string]string{
+		"REGISTRY": "registry:5000",
+		"TAG":      "",
+		"MODE":     "prod",
+		"TEAM":     "payments",
+		"ROLE":     "manager",
+	})()
+
+	services, err := ParseYAMLData([]byte(envsubstStack), "", "")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if services.Provider.GatewayURL != "http://localhost:8080" {
+		t.Errorf("want default gateway, got %q", services.Provider.GatewayURL)
+	}
+
+	function := services.Functions["url-ping"]
+	if function.Image != "registry:5000/url-ping:latest" {
+		t.Errorf("want image from environment with default tag, got %q", function.Image)
+	}
+	if function.Environment["mode"] != "prod" {
+		t.Errorf("want environment value from environment, got %q", function.Environment["mode"])
+	}
+	if (*function.Labels)["team"] != "payments" {
+		t.Errorf("want label value from environment, got %q", (*function.Labels)["team"])
+	}
+	if (*function.Constraints)[0] != "node.role == manager" {
+		t.Errorf("want constraint from environment, got %q", (*function.Constraints)[0])
+	}
+}
+
+func Test_ParseYAMLData_EnvironmentSubstitutionMissing(t *testing.T) {
+	defer mockLookupEnv(map[string]string{})()
+
+	_, err := ParseYAMLData([]byte(envsubstStack), "", "")
+	if err == nil {
+		t.Fatalf("expected error for unset variables")
+	}
+
+	want := "required environment variable(s) not set: REGISTRY, TEAM"
+	if !strings.Contains(err.Error(), want) {
+		t.Errorf("want error containing %q, got %q", want, err.Error())
+	}
+}
+
+func Test_substituteEnvironment_SkipsComments(t *testing.T) {
+	defer mockLookupEnv(map[string]string{})()
+
+	input := "# image: ${UNSET}\nimage: ${IMAGE:-func}"
+	output, err := substituteEnvironment([]byte(input))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	want := "# image: ${UNSET}\nimage: func"
+	if string(output) != want {
+		t.Errorf("want %q, got %q", want, string(output))
+	}
+}
@@ -0,0 +1,72 @@
+// Copyright (c) OpenFaaS Project 2018. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package stack
+
+import (
+	"encoding/json"
+	"reflect"
+)
+
+// schemaPatterns adds a pattern to string fields which have a fixed format
+var schemaPatterns = map[string]string{
+	"FunctionResources.Memory": memoryQuantityPattern.String(),
+	"FunctionResources.CPU":    cpuQuantityPattern.String(),
+}
+
+// GenerateJSONSchema returns a JSON Schema for stack files generated from the
+// Services type, so that editors can validate and autocomplete stack.yml.
+func GenerateJSONSchema() ([]byte, error) {
+	schema := typeSchema(reflect.TypeOf(Services{}))
+	schema["$schema"] = "http://json-schema.org/draft-07/schema#"
+	schema["title"] = "OpenFaaS stack file"
+	schema["required"] = []string{"provider"}
+
+	return json.MarshalIndent(schema, "", "  ")
+}
+
+func typeSchema(t reflect.Type) map[string]interface{} {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		properties := make(map[string]interface{})
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			name := yamlFieldName(field)
+			if len(name) == 0 {
+				continue
+			}
+
+			property := typeSchema(field.Type)
+			if pattern, found := schemaPatterns[t.Name()+"."+field.Name]; found {
+				property["pattern"] = pattern
+			}
+			properties[name] = property
+		}
+		return map[string]interface{}{
+			"type":                 "object",
+			"properties":           properties,
+			"additionalProperties": false,
+		}
+	case reflect.Map:
+		return map[string]interface{}{
+			"type":                 "object",
+			"additionalProperties": typeSchema(t.Elem()),
+		}
+	case reflect.Slice:
+		return map[string]interface{}{
+			"type":  "array",
+			"items": typeSchema(t.Elem()),
+		}
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int32, reflect.Int64:
+		return map[string]interface{}{"type": "integer"}
+	default:
+		// yaml.v2 decodes any scalar into a string field, i.e. "canary: true" in labels
+		return map[string]interface{}{"type": []string{"string", "number", "boolean"}}
+	}
+}
@@ -9,11 +9,20 @@ import (
 	"net/url"
 	"os"
 	"path/filepath"
+	"regexp"
+	"strconv"
 	"strings"
 
 	yaml "gopkg.in/yaml.v2"
 )
 
+// DefaultHandlerFolder is the folder of a template holding the function when
+// template.yml does not name one
+const DefaultHandlerFolder = "function"
+
+// versionPattern matches a release such as 0.6.1 or v0.7.0-rc1
+var versionPattern = regexp.MustCompile(`^v?([0-9]+)\.([0-9]+)(\.([0-9]+))?([-+].*)?$`)
+
 func ParseYAMLForLanguageTemplate(file string) (*LanguageTemplate, error) {
 	var err error
 	var fileData []byte
@@ -69,3 +78,142 @@ func IsValidTemplate(lang string) bool {
 
 	return found
 }
+
+// GetHandlerFolder returns the folder of the template which holds the function,
+// an error is returned when handler_folder is not a folder within the template
+func (t *LanguageTemplate) GetHandlerFolder() (string, error) {
+	if len(t.HandlerFolder) == 0 {
+		return DefaultHandlerFolder, nil
+	}
+
+	cleaned := filepath.Clean(t.HandlerFolder)
+	if filepath.IsAbs(cleaned) || cleaned == ".." || strings.HasPrefix(cleaned, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("handler_folder %s must be a folder within the template", t.HandlerFolder)
+	}
+	return cleaned, nil
+}
+
+// SupportsPlatform tells whether the template can be built for a platform,
+// a template which lists no platforms supports all of them
+func (t *LanguageTemplate) SupportsPlatform(platform string) bool {
+	if len(t.Platforms) == 0 {
+		return true
+	}
+	for _, supported := range t.Platforms {
+		if strings.EqualFold(supported, platform) {
+			return true
+		}
+	}
+	return false
+}
+
+// CheckCLIVersion returns an error when cliVersion is older than the template's
+// minimum_cli_version. Development builds, which have no release version, pass.
+func (t *LanguageTemplate) CheckCLIVersion(cliVersion string) error {
+	if len(t.MinimumCLIVersion) == 0 {
+		return nil
+	}
+
+	minimum, err := parseVersion(t.MinimumCLIVersion)
+	if err != nil {
+		return fmt.Errorf("template %s has an invalid minimum_cli_version: %v", t.Language, err)
+	}
+	current, err := parseVersion(cliVersion)
+	if err != nil {
+		return nil
+	}
+
+	for i := range minimum {
+		if current[i] != minimum[i] {
+			if current[i] < minimum[i] {
+				return fmt.Errorf("template %s requires faas-cli %s or newer, this is faas-cli %s", t.Language, t.MinimumCLIVersion, cliVersion)
+			}
+			return nil
+		}
+	}
+	return nil
+}
+
+// parseVersion returns the major, minor and patch numbers of a release
+func parseVersion(version string) ([3]int, error) {
+	var parsed [3]int
+	match := versionPattern.FindStringSubmatch(strings.TrimSpace(version))
+	if match == nil {
+		return parsed, fmt.Errorf("%q is not a version such as 0.6.1", version)
+	}
+
+	for i, part := range []string{match[1], match[2], match[4]} {
+		if len(part) > 0 {
+			parsed[i], _ = strconv.Atoi(part)
+		}
+	}
+	return parsed, nil
+}
+
+// ValidateLanguageTemplate checks that the language template in dir has a
+// Dockerfile, a handler folder and a template.yml which can be parsed, returning
+// every problem found
+func ValidateLanguageTemplate(dir string) ValidationErrors {
+	var errs ValidationErrors
+	addError := func(file string, format string, args ...interface{}) {
+		errs = append(errs, ValidationError{File: file, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+		addError(dir, "is not a template folder")
+		return errs
+	}
+
+	dockerfile := filepath.Join(dir, "Dockerfile")
+	if info, err := os.Stat(dockerfile); err != nil || info.IsDir() {
+		addError(dockerfile, "a template must have a Dockerfile")
+	}
+
+	templateYAML := filepath.Join(dir, "template.yml")
+	fileData, err := ioutil.ReadFile(templateYAML)
+	if err != nil {
+		addError(templateYAML, "a template must have a template.yml")
+		return errs
+	}
+
+	var langTemplate LanguageTemplate
+	if err := yaml.Unmarshal(fileData, &langTemplate); err != nil {
+		addError(templateYAML, "unable to parse: %v", err)
+		return errs
+	}
+
+	if len(langTemplate.Language) == 0 {
+		addError(templateYAML, "language is required")
+	}
+	if len(langTemplate.FProcess) == 0 {
+		addError(templateYAML, "fprocess is required")
+	}
+	if len(langTemplate.MinimumCLIVersion) > 0 {
+		if _, err := parseVersion(langTemplate.MinimumCLIVersion); err != nil {
+			addError(templateYAML, "minimum_cli_version: %v", err)
+		}
+	}
+	for _, platform := range langTemplate.Platforms {
+		if len(strings.TrimSpace(platform)) == 0 {
+			addError(templateYAML, "platforms must not contain an empty name")
+		}
+	}
+
+	optionNames := map[string]bool{}
+	for _, option := range langTemplate.BuildOptions {
+		if len(option.Name) == 0 {
+			addError(templateYAML, "build_options must have a name")
+		} else if optionNames[option.Name] {
+			addError(templateYAML, "build option %s is declared more than once", option.Name)
+		}
+		optionNames[option.Name] = true
+	}
+
+	if handlerFolder, err := langTemplate.GetHandlerFolder(); err != nil {
+		addError(templateYAML, "%v", err)
+	} else if info, err := os.Stat(filepath.Join(dir, handlerFolder)); err != nil || !info.IsDir() {
+		addError(filepath.Join(dir, handlerFolder), "a template must have a %s folder holding the function", handlerFolder)
+	}
+
+	return errs
+}
@@ -4,8 +4,11 @@ package stack
 
 import (
 	"fmt"
+	"io/ioutil"
 	"os"
+	"path/filepath"
 	"reflect"
+	"strings"
 	"testing"
 )
 
@@ -40,6 +43,24 @@ fprocess: python index.py
 				FProcess: "python index.py",
 			},
 		},
+		{
+			`
+language: python3
+fprocess: python3 index.py
+build_options:
+  - name: dev
+    packages:
+      - make
+      - gcc
+`,
+			&LanguageTemplate{
+				Language: "python3",
+				FProcess: "python3 index.py",
+				BuildOptions: []BuildOption{
+					{Name: "dev", Packages: []string{"make", "gcc"}},
+				},
+			},
+		},
 	}
 
 	for k, i := range langTemplateTest {
@@ -75,3 +96,143 @@ func Test_IsValidTemplate(t *testing.T) {
 		t.Fatalf("python must is not valid because it does not contain template.yml")
 	}
 }
+
+func Test_ParseYAMLDataForLanguageTemplate_Metadata(t *testing.T) {
+	langTemplate, err := ParseYAMLDataForLanguageTemplate([]byte(`
+language: go
+fprocess: ./handler
+welcome_message: |
+  You have created a Go function.
+handler_folder: src
+platforms:
+  - x86_64
+  - armhf
+minimum_cli_version: 0.6.0
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := &LanguageTemplate{
+		Language:          "go",
+		FProcess:          "./handler",
+		WelcomeMessage:    "You have created a Go function.\n",
+		HandlerFolder:     "src",
+		Platforms:         []string{"x86_64", "armhf"},
+		MinimumCLIVersion: "0.6.0",
+	}
+	if !reflect.DeepEqual(langTemplate, expected) {
+		t.Errorf("want %+v, got %+v", expected, langTemplate)
+	}
+
+	if handlerFolder, err := langTemplate.GetHandlerFolder(); err != nil || handlerFolder != "src" {
+		t.Errorf("want handler folder src, got %s %v", handlerFolder, err)
+	}
+	if handlerFolder, _ := (&LanguageTemplate{}).GetHandlerFolder(); handlerFolder != DefaultHandlerFolder {
+		t.Errorf("want the default handler folder when none is given")
+	}
+
+	for _, handlerFolder := range []string{"..", "../..", "src/../../other", "/etc"} {
+		_, err := (&LanguageTemplate{HandlerFolder: handlerFolder}).GetHandlerFolder()
+		if err == nil || err.Error() != "handler_folder "+handlerFolder+" must be a folder within the template" {
+			t.Errorf("%s: want a handler folder outside the template refused, got %v", handlerFolder, err)
+		}
+	}
+}
+
+func Test_SupportsPlatform(t *testing.T) {
+	langTemplate := &LanguageTemplate{Platforms: []string{"x86_64", "armhf"}}
+	if !langTemplate.SupportsPlatform("armhf") || !langTemplate.SupportsPlatform("X86_64") {
+		t.Errorf("want armhf and x86_64 supported")
+	}
+	if langTemplate.SupportsPlatform("arm64") {
+		t.Errorf("want arm64 unsupported")
+	}
+	if !(&LanguageTemplate{}).SupportsPlatform("arm64") {
+		t.Errorf("want every platform supported when none are listed")
+	}
+}
+
+func Test_CheckCLIVersion(t *testing.T) {
+	tests := []struct {
+		minimum string
+		current string
+		wantErr bool
+	}{
+		{minimum: "", current: "0.5.0", wantErr: false},
+		{minimum: "0.6.0", current: "0.6.0", wantErr: false},
+		{minimum: "0.6.0", current: "0.6.11", wantErr: false},
+		{minimum: "0.6.0", current: "1.0.0", wantErr: false},
+		{minimum: "0.6.0", current: "0.5.9", wantErr: true},
+		{minimum: "v0.6", current: "0.5.9", wantErr: true},
+		{minimum: "0.6.1", current: "0.6.1-rc1", wantErr: false},
+		{minimum: "0.6.0", current: "dev", wantErr: false},
+		{minimum: "six", current: "0.6.0", wantErr: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.minimum+"_"+test.current, func(t *testing.T) {
+			langTemplate := &LanguageTemplate{Language: "go", MinimumCLIVersion: test.minimum}
+			err := langTemplate.CheckCLIVersion(test.current)
+			if (err != nil) != test.wantErr {
+				t.Errorf("want error: %v, got: %v", test.wantErr, err)
+			}
+		})
+	}
+}
+
+func Test_ValidateLanguageTemplate(t *testing.T) {
+	dir, err := ioutil.TempDir("", "stack-validate-template")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	writeFile := func(name string, content string) {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	errs := ValidateLanguageTemplate(dir)
+	if len(errs) != 2 {
+		t.Fatalf("want a missing Dockerfile and template.yml reported, got:\n%v", errs)
+	}
+
+	writeFile("Dockerfile", "FROM alpine:3.6\n")
+	writeFile("template.yml", "language: [python\n")
+	errs = ValidateLanguageTemplate(dir)
+	if len(errs) != 1 || !strings.Contains(errs[0].Message, "unable to parse") {
+		t.Fatalf("want template.yml reported as unparseable, got:\n%v", errs)
+	}
+
+	writeFile("template.yml", `
+handler_folder: src
+minimum_cli_version: latest
+build_options:
+  - name: dev
+  - name: dev
+`)
+	errs = ValidateLanguageTemplate(dir)
+	expected := []string{
+		"language is required",
+		"fprocess is required",
+		`minimum_cli_version: "latest" is not a version such as 0.6.1`,
+		"build option dev is declared more than once",
+		"a template must have a src folder holding the function",
+	}
+	if len(errs) != len(expected) {
+		t.Fatalf("want %d problems, got:\n%v", len(expected), errs)
+	}
+	for i, message := range expected {
+		if errs[i].Message != message {
+			t.Errorf("want %q, got %q", message, errs[i].Message)
+		}
+	}
+
+	writeFile("template.yml", "language: python\nfprocess: python index.py\nhandler_folder: src\n")
+	os.Mkdir(filepath.Join(dir, "src"), 0755)
+	if errs := ValidateLanguageTemplate(dir); len(errs) != 0 {
+		t.Errorf("want a valid template, got:\n%v", errs)
+	}
+}
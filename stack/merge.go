@@ -0,0 +1,161 @@
+// Copyright (c) OpenFaaS Project 2018. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package stack
+
+// mergeServices deep-merges overlay into base in the same way docker-compose
+// applies override files: provider values and functions found in the overlay
+// take priority, and functions defined in both are merged field by field.
+func mergeServices(base *Services, overlay *Services) {
+	if len(overlay.Provider.Name) > 0 {
+		base.Provider.Name = overlay.Provider.Name
+	}
+	if len(overlay.Provider.GatewayURL) > 0 {
+		base.Provider.GatewayURL = overlay.Provider.GatewayURL
+	}
+	if len(overlay.Provider.Network) > 0 {
+		base.Provider.Network = overlay.Provider.Network
+	}
+	if len(overlay.Provider.Builder) > 0 {
+		base.Provider.Builder = overlay.Provider.Builder
+	}
+
+	if len(overlay.Functions) == 0 {
+		return
+	}
+
+	if base.Functions == nil {
+		base.Functions = make(map[string]Function)
+	}
+
+	for name, function := range overlay.Functions {
+		if existing, found := base.Functions[name]; found {
+			base.Functions[name] = mergeFunction(existing, function)
+		} else {
+			base.Functions[name] = function
+		}
+	}
+}
+
+// mergeFunction returns base with the values set in overlay applied on top.
+// Scalars are replaced, maps are merged key by key and lists are concatenated
+// without duplicates.
+func mergeFunction(base Function, overlay Function) Function {
+	merged := base
+
+	if len(overlay.Language) > 0 {
+		merged.Language = overlay.Language
+	}
+	if len(overlay.Handler) > 0 {
+		merged.Handler = overlay.Handler
+	}
+	if len(overlay.Image) > 0 {
+		merged.Image = overlay.Image
+	}
+	if len(overlay.FProcess) > 0 {
+		merged.FProcess = overlay.FProcess
+	}
+	if overlay.SkipBuild {
+		merged.SkipBuild = true
+	}
+
+	if base.Environment != nil || overlay.Environment != nil {
+		merged.Environment = mergeMap(base.Environment, overlay.Environment)
+	}
+
+	if base.Labels != nil || overlay.Labels != nil {
+		var baseLabels, overlayLabels map[string]string
+		if base.Labels != nil {
+			baseLabels = *base.Labels
+		}
+		if overlay.Labels != nil {
+			overlayLabels = *overlay.Labels
+		}
+		labels := mergeMap(baseLabels, overlayLabels)
+		merged.Labels = &labels
+	}
+
+	if base.Constraints != nil || overlay.Constraints != nil {
+		var baseConstraints, overlayConstraints []string
+		if base.Constraints != nil {
+			baseConstraints = *base.Constraints
+		}
+		if overlay.Constraints != nil {
+			overlayConstraints = *overlay.Constraints
+		}
+		constraints := MergeList(baseConstraints, overlayConstraints)
+		merged.Constraints = &constraints
+	}
+
+	if base.EnvironmentFile != nil || overlay.EnvironmentFile != nil {
+		merged.EnvironmentFile = MergeList(base.EnvironmentFile, overlay.EnvironmentFile)
+	}
+
+	if base.Secrets != nil || overlay.Secrets != nil {
+		merged.Secrets = MergeList(base.Secrets, overlay.Secrets)
+	}
+
+	if base.BuildArgs != nil || overlay.BuildArgs != nil {
+		merged.BuildArgs = mergeMap(base.BuildArgs, overlay.BuildArgs)
+	}
+
+	if base.BuildOptions != nil || overlay.BuildOptions != nil {
+		merged.BuildOptions = MergeList(base.BuildOptions, overlay.BuildOptions)
+	}
+
+	merged.Limits = mergeResources(base.Limits, overlay.Limits)
+	merged.Requests = mergeResources(base.Requests, overlay.Requests)
+
+	return merged
+}
+
+func mergeResources(base *FunctionResources, overlay *FunctionResources) *FunctionResources {
+	if base == nil && overlay == nil {
+		return nil
+	}
+
+	merged := FunctionResources{}
+	if base != nil {
+		merged = *base
+	}
+
+	if overlay != nil {
+		if len(overlay.Memory) > 0 {
+			merged.Memory = overlay.Memory
+		}
+		if len(overlay.CPU) > 0 {
+			merged.CPU = overlay.CPU
+		}
+	}
+
+	return &merged
+}
+
+func mergeMap(base map[string]string, overlay map[string]string) map[string]string {
+	merged := make(map[string]string)
+
+	for k, v := range base {
+		merged[k] = v
+	}
+	for k, v := range overlay {
+		merged[k] = v
+	}
+	return merged
+}
+
+// MergeList returns the values of base followed by those of overlay, in order
+// with duplicates removed
+func MergeList(base []string, overlay []string) []string {
+	merged := []string{}
+	seen := make(map[string]bool)
+
+	for _, list := range [][]string{base, overlay} {
+		for _, value := range list {
+			if !seen[value] {
+				seen[value] = true
+				merged = append(merged, value)
+			}
+		}
+	}
+	return merged
+}
@@ -0,0 +1,201 @@
+// Copyright (c) OpenFaaS Project 2018. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package stack
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// writeStackFiles writes the given files into a temporary directory
+func writeStackFiles(t *testing.T, files map[string]string) string {
+	dir, err := ioutil.TempDir("", "faas-cli-stack-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path, []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return dir
+}
+
+func Test_ParseYAMLFiles_Overlay(t *testing.T) {
+	dir := writeStackFiles(t, map[string]string{
+		"stack.yml": `provider:
+  name: faas
+  gateway: http://localhost:8080
+
+functions:
+  url-ping:
+    lang: python
+    handler: ./url-ping
+    image: alexellis/faas-url-ping
+    environment:
+      mode: dev
+      debug: "true"
+    constraints:
+      - "node.platform.os == linux"
+`,
+		"prod.yml": `provider:
+  gateway: https://gateway.prod:8080
+
+functions:
+  url-ping:
+    image: registry.prod/faas-url-ping:1.0
+    environment:
+      mode: prod
+    labels:
+      canary: "false"
+    constraints:
+      - "node.role == worker"
+`,
+	})
+	defer os.RemoveAll(dir)
+
+	services, err := ParseYAMLFiles([]string{
+		filepath.Join(dir, "stack.yml"),
+		filepath.Join(dir, "prod.yml"),
+	}, "", "")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if services.Provider.Name != "faas" || services.Provider.GatewayURL != "https://gateway.prod:8080" {
+		t.Errorf("provider not merged, got %+v", services.Provider)
+	}
+
+	function := services.Functions["url-ping"]
+	if function.Handler != "./url-ping" || function.Language != "python" {
+		t.Errorf("want handler and lang from base file, got %s %s", function.Handler, function.Language)
+	}
+	if function.Image != "registry.prod/faas-url-ping:1.0" {
+		t.Errorf("want image from overlay, got %s", function.Image)
+	}
+
+	wantEnvironment := map[string]string{"mode": "prod", "debug": "true"}
+	if !reflect.DeepEqual(function.Environment, wantEnvironment) {
+		t.Errorf("want environment %v, got %v", wantEnvironment, function.Environment)
+	}
+
+	wantConstraints := []string{"node.platform.os == linux", "node.role == worker"}
+	if !reflect.DeepEqual(*function.Constraints, wantConstraints) {
+		t.Errorf("want constraints %v, got %v", wantConstraints, *function.Constraints)
+	}
+
+	if (*function.Labels)["canary"] != "false" {
+		t.Errorf("want labels from overlay, got %v", *function.Labels)
+	}
+}
+
+func Test_ParseYAMLFile_Include(t *testing.T) {
+	dir := writeStackFiles(t, map[string]string{
+		"stack.yml": `provider:
+  name: faas
+
+include:
+  - ./team-a/stack.yml
+  - ./team-b/stack.yml
+
+functions:
+  shared:
+    image: shared:override
+`,
+		"team-a/stack.yml": `functions:
+  fn-a:
+    lang: node
+    handler: ./fn-a
+    image: fn-a
+  shared:
+    lang: go
+    handler: ./shared
+    image: shared
+`,
+		"team-b/stack.yml": `functions:
+  fn-b:
+    lang: python
+    handler: ./fn-b
+    image: fn-b
+  shared:
+    handler: ./shared
+    environment:
+      team: b
+`,
+	})
+	defer os.RemoveAll(dir)
+
+	services, err := ParseYAMLFile(filepath.Join(dir, "stack.yml"), "", "")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if len(services.Functions) != 3 {
+		t.Fatalf("want 3 functions, got %d", len(services.Functions))
+	}
+
+	shared := services.Functions["shared"]
+	if shared.Image != "shared:override" || shared.Language != "go" || shared.Environment["team"] != "b" {
+		t.Errorf("shared function not merged, got %+v", shared)
+	}
+}
+
+func Test_ParseYAMLFile_IncludeConflict(t *testing.T) {
+	dir := writeStackFiles(t, map[string]string{
+		"stack.yml": `provider:
+  name: faas
+
+include:
+  - a.yml
+  - b.yml
+`,
+		"a.yml": `functions:
+  echo:
+    handler: ./a/echo
+`,
+		"b.yml": `functions:
+  echo:
+    handler: ./b/echo
+`,
+	})
+	defer os.RemoveAll(dir)
+
+	_, err := ParseYAMLFile(filepath.Join(dir, "stack.yml"), "", "")
+	if err == nil {
+		t.Fatalf("expected conflict error")
+	}
+
+	if !strings.Contains(err.Error(), "function echo is defined in both") {
+		t.Errorf("unexpected error: %s", err)
+	}
+}
+
+func Test_ParseYAMLFile_IncludeCycle(t *testing.T) {
+	dir := writeStackFiles(t, map[string]string{
+		"stack.yml": `provider:
+  name: faas
+include:
+  - other.yml
+`,
+		"other.yml": `include:
+  - stack.yml
+`,
+	})
+	defer os.RemoveAll(dir)
+
+	_, err := ParseYAMLFile(filepath.Join(dir, "stack.yml"), "", "")
+	if err == nil || !strings.Contains(err.Error(), "include cycle detected") {
+		t.Fatalf("expected include cycle error, got %v", err)
+	}
+}
@@ -8,6 +8,9 @@ type Provider struct {
 	Name       string `yaml:"name"`
 	GatewayURL string `yaml:"gateway"`
 	Network    string `yaml:"network"`
+
+	// Builder is the engine used to build and push images, i.e. docker, podman or buildah
+	Builder string `yaml:"builder,omitempty"`
 }
 
 // Function as deployed or built on FaaS
@@ -36,17 +39,26 @@ type Function struct {
 
 	Labels *map[string]string `yaml:"labels"`
 
+	// Secrets list of secrets to be made available to the function
+	Secrets []string `yaml:"secrets"`
+
+	// BuildArgs are passed to the Docker build as --build-arg values
+	BuildArgs map[string]string `yaml:"build_args"`
+
+	// BuildOptions are named sets of packages declared in the template's template.yml
+	BuildOptions []string `yaml:"build_options"`
+
 	// Limits for function
-	Limits *FunctionResources `json:"limits"`
+	Limits *FunctionResources `yaml:"limits"`
 
 	// Requests of resources requested by function
-	Requests *FunctionResources `json:"requests"`
+	Requests *FunctionResources `yaml:"requests"`
 }
 
 // FunctionResources Memory and CPU
 type FunctionResources struct {
-	Memory string `json:"memory"`
-	CPU    string `json:"cpu"`
+	Memory string `yaml:"memory"`
+	CPU    string `yaml:"cpu"`
 }
 
 // EnvironmentFile represents external file for environment data
@@ -58,10 +70,29 @@ type EnvironmentFile struct {
 type Services struct {
 	Functions map[string]Function `yaml:"functions,omitempty"`
 	Provider  Provider            `yaml:"provider,omitempty"`
+
+	// Include is a list of stack files merged underneath this one
+	Include []string `yaml:"include,omitempty"`
 }
 
 // LanguageTemplate read from template.yml within root of a language template folder
 type LanguageTemplate struct {
-	Language string `yaml:"language"`
-	FProcess string `yaml:"fprocess"`
+	Language     string        `yaml:"language"`
+	FProcess     string        `yaml:"fprocess"`
+	BuildOptions []BuildOption `yaml:"build_options"`
+
+	// WelcomeMessage is printed by faas-cli new once a function is created
+	WelcomeMessage string `yaml:"welcome_message,omitempty"`
+	// HandlerFolder is the folder of the template which holds the function, "function" when empty
+	HandlerFolder string `yaml:"handler_folder,omitempty"`
+	// Platforms the template can be built for, such as x86_64 or armhf, any platform when empty
+	Platforms []string `yaml:"platforms,omitempty"`
+	// MinimumCLIVersion is the oldest faas-cli release which can use the template
+	MinimumCLIVersion string `yaml:"minimum_cli_version,omitempty"`
+}
+
+// BuildOption a named set of packages which a function can opt into at build time
+type BuildOption struct {
+	Name     string   `yaml:"name"`
+	Packages []string `yaml:"packages"`
 }
@@ -9,9 +9,12 @@ import (
 	"net"
 	"net/http"
 	"net/url"
+	"path/filepath"
 	"regexp"
+	"strings"
 	"time"
 
+	"github.com/openfaas/faas-cli/config"
 	"github.com/ryanuber/go-glob"
 	yaml "gopkg.in/yaml.v2"
 )
@@ -20,36 +23,138 @@ const providerName = "faas"
 
 // ParseYAMLFile parse YAML file into a stack of "services".
 func ParseYAMLFile(yamlFile, regex, filter string) (*Services, error) {
-	var err error
-	var fileData []byte
-	urlParsed, err := url.Parse(yamlFile)
-	if err == nil && len(urlParsed.Scheme) > 0 {
-		fmt.Println("Parsed: " + urlParsed.String())
-		fileData, err = fetchYAML(urlParsed)
-		if err != nil {
-			return nil, err
-		}
-	} else {
-		fileData, err = ioutil.ReadFile(yamlFile)
+	return ParseYAMLFiles([]string{yamlFile}, regex, filter)
+}
+
+// ParseYAMLFiles parse one or more YAML files into a stack of "services".
+// Each file is deep-merged over the files before it.
+func ParseYAMLFiles(yamlFiles []string, regex, filter string) (*Services, error) {
+	var services Services
+
+	for _, yamlFile := range yamlFiles {
+		parsedServices, err := loadYAMLFile(yamlFile, nil)
 		if err != nil {
 			return nil, err
 		}
+		mergeServices(&services, parsedServices)
 	}
-	return ParseYAMLData(fileData, regex, filter)
+
+	return filterServices(&services, regex, filter)
 }
 
 // ParseYAMLData parse YAML data into a stack of "services".
 func ParseYAMLData(fileData []byte, regex string, filter string) (*Services, error) {
+	services, err := parseYAMLData(fileData, "", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return filterServices(services, regex, filter)
+}
+
+// loadYAMLFile reads a stack file from disk or a URL and resolves its includes.
+// parents holds the chain of files which included this one.
+func loadYAMLFile(yamlFile string, parents []string) (*Services, error) {
+	for _, parent := range parents {
+		if parent == yamlFile {
+			return nil, fmt.Errorf("include cycle detected: %s -> %s", strings.Join(parents, " -> "), yamlFile)
+		}
+	}
+
+	fileData, err := readYAML(yamlFile)
+	if err != nil {
+		return nil, err
+	}
+
+	return parseYAMLData(fileData, yamlFile, append(parents, yamlFile))
+}
+
+// readYAML reads a YAML file from disk or from a remote URL
+func readYAML(yamlFile string) ([]byte, error) {
+	urlParsed, err := url.Parse(yamlFile)
+	if err == nil && len(urlParsed.Scheme) > 0 {
+		fmt.Println("Parsed: " + urlParsed.String())
+		return fetchYAML(urlParsed)
+	}
+	return ioutil.ReadFile(yamlFile)
+}
+
+// parseYAMLData unmarshals a single stack file and merges it over the files
+// listed in its include section. Two includes defining the same function with
+// different handlers are reported as a conflict.
+func parseYAMLData(fileData []byte, location string, parents []string) (*Services, error) {
 	var services Services
-	regexExists := len(regex) > 0
-	filterExists := len(filter) > 0
 
-	err := yaml.Unmarshal(fileData, &services)
+	fileData, err := substituteEnvironment(fileData)
+	if err != nil {
+		return nil, err
+	}
+
+	err = yaml.Unmarshal(fileData, &services)
 	if err != nil {
 		fmt.Printf("Error with YAML file\n")
 		return nil, err
 	}
 
+	if len(services.Include) == 0 {
+		return &services, nil
+	}
+
+	var merged Services
+	sources := make(map[string]string)
+
+	for _, include := range services.Include {
+		includePath := resolveInclude(location, include)
+
+		included, err := loadYAMLFile(includePath, parents)
+		if err != nil {
+			return nil, fmt.Errorf("error including %s: %v", includePath, err)
+		}
+
+		for name, function := range included.Functions {
+			if source, found := sources[name]; found {
+				existing := merged.Functions[name]
+				if len(existing.Handler) > 0 && len(function.Handler) > 0 && existing.Handler != function.Handler {
+					return nil, fmt.Errorf("function %s is defined in both %s and %s with different handlers: %s and %s",
+						name, source, includePath, existing.Handler, function.Handler)
+				}
+			}
+			sources[name] = includePath
+		}
+
+		mergeServices(&merged, included)
+	}
+
+	services.Include = nil
+	mergeServices(&merged, &services)
+
+	return &merged, nil
+}
+
+// resolveInclude makes an include path relative to the file which declared it.
+func resolveInclude(location string, include string) string {
+	if includeURL, err := url.Parse(include); err == nil && len(includeURL.Scheme) > 0 {
+		return include
+	}
+
+	if locationURL, err := url.Parse(location); err == nil && len(locationURL.Scheme) > 0 {
+		if includeURL, err := url.Parse(include); err == nil {
+			return locationURL.ResolveReference(includeURL).String()
+		}
+	}
+
+	if len(location) == 0 || filepath.IsAbs(include) {
+		return include
+	}
+
+	return filepath.Join(filepath.Dir(location), include)
+}
+
+// filterServices checks the provider and applies the regex or filter to the functions.
+func filterServices(services *Services, regex string, filter string) (*Services, error) {
+	regexExists := len(regex) > 0
+	filterExists := len(filter) > 0
+
 	if services.Provider.Name != providerName {
 		return nil, fmt.Errorf("'%s' is the only valid provider for this tool - found: %s", providerName, services.Provider.Name)
 	}
@@ -84,10 +189,16 @@ func ParseYAMLData(fileData []byte, regex string, filter string) (*Services, err
 
 	}
 
-	return &services, nil
+	return services, nil
 }
 
-func makeHTTPClient(timeout *time.Duration) http.Client {
+// makeHTTPClient uses the TLS settings of config.DefaultTLSConfig
+func makeHTTPClient(timeout *time.Duration) (http.Client, error) {
+	tlsConfig, err := config.DefaultTLSConfig.ClientConfig()
+	if err != nil {
+		return http.Client{}, err
+	}
+
 	if timeout != nil {
 		return http.Client{
 			Timeout: *timeout,
@@ -101,12 +212,19 @@ func makeHTTPClient(timeout *time.Duration) http.Client {
 				// DisableKeepAlives:     true,
 				IdleConnTimeout:       120 * time.Millisecond,
 				ExpectContinueTimeout: 1500 * time.Millisecond,
+				TLSClientConfig:       tlsConfig,
 			},
-		}
+		}, nil
+	}
+
+	if tlsConfig != nil {
+		transport := http.DefaultTransport.(*http.Transport).Clone()
+		transport.TLSClientConfig = tlsConfig
+		return http.Client{Transport: transport}, nil
 	}
 
 	// This should be used for faas-cli invoke etc.
-	return http.Client{}
+	return http.Client{}, nil
 }
 
 // fetchYAML pulls in file from remote location such as GitHub raw file-view
@@ -117,7 +235,10 @@ func fetchYAML(address *url.URL) ([]byte, error) {
 	}
 
 	timeout := 120 * time.Second
-	client := makeHTTPClient(&timeout)
+	client, err := makeHTTPClient(&timeout)
+	if err != nil {
+		return nil, err
+	}
 
 	res, err := client.Do(req)
 	if err != nil {
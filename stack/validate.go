@@ -0,0 +1,367 @@
+// Copyright (c) OpenFaaS Project 2018. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package stack
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+
+	yaml "gopkg.in/yaml.v2"
+)
+
+var (
+	memoryQuantityPattern = regexp.MustCompile(`^[0-9]+(\.[0-9]+)?([kKmMgGtTpPeE]i?)?$`)
+	cpuQuantityPattern    = regexp.MustCompile(`^([0-9]+(\.[0-9]+)?|\.[0-9]+|[0-9]+m)$`)
+	yamlKeyPattern        = regexp.MustCompile(`^("[^"]*"|'[^']*'|[^\s:#"'][^:#]*?)\s*:(\s|$)`)
+)
+
+// ValidationError describes a single problem found in a stack file
+type ValidationError struct {
+	File    string
+	Line    int
+	Message string
+}
+
+func (e ValidationError) Error() string {
+	if e.Line > 0 {
+		return fmt.Sprintf("%s:%d: %s", e.File, e.Line, e.Message)
+	}
+	if len(e.File) > 0 {
+		return fmt.Sprintf("%s: %s", e.File, e.Message)
+	}
+	return e.Message
+}
+
+// ValidationErrors is the list of problems found in one or more stack files
+type ValidationErrors []ValidationError
+
+func (e ValidationErrors) Error() string {
+	var messages []string
+	for _, validationErr := range e {
+		messages = append(messages, validationErr.Error())
+	}
+	return strings.Join(messages, "\n")
+}
+
+// IsValidMemoryQuantity tells whether a memory limit or request such as "128m" or "1Gi" is well-formed
+func IsValidMemoryQuantity(quantity string) bool {
+	return memoryQuantityPattern.MatchString(quantity)
+}
+
+// IsValidCPUQuantity tells whether a CPU limit or request such as "100m" or "0.5" is well-formed
+func IsValidCPUQuantity(quantity string) bool {
+	return cpuQuantityPattern.MatchString(quantity)
+}
+
+// ValidateYAMLFiles strictly validates one or more stack files. Each file and
+// everything it includes is checked for unknown keys, then the merged stack is
+// checked for missing images and handlers, unknown languages and malformed
+// resource quantities. A nil error means the stack is valid, otherwise the
+// error is of type ValidationErrors.
+func ValidateYAMLFiles(yamlFiles []string) error {
+	var problems ValidationErrors
+	var lineIndexes []fileLines
+
+	for _, yamlFile := range yamlFiles {
+		found, indexes, err := validateKeys(yamlFile, nil)
+		if err != nil {
+			return err
+		}
+		problems = append(problems, found...)
+		lineIndexes = append(lineIndexes, indexes...)
+	}
+
+	services, err := ParseYAMLFiles(yamlFiles, "", "")
+	if err != nil {
+		return append(problems, ValidationError{Message: err.Error()})
+	}
+
+	var names []string
+	for name := range services.Functions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		function := services.Functions[name]
+		report := func(message string, path ...string) {
+			file, line := lookupLine(lineIndexes, append([]string{"functions", name}, path...)...)
+			if line == 0 {
+				file, line = lookupLine(lineIndexes, "functions", name)
+			}
+			problems = append(problems, ValidationError{File: file, Line: line, Message: fmt.Sprintf("function %s: %s", name, message)})
+		}
+
+		if len(function.Image) == 0 {
+			report("missing image")
+		}
+
+		if !function.SkipBuild {
+			if len(function.Handler) == 0 {
+				report("missing handler")
+			}
+			if len(function.Language) > 0 && !IsValidTemplate(function.Language) {
+				report(fmt.Sprintf("unknown language %s, run 'faas-cli template pull' or check the lang value", function.Language), "lang")
+			}
+		}
+
+		for _, kind := range []string{"limits", "requests"} {
+			resources := function.Limits
+			if kind == "requests" {
+				resources = function.Requests
+			}
+			if resources == nil {
+				continue
+			}
+			if len(resources.Memory) > 0 && !IsValidMemoryQuantity(resources.Memory) {
+				report(fmt.Sprintf("invalid memory quantity %q in %s, use a value such as 128m or 1Gi", resources.Memory, kind), kind, "memory")
+			}
+			if len(resources.CPU) > 0 && !IsValidCPUQuantity(resources.CPU) {
+				report(fmt.Sprintf("invalid cpu quantity %q in %s, use a value such as 100m or 0.5", resources.CPU, kind), kind, "cpu")
+			}
+		}
+	}
+
+	if len(problems) > 0 {
+		sort.SliceStable(problems, func(i, j int) bool {
+			if problems[i].File != problems[j].File {
+				return problems[i].File < problems[j].File
+			}
+			return problems[i].Line < problems[j].Line
+		})
+		return problems
+	}
+
+	return nil
+}
+
+// fileLines maps the dotted path of every key in a file to its line number
+type fileLines struct {
+	file  string
+	lines map[string]int
+}
+
+func lookupLine(indexes []fileLines, path ...string) (string, int) {
+	key := strings.Join(path, ".")
+	for _, index := range indexes {
+		if line, found := index.lines[key]; found {
+			return index.file, line
+		}
+	}
+	if len(indexes) > 0 {
+		return indexes[0].file, 0
+	}
+	return "", 0
+}
+
+// validateKeys reports keys in a stack file, and the files it includes, which
+// do not map onto a field of the stack types.
+func validateKeys(yamlFile string, parents []string) (ValidationErrors, []fileLines, error) {
+	for _, parent := range parents {
+		if parent == yamlFile {
+			return nil, nil, fmt.Errorf("include cycle detected: %s -> %s", strings.Join(parents, " -> "), yamlFile)
+		}
+	}
+
+	fileData, err := readYAML(yamlFile)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	fileData, err = substituteEnvironment(fileData)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var document interface{}
+	if err := yaml.Unmarshal(fileData, &document); err != nil {
+		return nil, nil, fmt.Errorf("%s: %v", yamlFile, err)
+	}
+
+	index := fileLines{file: yamlFile, lines: indexKeyLines(fileData)}
+	indexes := []fileLines{index}
+
+	var problems ValidationErrors
+	checkKeys(document, reflect.TypeOf(Services{}), nil, func(path []string, message string) {
+		line := index.lines[strings.Join(path, ".")]
+		problems = append(problems, ValidationError{File: yamlFile, Line: line, Message: message})
+	})
+
+	var services Services
+	if err := yaml.Unmarshal(fileData, &services); err == nil {
+		for _, include := range services.Include {
+			found, included, err := validateKeys(resolveInclude(yamlFile, include), append(parents, yamlFile))
+			if err != nil {
+				return nil, nil, err
+			}
+			problems = append(problems, found...)
+			indexes = append(indexes, included...)
+		}
+	}
+
+	return problems, indexes, nil
+}
+
+// checkKeys walks a decoded YAML document alongside the Go type it will be
+// unmarshalled into and reports any key which has no matching field.
+func checkKeys(value interface{}, t reflect.Type, path []string, report func([]string, string)) {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	values, isMap := value.(map[interface{}]interface{})
+	if !isMap {
+		return
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		fields := yamlFields(t)
+		for key, child := range values {
+			name := fmt.Sprintf("%v", key)
+			childPath := append(append([]string{}, path...), name)
+
+			field, found := fields[name]
+			if !found {
+				message := fmt.Sprintf("unknown key %s", strings.Join(childPath, "."))
+				if suggestion := closestKey(name, fields); len(suggestion) > 0 {
+					message += fmt.Sprintf(", did you mean %s?", suggestion)
+				}
+				report(childPath, message)
+				continue
+			}
+			checkKeys(child, field.Type, childPath, report)
+		}
+	case reflect.Map:
+		for key, child := range values {
+			childPath := append(append([]string{}, path...), fmt.Sprintf("%v", key))
+			checkKeys(child, t.Elem(), childPath, report)
+		}
+	}
+}
+
+// yamlFields returns the fields of a struct keyed by the name yaml.v2 uses for them
+func yamlFields(t reflect.Type) map[string]reflect.StructField {
+	fields := make(map[string]reflect.StructField)
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if name := yamlFieldName(field); len(name) > 0 {
+			fields[name] = field
+		}
+	}
+	return fields
+}
+
+// yamlFieldName mirrors the field naming rules of yaml.v2, an empty name means the field is ignored
+func yamlFieldName(field reflect.StructField) string {
+	if len(field.PkgPath) > 0 {
+		return ""
+	}
+
+	name := strings.Split(field.Tag.Get("yaml"), ",")[0]
+	if name == "-" {
+		return ""
+	}
+	if len(name) == 0 {
+		name = strings.ToLower(field.Name)
+	}
+	return name
+}
+
+// closestKey suggests the known key nearest to an unknown one, if any is close enough
+func closestKey(key string, fields map[string]reflect.StructField) string {
+	var names []string
+	for name := range fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var suggestion string
+	best := 3
+	for _, name := range names {
+		if distance := levenshtein(key, name); distance < best {
+			best = distance
+			suggestion = name
+		}
+	}
+	return suggestion
+}
+
+func levenshtein(a string, b string) int {
+	previous := make([]int, len(b)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		current := make([]int, len(b)+1)
+		current[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			current[j] = minInt(minInt(previous[j]+1, current[j-1]+1), previous[j-1]+cost)
+		}
+		previous = current
+	}
+	return previous[len(b)]
+}
+
+func minInt(a int, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+// indexKeyLines records the line number of every mapping key in a YAML
+// document, keyed by the dotted path of parent keys. Only block-style
+// mappings are understood, which covers the stack file format.
+func indexKeyLines(fileData []byte) map[string]int {
+	type level struct {
+		indent int
+		key    string
+	}
+
+	keyLines := make(map[string]int)
+	var levels []level
+
+	for number, line := range strings.Split(string(fileData), "\n") {
+		trimmed := strings.TrimLeft(line, " ")
+		if len(trimmed) == 0 || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+		indent := len(line) - len(trimmed)
+
+		for strings.HasPrefix(trimmed, "- ") {
+			trimmed = strings.TrimLeft(trimmed[2:], " ")
+			indent = len(line) - len(trimmed)
+		}
+
+		match := yamlKeyPattern.FindStringSubmatch(trimmed)
+		if match == nil {
+			continue
+		}
+		key := strings.Trim(match[1], `"'`)
+
+		for len(levels) > 0 && levels[len(levels)-1].indent >= indent {
+			levels = levels[:len(levels)-1]
+		}
+		levels = append(levels, level{indent: indent, key: key})
+
+		var path []string
+		for _, l := range levels {
+			path = append(path, l.key)
+		}
+		if _, found := keyLines[strings.Join(path, ".")]; !found {
+			keyLines[strings.Join(path, ".")] = number + 1
+		}
+	}
+
+	return keyLines
+}
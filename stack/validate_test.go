@@ -0,0 +1,106 @@
+// Copyright (c) OpenFaaS Project 2018. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package stack
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+const invalidStack = `provider:
+  name: faas
+  gateway: http://localhost:8080
+
+functions:
+  url-ping:
+    lang: Dockerfile
+    handler: ./sample/url-ping
+    image: alexellis/faas-url-ping
+    enviroment:
+      mode: dev
+    limits:
+      memory: 128x
+      cpu: 100m
+  no-image:
+    lang: cobol
+    handler: ./sample/no-image
+    requests:
+      cpu: lots
+  prebuilt:
+    image: functions/alpine
+    skip_build: true
+`
+
+func Test_ValidateYAMLFiles(t *testing.T) {
+	dir := writeStackFiles(t, map[string]string{"stack.yml": invalidStack})
+	defer os.RemoveAll(dir)
+	yamlFile := filepath.Join(dir, "stack.yml")
+
+	err := ValidateYAMLFiles([]string{yamlFile})
+	problems, ok := err.(ValidationErrors)
+	if !ok {
+		t.Fatalf("want ValidationErrors, got %v", err)
+	}
+
+	want := ValidationErrors{
+		{File: yamlFile, Line: 10, Message: "unknown key functions.url-ping.enviroment, did you mean environment?"},
+		{File: yamlFile, Line: 13, Message: `function url-ping: invalid memory quantity "128x" in limits, use a value such as 128m or 1Gi`},
+		{File: yamlFile, Line: 15, Message: "function no-image: missing image"},
+		{File: yamlFile, Line: 16, Message: "function no-image: unknown language cobol, run 'faas-cli template pull' or check the lang value"},
+		{File: yamlFile, Line: 19, Message: `function no-image: invalid cpu quantity "lots" in requests, use a value such as 100m or 0.5`},
+	}
+
+	if !reflect.DeepEqual(problems, want) {
+		t.Errorf("want:\n%s\ngot:\n%s", want.Error(), problems.Error())
+	}
+}
+
+func Test_ValidateYAMLFiles_Valid(t *testing.T) {
+	dir := writeStackFiles(t, map[string]string{"stack.yml": TestData_1})
+	defer os.RemoveAll(dir)
+
+	// only check keys, the languages used need templates to be pulled
+	problems, _, err := validateKeys(filepath.Join(dir, "stack.yml"), nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(problems) > 0 {
+		t.Errorf("want no problems, got:\n%s", problems.Error())
+	}
+}
+
+func Test_IsValidQuantity(t *testing.T) {
+	memoryTests := map[string]bool{"128m": true, "1Gi": true, "512Mi": true, "1024": true, "1.5G": true, "128x": false, "m": false, "": false}
+	for quantity, valid := range memoryTests {
+		if IsValidMemoryQuantity(quantity) != valid {
+			t.Errorf("memory quantity %q: want valid=%t", quantity, valid)
+		}
+	}
+
+	cpuTests := map[string]bool{"100m": true, "0.5": true, ".5": true, "2": true, "1.5m": false, "lots": false, "": false}
+	for quantity, valid := range cpuTests {
+		if IsValidCPUQuantity(quantity) != valid {
+			t.Errorf("cpu quantity %q: want valid=%t", quantity, valid)
+		}
+	}
+}
+
+func Test_GenerateJSONSchema_MatchesPublishedSchema(t *testing.T) {
+	schema, err := GenerateJSONSchema()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	published, err := ioutil.ReadFile("../contrib/schema/stack.schema.json")
+	if err != nil {
+		t.Fatalf("unable to read published schema: %s", err)
+	}
+
+	if string(schema)+"\n" != string(published) {
+		t.Errorf("contrib/schema/stack.schema.json is out of date, regenerate it with: faas-cli validate --schema")
+	}
+}
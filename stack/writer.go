@@ -0,0 +1,112 @@
+// Copyright (c) OpenFaaS Project 2018. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package stack
+
+import (
+	"bytes"
+	"io/ioutil"
+	"reflect"
+	"sort"
+
+	yaml "gopkg.in/yaml.v2"
+)
+
+// MarshalServices serialises a stack deterministically: the provider comes first,
+// followed by the included files and the functions sorted by name, with a blank
+// line between them. The fields of each section are written in the order they
+// are declared and empty fields are left out, so the output only changes when
+// the stack does.
+func MarshalServices(services *Services) ([]byte, error) {
+	document := yaml.MapSlice{
+		{Key: "provider", Value: marshalValue(reflect.ValueOf(services.Provider))},
+	}
+	if len(services.Include) > 0 {
+		document = append(document, yaml.MapItem{Key: "include", Value: services.Include})
+	}
+	if len(services.Functions) > 0 {
+		document = append(document, yaml.MapItem{Key: "functions", Value: marshalFunctions(services.Functions)})
+	}
+
+	// sections are marshalled one at a time so they can be separated by a blank line
+	var sections [][]byte
+	for _, section := range document {
+		sectionData, err := yaml.Marshal(yaml.MapSlice{section})
+		if err != nil {
+			return nil, err
+		}
+		sections = append(sections, sectionData)
+	}
+	return bytes.Join(sections, []byte("\n")), nil
+}
+
+// WriteYAMLFile writes a stack to a file with MarshalServices
+func WriteYAMLFile(yamlFile string, services *Services) error {
+	fileData, err := MarshalServices(services)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(yamlFile, fileData, 0600)
+}
+
+// marshalFunctions orders functions by name
+func marshalFunctions(functions map[string]Function) yaml.MapSlice {
+	var names []string
+	for name := range functions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var marshalled yaml.MapSlice
+	for _, name := range names {
+		marshalled = append(marshalled, yaml.MapItem{Key: name, Value: marshalValue(reflect.ValueOf(functions[name]))})
+	}
+	return marshalled
+}
+
+// marshalValue converts a struct to a yaml.MapSlice of its non-empty fields in
+// the order they are declared, other values are marshalled by yaml.v2 which
+// sorts the keys of maps
+func marshalValue(value reflect.Value) interface{} {
+	for value.Kind() == reflect.Ptr {
+		if value.IsNil() {
+			return nil
+		}
+		value = value.Elem()
+	}
+	if value.Kind() != reflect.Struct {
+		return value.Interface()
+	}
+
+	fields := yaml.MapSlice{}
+	for i := 0; i < value.NumField(); i++ {
+		name := yamlFieldName(value.Type().Field(i))
+		if len(name) == 0 || isEmptyValue(value.Field(i)) {
+			continue
+		}
+		fields = append(fields, yaml.MapItem{Key: name, Value: marshalValue(value.Field(i))})
+	}
+	return fields
+}
+
+// isEmptyValue tells whether a field is left out of a marshalled stack
+func isEmptyValue(value reflect.Value) bool {
+	switch value.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		return value.IsNil() || isEmptyValue(value.Elem())
+	case reflect.Map, reflect.Slice, reflect.String:
+		return value.Len() == 0
+	case reflect.Bool:
+		return !value.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return value.Int() == 0
+	case reflect.Struct:
+		for i := 0; i < value.NumField(); i++ {
+			if len(yamlFieldName(value.Type().Field(i))) > 0 && !isEmptyValue(value.Field(i)) {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
@@ -0,0 +1,160 @@
+// Copyright (c) OpenFaaS Project 2018. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package stack
+
+import (
+	"flag"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	yaml "gopkg.in/yaml.v2"
+)
+
+var updateGolden = flag.Bool("update", false, "update the golden files of the stack writer")
+
+func Test_MarshalServices_Golden(t *testing.T) {
+	constraints := []string{"node.platform.os == linux"}
+	labels := map[string]string{"com.openfaas.scale.min": "2", "app": "shop"}
+
+	tests := []struct {
+		golden   string
+		services *Services
+	}{
+		{
+			golden: "new_function.yml",
+			services: &Services{
+				Provider: Provider{Name: "faas", GatewayURL: "http://127.0.0.1:8080"},
+				Functions: map[string]Function{
+					"echo": {Language: "python", Handler: "./echo", Image: "echo"},
+				},
+			},
+		},
+		{
+			golden: "special_characters.yml",
+			services: &Services{
+				Provider: Provider{Name: "faas", GatewayURL: "http://gateway:8080/#admin"},
+				Functions: map[string]Function{
+					"yes": {Language: "node", Handler: "./handlers/@yes", Image: "registry:5000/owner/yes:latest"},
+					"echo: debug": {
+						Language: "python",
+						Handler:  "./echo",
+						Image:    "echo",
+						Environment: map[string]string{
+							"greeting": "hello: world",
+							"empty":    "",
+							"comment":  "# not a comment",
+						},
+					},
+				},
+			},
+		},
+		{
+			golden: "all_fields.yml",
+			services: &Services{
+				Provider: Provider{Name: "faas", GatewayURL: "http://127.0.0.1:8080", Network: "func_functions", Builder: "buildah"},
+				Include:  []string{"common.yml"},
+				Functions: map[string]Function{
+					"checkout": {
+						Language:        "node",
+						Handler:         "./checkout",
+						Image:           "alexellis/checkout:0.1.0",
+						FProcess:        "node index.js",
+						Environment:     map[string]string{"write_debug": "true", "currency": "GBP"},
+						SkipBuild:       true,
+						Constraints:     &constraints,
+						EnvironmentFile: []string{"env.yml"},
+						Labels:          &labels,
+						Secrets:         []string{"stripe-key"},
+						BuildArgs:       map[string]string{"NODE_VERSION": "8"},
+						BuildOptions:    []string{"dev"},
+						Limits:          &FunctionResources{Memory: "128m", CPU: "100m"},
+						Requests:        &FunctionResources{Memory: "64m"},
+					},
+					"basket": {Language: "go", Handler: "./basket", Image: "alexellis/basket"},
+				},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.golden, func(t *testing.T) {
+			goldenFile := filepath.Join("testdata", "writer", test.golden)
+
+			marshalled, err := MarshalServices(test.services)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if *updateGolden {
+				if err := ioutil.WriteFile(goldenFile, marshalled, 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			golden, err := ioutil.ReadFile(goldenFile)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(marshalled) != string(golden) {
+				t.Errorf("%s does not match, run go test -update to regenerate it, got:\n%s", goldenFile, marshalled)
+			}
+
+			var parsed Services
+			if err := yaml.Unmarshal(golden, &parsed); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(&parsed, test.services) {
+				t.Errorf("want %s to parse back into the stack\nwant: %+v\ngot:  %+v", goldenFile, test.services, &parsed)
+			}
+		})
+	}
+}
+
+func Test_MarshalServices_Deterministic(t *testing.T) {
+	services := &Services{
+		Provider:  Provider{Name: "faas", GatewayURL: "http://127.0.0.1:8080"},
+		Functions: map[string]Function{},
+	}
+	for _, name := range []string{"c", "a", "d", "b", "e"} {
+		services.Functions[name] = Function{Language: "go", Handler: "./" + name, Image: name, Environment: map[string]string{"z": "1", "a": "2"}}
+	}
+
+	first, err := MarshalServices(services)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 10; i++ {
+		if next, _ := MarshalServices(services); string(next) != string(first) {
+			t.Fatalf("want the same output on every run, got:\n%s\nand:\n%s", first, next)
+		}
+	}
+}
+
+func Test_WriteYAMLFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "stack-writer")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	yamlFile := filepath.Join(dir, "stack.yml")
+	services := &Services{
+		Provider:  Provider{Name: "faas", GatewayURL: "http://127.0.0.1:8080"},
+		Functions: map[string]Function{"echo": {Language: "python", Handler: "./echo", Image: "echo"}},
+	}
+	if err := WriteYAMLFile(yamlFile, services); err != nil {
+		t.Fatal(err)
+	}
+
+	parsed, err := ParseYAMLFile(yamlFile, "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(parsed, services) {
+		t.Errorf("want %+v, got %+v", services, parsed)
+	}
+}
@@ -0,0 +1,77 @@
+package test
+
+import (
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// TokenServer is a stand-in OAuth2 token endpoint for the client credentials
+// grant, it issues the tokens token-1, token-2 and so on
+type TokenServer struct {
+	URL string // Shortcut to httptest.Server.URL
+	// ExpiresIn is the lifetime of the tokens issued in seconds, 0 for tokens which do not expire
+	ExpiresIn int
+	// Issued counts the tokens issued
+	Issued int
+	// Scope is the scope requested for the last token
+	Scope  string
+	server *httptest.Server
+}
+
+// MockTokenServer creates a token endpoint which accepts the client credentials given
+func MockTokenServer(t *testing.T, clientID string, clientSecret string, expiresIn int) *TokenServer {
+	s := newTokenServer(t, clientID, clientSecret, expiresIn)
+	s.server.Start()
+	s.URL = s.server.URL
+
+	return s
+}
+
+// MockTLSTokenServer creates a token endpoint served over TLS with the certificate given
+func MockTLSTokenServer(t *testing.T, certificate tls.Certificate, clientID string, clientSecret string, expiresIn int) *TokenServer {
+	s := newTokenServer(t, clientID, clientSecret, expiresIn)
+	s.server.TLS = &tls.Config{Certificates: []tls.Certificate{certificate}}
+	s.server.StartTLS()
+	s.URL = s.server.URL
+
+	return s
+}
+
+func newTokenServer(t *testing.T, clientID string, clientSecret string, expiresIn int) *TokenServer {
+	s := &TokenServer{ExpiresIn: expiresIn}
+
+	s.server = httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		id, secret, _ := r.BasicAuth()
+		if r.Method != http.MethodPost || r.FormValue("grant_type") != "client_credentials" || id != clientID || secret != clientSecret {
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte(`{"error": "invalid_client", "error_description": "client authentication failed"}`))
+			return
+		}
+
+		s.Issued++
+		s.Scope = r.FormValue("scope")
+		response := map[string]interface{}{
+			"access_token": fmt.Sprintf("token-%d", s.Issued),
+			"token_type":   "bearer",
+		}
+		if s.ExpiresIn > 0 {
+			response["expires_in"] = s.ExpiresIn
+		}
+		if err := json.NewEncoder(w).Encode(response); err != nil {
+			t.Fatal(err)
+		}
+	}))
+
+	return s
+}
+
+// Close closes the token endpoint
+func (s *TokenServer) Close() {
+	s.server.Close()
+}